@@ -1,29 +1,333 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 	"trafilea-tech-challenge/handlers"
 	"trafilea-tech-challenge/pkg/cart"
+	"trafilea-tech-challenge/pkg/catalog"
+	"trafilea-tech-challenge/pkg/coupons"
+	"trafilea-tech-challenge/pkg/inventory"
 	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+	"trafilea-tech-challenge/pkg/orders"
+	"trafilea-tech-challenge/pkg/promotions"
 	"trafilea-tech-challenge/pkg/storage"
 )
 
+// defaultReservationTTL is how long the stock of an order is held for it to be paid, unless RESERVATION_TTL says
+// otherwise.
+const defaultReservationTTL = 15 * time.Minute
+
+// reservationSweepInterval is how often the orders whose reservation expired are cancelled.
+const reservationSweepInterval = time.Minute
+
 func main() {
-	// Map used as in memory storage. For this example, we assume that one user can have only one cart
-	var localStorage = make(map[string]models.Cart)
+	repos, err := newRepositories()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	reservationTTL, err := reservationTTL()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rates, err := exchangeRates()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	promotionRegistry, err := promotions.NewRegistry(promotions.Defaults()...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// When a promotions file is given, it replaces the default promotions and is reloaded on SIGHUP
+	if promotionsFile := os.Getenv("PROMOTIONS_FILE"); promotionsFile != "" {
+		if err := loadPromotions(promotionsFile, promotionRegistry); err != nil {
+			log.Fatal(err)
+		}
+		go reloadPromotionsOnSignal(promotionsFile, promotionRegistry)
+	}
 
-	cartRepo := storage.NewCartRepo(localStorage)
-	cartService := cart.NewCart(cartRepo)
+	shipping, err := shippingMethods()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	taxes, err := taxCalculator()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mergeStrategy, err := cartMergeStrategy()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	catalogService := catalog.NewCatalog(repos.products)
+	inventoryService := inventory.NewInventory(repos.inventory, reservationTTL)
+	couponService := coupons.NewCoupons(repos.coupons)
+	cartService := cart.NewCart(repos.carts, repos.orders, catalogService, inventoryService, promotionRegistry, couponService, rates, shipping, taxes, cart.NewTimeOrderIDGenerator())
+	orderService := orders.NewOrders(repos.orders, inventoryService, couponService)
+	go expireReservations(orderService)
 
 	router := gin.Default()
+	router.Use(handlers.ErrorHandler())
 	router.POST("/carts", handlers.CreateCartHandler(cartService))
+	router.GET("/carts/:cart_id", handlers.GetCartHandler(cartService))
+	router.PUT("/carts/:cart_id/name", handlers.RenameCartHandler(cartService))
+	router.DELETE("/carts/:cart_id", handlers.DeleteCartHandler(cartService))
+	router.GET("/users/:user_id/cart", handlers.GetUserCartHandler(cartService))
+	router.GET("/users/:user_id/carts", handlers.GetUserCartsHandler(cartService))
+	router.POST("/users/:user_id/carts", handlers.CreateUserCartHandler(cartService))
+	router.GET("/guests/:guest_token/cart", handlers.GetGuestCartHandler(cartService))
+	router.POST("/users/:user_id/cart/merge", handlers.MergeGuestCartHandler(cartService, mergeStrategy))
 	router.POST("/carts/:cart_id/products", handlers.AddProductToCartHandler(cartService))
 	router.PUT("/carts/:cart_id/products/:product", handlers.UpdateProductQuantityInCart(cartService))
+	router.DELETE("/carts/:cart_id/products/:product", handlers.RemoveProductFromCartHandler(cartService))
+	router.PUT("/carts/:cart_id/shipping_address", handlers.SetShippingAddressHandler(cartService))
+	router.PUT("/carts/:cart_id/billing_address", handlers.SetBillingAddressHandler(cartService))
+	router.PUT("/carts/:cart_id/email", handlers.SetEmailHandler(cartService))
+	router.PUT("/carts/:cart_id/delivery_method", handlers.SetDeliveryMethodHandler(cartService))
+	router.POST("/carts/:cart_id/coupons", handlers.ApplyCouponHandler(cartService))
+	router.DELETE("/carts/:cart_id/coupons/:code", handlers.RemoveCouponHandler(cartService))
 	router.POST("/carts/:cart_id/orders", handlers.CreateOrderForCart(cartService))
+	router.GET("/orders/:order_id", handlers.GetOrderHandler(cartService))
+	router.GET("/users/:user_id/orders", handlers.GetUserOrdersHandler(cartService))
+	router.POST("/orders/:order_id/pay", handlers.TransitionOrderHandler(orderService, models.OrderPaid))
+	router.POST("/orders/:order_id/fulfill", handlers.TransitionOrderHandler(orderService, models.OrderFulfilled))
+	router.POST("/orders/:order_id/ship", handlers.TransitionOrderHandler(orderService, models.OrderShipped))
+	router.POST("/orders/:order_id/deliver", handlers.TransitionOrderHandler(orderService, models.OrderDelivered))
+	router.POST("/orders/:order_id/cancel", handlers.TransitionOrderHandler(orderService, models.OrderCancelled))
+	router.POST("/orders/:order_id/refund", handlers.TransitionOrderHandler(orderService, models.OrderRefunded))
+	router.GET("/products", handlers.GetProductsHandler(catalogService))
+	router.GET("/products/:sku", handlers.GetProductHandler(catalogService))
+
+	// The catalog, the stock and the coupons are managed by the admins, who authenticate with the ADMIN_TOKEN as
+	// bearer token
+	admin := router.Group("/admin", handlers.RequireAdminToken(os.Getenv("ADMIN_TOKEN")))
+	admin.POST("/products", handlers.CreateProductHandler(catalogService))
+	admin.PUT("/products/:sku", handlers.UpdateProductHandler(catalogService))
+	admin.DELETE("/products/:sku", handlers.DeleteProductHandler(catalogService))
+	admin.GET("/stock/:sku", handlers.GetStockHandler(inventoryService))
+	admin.PUT("/stock/:sku", handlers.SetStockHandler(inventoryService))
+	admin.POST("/coupons", handlers.CreateCouponHandler(couponService))
+	admin.GET("/coupons/:code", handlers.GetCouponHandler(couponService))
 
-	err := router.Run(":8080")
+	err = router.Run(":8080")
 	if err != nil {
 		log.Fatal(err)
 	}
 }
+
+type repositories struct {
+	carts     storage.CartRepository
+	orders    storage.OrderRepository
+	products  storage.ProductRepository
+	inventory storage.InventoryRepository
+	coupons   storage.CouponRepository
+}
+
+// newRepositories picks the storage backend from the STORAGE_BACKEND env var: "memory" (default), "file" or "sql".
+// The file and sql backends keep their data in STORAGE_DIR, "data" by default.
+func newRepositories() (repositories, error) {
+	switch backend := os.Getenv("STORAGE_BACKEND"); backend {
+	case "", "memory":
+		// Map used as in memory storage, holding every cart by its ID
+		var localStorage = make(map[string]models.Cart)
+		return repositories{
+			carts:     storage.NewCartRepo(localStorage),
+			orders:    storage.NewOrderRepo(),
+			products:  storage.NewProductRepo(),
+			inventory: storage.NewInventoryRepo(),
+			coupons:   storage.NewCouponRepo(),
+		}, nil
+	case "file":
+		cartRepo, err := storage.NewFileCartRepo(storageDir(), 0)
+		if err != nil {
+			return repositories{}, err
+		}
+		orderRepo, err := storage.NewFileOrderRepo(storageDir(), 0)
+		if err != nil {
+			return repositories{}, err
+		}
+		productRepo, err := storage.NewFileProductRepo(storageDir(), 0)
+		if err != nil {
+			return repositories{}, err
+		}
+		inventoryRepo, err := storage.NewFileInventoryRepo(storageDir(), 0)
+		if err != nil {
+			return repositories{}, err
+		}
+		couponRepo, err := storage.NewFileCouponRepo(storageDir(), 0)
+		if err != nil {
+			return repositories{}, err
+		}
+		return repositories{carts: cartRepo, orders: orderRepo, products: productRepo, inventory: inventoryRepo, coupons: couponRepo}, nil
+	case "sql":
+		if err := os.MkdirAll(storageDir(), 0o755); err != nil {
+			return repositories{}, err
+		}
+		db, err := storage.OpenSQLite(filepath.Join(storageDir(), "carts.db"))
+		if err != nil {
+			return repositories{}, err
+		}
+		return repositories{
+			carts:     storage.NewSQLCartRepo(db),
+			orders:    storage.NewSQLOrderRepo(db),
+			products:  storage.NewSQLProductRepo(db),
+			inventory: storage.NewSQLInventoryRepo(db),
+			coupons:   storage.NewSQLCouponRepo(db),
+		}, nil
+	default:
+		return repositories{}, errors.New(fmt.Sprintf("unknown storage backend %v", backend))
+	}
+}
+
+func storageDir() string {
+	if dir := os.Getenv("STORAGE_DIR"); dir != "" {
+		return dir
+	}
+
+	return "data"
+}
+
+// reservationTTL reads how long reservations last from the RESERVATION_TTL env var, as a duration such as "30m".
+func reservationTTL() (time.Duration, error) {
+	value := os.Getenv("RESERVATION_TTL")
+	if value == "" {
+		return defaultReservationTTL, nil
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		return 0, errors.New(fmt.Sprintf("invalid RESERVATION_TTL %v", value))
+	}
+
+	return ttl, nil
+}
+
+// cartMergeStrategy reads how guest carts are merged into the carts of users from the CART_MERGE_STRATEGY env var,
+// "sum" by default.
+func cartMergeStrategy() (cart.MergeStrategy, error) {
+	value := os.Getenv("CART_MERGE_STRATEGY")
+	if value == "" {
+		return cart.MergeSum, nil
+	}
+
+	strategy := cart.MergeStrategy(value)
+	if !strategy.Valid() {
+		return "", errors.New(fmt.Sprintf("invalid CART_MERGE_STRATEGY %v, try one of %v", value, cart.MergeStrategies))
+	}
+
+	return strategy, nil
+}
+
+// exchangeRates reads the currencies the shop sells in from the rates file at RATES_FILE. Without it, the shop only
+// sells in the catalog currency.
+func exchangeRates() (money.Rates, error) {
+	path := os.Getenv("RATES_FILE")
+	if path == "" {
+		return money.NewRates(money.DefaultCurrency)
+	}
+
+	rates, err := money.LoadRates(path)
+	if err != nil {
+		return money.Rates{}, err
+	}
+
+	if rates.Base != money.DefaultCurrency {
+		return money.Rates{}, errors.New(fmt.Sprintf("%v: rates must convert from %v, the catalog currency", path, money.DefaultCurrency))
+	}
+
+	return rates, nil
+}
+
+// shippingMethods reads the ways the shop ships from the rules file at SHIPPING_FILE. Without it, every cart is shipped
+// at a flat rate.
+func shippingMethods() (cart.ShippingMethods, error) {
+	path := os.Getenv("SHIPPING_FILE")
+	if path == "" {
+		return cart.DefaultShippingMethods(), nil
+	}
+
+	rules, err := cart.LoadShippingRules(path)
+	if err != nil {
+		return cart.ShippingMethods{}, err
+	}
+
+	methods, err := cart.NewRulesShippingMethods(rules)
+	if err != nil {
+		return cart.ShippingMethods{}, errors.New(fmt.Sprintf("%v: %v", path, err))
+	}
+
+	return methods, nil
+}
+
+// taxCalculator reads the tax rates by region and category from the rules file at TAX_RULES_FILE. Without it, orders
+// aren't taxed.
+func taxCalculator() (cart.TaxCalculator, error) {
+	path := os.Getenv("TAX_RULES_FILE")
+	if path == "" {
+		return cart.NewNoTaxCalculator(), nil
+	}
+
+	rules, err := cart.LoadTaxRules(path)
+	if err != nil {
+		return nil, err
+	}
+
+	calculator, err := cart.NewRulesTaxCalculator(rules)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("%v: %v", path, err))
+	}
+
+	return calculator, nil
+}
+
+// expireReservations periodically cancels the orders that weren't paid before their reservation expired, so their
+// stock can be ordered again.
+func expireReservations(orderService orders.Orders) {
+	for range time.Tick(reservationSweepInterval) {
+		cancelled, err := orderService.ExpireReservations()
+		if err != nil {
+			log.Printf("reservations not expired: %v", err)
+		}
+		if cancelled > 0 {
+			log.Printf("%v orders cancelled as their reservation expired", cancelled)
+		}
+	}
+}
+
+func loadPromotions(path string, registry promotions.Registry) error {
+	definitions, err := promotions.LoadDefinitions(path)
+	if err != nil {
+		return err
+	}
+
+	return registry.Replace(definitions)
+}
+
+// reloadPromotionsOnSignal reloads the promotions file every time the process gets a SIGHUP.
+// An invalid file is logged and the promotions currently running are kept.
+func reloadPromotionsOnSignal(path string, registry promotions.Registry) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		if err := loadPromotions(path, registry); err != nil {
+			log.Printf("promotions not reloaded: %v", err)
+			continue
+		}
+		log.Printf("promotions reloaded from %v", path)
+	}
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"strings"
+)
+
+// RequireAdminToken only lets through the requests carrying the token as a bearer token in their Authorization
+// header. Without a token every request is rejected, so admin endpoints are never left open by mistake.
+func RequireAdminToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" || !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			_ = c.Error(errUnauthorized)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
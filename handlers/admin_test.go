@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireAdminToken(t *testing.T) {
+	tests := []struct {
+		name           string
+		token          string
+		authorization  string
+		expectedStatus int
+	}{
+		{name: "valid token", token: "secret", authorization: "Bearer secret", expectedStatus: http.StatusNoContent},
+		{name: "wrong token", token: "secret", authorization: "Bearer guess", expectedStatus: http.StatusUnauthorized},
+		{name: "no bearer token", token: "secret", authorization: "secret", expectedStatus: http.StatusUnauthorized},
+		{name: "no authorization", token: "secret", expectedStatus: http.StatusUnauthorized},
+		{name: "no token configured", token: "", authorization: "Bearer ", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			r := gin.Default()
+			r.Use(ErrorHandler())
+			r.DELETE("/admin/products/:sku", RequireAdminToken(tt.token), func(c *gin.Context) {
+				c.Status(http.StatusNoContent)
+			})
+			req, err := http.NewRequest("DELETE", "/admin/products/COF-001", nil)
+			require.NoError(t, err)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(w, req)
+
+			// Then
+			require.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"trafilea-tech-challenge/pkg/catalog"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+)
+
+// productRequest is the body of the admin requests that create or update a product.
+type productRequest struct {
+	SKU      string        `json:"sku"`
+	Name     string        `json:"name"`
+	Category string        `json:"category"`
+	Price    money.Money   `json:"price"`
+	Prices   []money.Money `json:"prices"`
+	Weight   int           `json:"weight"`
+}
+
+func (r productRequest) product() models.Product {
+	return models.Product{
+		SKU:      r.SKU,
+		Name:     r.Name,
+		Category: r.Category,
+		Price:    r.Price,
+		Prices:   r.Prices,
+		Weight:   r.Weight,
+	}
+}
+
+func GetProductsHandler(catalogService catalog.Catalog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		products, err := catalogService.GetProducts()
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, products)
+	}
+}
+
+func GetProductHandler(catalogService catalog.Catalog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		product, err := catalogService.GetProduct(c.Param("sku"))
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, product)
+	}
+}
+
+func CreateProductHandler(catalogService catalog.Catalog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request productRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			_ = c.Error(bindError(err))
+			return
+		}
+
+		product, err := catalogService.AddProduct(request.product())
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, product)
+	}
+}
+
+func UpdateProductHandler(catalogService catalog.Catalog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request productRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			_ = c.Error(bindError(err))
+			return
+		}
+
+		product, err := catalogService.UpdateProduct(c.Param("sku"), request.product())
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, product)
+	}
+}
+
+func DeleteProductHandler(catalogService catalog.Catalog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := catalogService.RemoveProduct(c.Param("sku")); err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"trafilea-tech-challenge/pkg/catalog"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+)
+
+func TestCreateProduct_Success(t *testing.T) {
+	// Given
+	product := models.Product{SKU: "COF-001", Name: "coffeeA", Category: models.CoffeeCategory, Price: usd(15)}
+	catalogService := &catalog.CatalogMock{}
+	catalogService.On("AddProduct", product).Return(product, nil)
+
+	r := gin.Default()
+	r.POST("/admin/products", CreateProductHandler(catalogService))
+	reqBody := []byte(`{"sku": "COF-001", "name": "coffeeA", "category": "coffee", "price": 15}`)
+	req, err := http.NewRequest("POST", "/admin/products", bytes.NewBuffer(reqBody))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var created models.Product
+	err = json.Unmarshal(w.Body.Bytes(), &created)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, product, created)
+}
+
+func TestUpdateProduct_Success(t *testing.T) {
+	// Given
+	product := models.Product{SKU: "COF-001", Name: "coffeeA", Category: models.CoffeeCategory, Price: usd(18)}
+	catalogService := &catalog.CatalogMock{}
+	catalogService.On("UpdateProduct", "COF-001", models.Product{Name: "coffeeA", Category: models.CoffeeCategory, Price: usd(18)}).Return(product, nil)
+
+	r := gin.Default()
+	r.PUT("/admin/products/:sku", UpdateProductHandler(catalogService))
+	reqBody := []byte(`{"name": "coffeeA", "category": "coffee", "price": 18}`)
+	req, err := http.NewRequest("PUT", "/admin/products/COF-001", bytes.NewBuffer(reqBody))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestDeleteProduct_Not_Found(t *testing.T) {
+	// Given
+	catalogService := &catalog.CatalogMock{}
+	catalogService.On("RemoveProduct", "COF-001").Return(fmt.Errorf("%w: product with SKU COF-001 doesn't exist", catalog.ErrProductNotFound))
+
+	r := gin.Default()
+	r.Use(ErrorHandler())
+	r.DELETE("/admin/products/:sku", DeleteProductHandler(catalogService))
+	req, err := http.NewRequest("DELETE", "/admin/products/COF-001", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var body problem
+	err = json.Unmarshal(w.Body.Bytes(), &body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Equal(t, "product_not_found", body.Code)
+}
+
+func TestGetProducts_Success(t *testing.T) {
+	// Given
+	catalogService := &catalog.CatalogMock{}
+	catalogService.On("GetProducts").Return([]models.Product{
+		{SKU: "ACC-001", Name: "mug", Category: models.AccessoriesCategory, Price: usd(5)},
+		{SKU: "COF-001", Name: "coffeeA", Category: models.CoffeeCategory, Price: usd(15)},
+	}, nil)
+
+	r := gin.Default()
+	r.GET("/products", GetProductsHandler(catalogService))
+	req, err := http.NewRequest("GET", "/products", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var products []models.Product
+	err = json.Unmarshal(w.Body.Bytes(), &products)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, products, 2)
+}
+
+func TestGetProduct_Price_Has_Amount_And_Currency(t *testing.T) {
+	// Given
+	catalogService := &catalog.CatalogMock{}
+	catalogService.On("GetProduct", "COF-001").Return(models.Product{SKU: "COF-001", Name: "coffeeA", Category: models.CoffeeCategory, Price: money.New(1550, money.USD)}, nil)
+
+	r := gin.Default()
+	r.GET("/products/:sku", GetProductHandler(catalogService))
+	req, err := http.NewRequest("GET", "/products/COF-001", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"sku": "COF-001", "name": "coffeeA", "category": "coffee", "price": {"amount": "15.50", "currency": "USD"}}`, w.Body.String())
+}
+
+func TestCreateProduct_Invalid_Price(t *testing.T) {
+	// Given
+	catalogService := &catalog.CatalogMock{}
+
+	r := gin.Default()
+	r.Use(ErrorHandler())
+	r.POST("/admin/products", CreateProductHandler(catalogService))
+	reqBody := []byte(`{"sku": "COF-001", "name": "coffeeA", "category": "coffee", "price": {"amount": "15.505", "currency": "USD"}}`)
+	req, err := http.NewRequest("POST", "/admin/products", bytes.NewBuffer(reqBody))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	catalogService.AssertNotCalled(t, "AddProduct", mock.Anything)
+}
+
+func TestCreateProduct_With_Price_List(t *testing.T) {
+	// Given
+	product := models.Product{SKU: "COF-001", Name: "coffeeA", Category: models.CoffeeCategory, Price: usd(15),
+		Prices: []money.Money{money.New(1350, money.EUR)}}
+	catalogService := &catalog.CatalogMock{}
+	catalogService.On("AddProduct", product).Return(product, nil)
+
+	r := gin.Default()
+	r.POST("/admin/products", CreateProductHandler(catalogService))
+	reqBody := []byte(`{"sku": "COF-001", "name": "coffeeA", "category": "coffee", "price": 15,
+		"prices": [{"amount": "13.50", "currency": "EUR"}]}`)
+	req, err := http.NewRequest("POST", "/admin/products", bytes.NewBuffer(reqBody))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"sku": "COF-001", "name": "coffeeA", "category": "coffee",
+		"price": {"amount": "15.00", "currency": "USD"}, "prices": [{"amount": "13.50", "currency": "EUR"}]}`, w.Body.String())
+}
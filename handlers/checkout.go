@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"trafilea-tech-challenge/pkg/cart"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+// SetShippingAddressHandler sets where the order of the cart is shipped to, which also decides its shipping and taxes.
+func SetShippingAddressHandler(cartService cart.Cart) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var address models.Address
+		if err := c.ShouldBindJSON(&address); err != nil {
+			_ = c.Error(bindError(err))
+			return
+		}
+
+		updateCheckout(c, cartService, models.Checkout{ShippingAddress: &address})
+	}
+}
+
+func SetBillingAddressHandler(cartService cart.Cart) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var address models.Address
+		if err := c.ShouldBindJSON(&address); err != nil {
+			_ = c.Error(bindError(err))
+			return
+		}
+
+		updateCheckout(c, cartService, models.Checkout{BillingAddress: &address})
+	}
+}
+
+// SetEmailHandler sets the email the shop contacts the user at about the order of the cart.
+func SetEmailHandler(cartService cart.Cart) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request struct {
+			Email string `json:"email" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			_ = c.Error(bindError(err))
+			return
+		}
+
+		updateCheckout(c, cartService, models.Checkout{Email: request.Email})
+	}
+}
+
+// SetDeliveryMethodHandler chooses which of the shipping methods of the shop the order of the cart is shipped with.
+func SetDeliveryMethodHandler(cartService cart.Cart) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request struct {
+			Method string `json:"method" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			_ = c.Error(bindError(err))
+			return
+		}
+
+		updateCheckout(c, cartService, models.Checkout{DeliveryMethod: request.Method})
+	}
+}
+
+func updateCheckout(c *gin.Context, cartService cart.Cart, checkout models.Checkout) {
+	updatedCart, err := cartService.UpdateCheckout(c.Param("cart_id"), checkout)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedCart)
+}
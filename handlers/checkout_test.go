@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"trafilea-tech-challenge/pkg/cart"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+func TestUpdateCheckout_Success(t *testing.T) {
+	address := models.Address{Name: "Ada Lovelace", Line1: "1 Main St", City: "New York", Region: "NY", PostalCode: "10001", Country: "US"}
+	addressBody := `{"name": "Ada Lovelace", "line1": "1 Main St", "city": "New York", "region": "NY", "postal_code": "10001", "country": "US"}`
+
+	tests := []struct {
+		name     string
+		path     string
+		handler  func(cart.Cart) gin.HandlerFunc
+		body     string
+		checkout models.Checkout
+	}{
+		{name: "shipping address", path: "shipping_address", handler: SetShippingAddressHandler, body: addressBody, checkout: models.Checkout{ShippingAddress: &address}},
+		{name: "billing address", path: "billing_address", handler: SetBillingAddressHandler, body: addressBody, checkout: models.Checkout{BillingAddress: &address}},
+		{name: "email", path: "email", handler: SetEmailHandler, body: `{"email": "ada@example.com"}`, checkout: models.Checkout{Email: "ada@example.com"}},
+		{name: "delivery method", path: "delivery_method", handler: SetDeliveryMethodHandler, body: `{"method": "express"}`, checkout: models.Checkout{DeliveryMethod: "express"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			cartService := &cart.CartMock{}
+			cartService.On("UpdateCheckout", "123", tt.checkout).Return(models.Cart{ID: "123", Checkout: tt.checkout}, nil)
+
+			r := gin.Default()
+			r.PUT("/carts/:cart_id/"+tt.path, tt.handler(cartService))
+			req, err := http.NewRequest("PUT", "/carts/123/"+tt.path, bytes.NewBufferString(tt.body))
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(w, req)
+
+			// Then
+			var updated models.Cart
+			err = json.Unmarshal(w.Body.Bytes(), &updated)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, tt.checkout, updated.Checkout)
+			cartService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSetEmail_Missing_Email(t *testing.T) {
+	// Given
+	cartService := &cart.CartMock{}
+
+	r := gin.Default()
+	r.Use(ErrorHandler())
+	r.PUT("/carts/:cart_id/email", SetEmailHandler(cartService))
+	req, err := http.NewRequest("PUT", "/carts/123/email", bytes.NewBufferString(`{}`))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	cartService.AssertNotCalled(t, "UpdateCheckout")
+}
+
+func TestSetShippingAddress_Invalid_Address(t *testing.T) {
+	// Given
+	address := models.Address{Name: "Ada Lovelace", Line1: "1 Main St", City: "New York", PostalCode: "10001", Country: "USA"}
+	cartService := &cart.CartMock{}
+	cartService.On("UpdateCheckout", "123", models.Checkout{ShippingAddress: &address}).Return(models.Cart{},
+		fmt.Errorf("%w: shipping address country \"USA\" is not an ISO 3166-1 alpha-2 code", cart.ErrValidation))
+
+	r := gin.Default()
+	r.Use(ErrorHandler())
+	r.PUT("/carts/:cart_id/shipping_address", SetShippingAddressHandler(cartService))
+	body := `{"name": "Ada Lovelace", "line1": "1 Main St", "city": "New York", "postal_code": "10001", "country": "USA"}`
+	req, err := http.NewRequest("PUT", "/carts/123/shipping_address", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
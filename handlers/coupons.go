@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"trafilea-tech-challenge/pkg/cart"
+	"trafilea-tech-challenge/pkg/coupons"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+// ApplyCouponHandler applies a coupon code to the cart, redeemed once the order of the cart is placed.
+func ApplyCouponHandler(cartService cart.Cart) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request struct {
+			Code string `json:"code" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			_ = c.Error(bindError(err))
+			return
+		}
+
+		updatedCart, err := cartService.ApplyCoupon(c.Param("cart_id"), request.Code)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, updatedCart)
+	}
+}
+
+func RemoveCouponHandler(cartService cart.Cart) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		updatedCart, err := cartService.RemoveCoupon(c.Param("cart_id"), c.Param("code"))
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, updatedCart)
+	}
+}
+
+func CreateCouponHandler(couponService coupons.Coupons) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var coupon models.Coupon
+		if err := c.ShouldBindJSON(&coupon); err != nil {
+			_ = c.Error(bindError(err))
+			return
+		}
+
+		created, err := couponService.CreateCoupon(coupon)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, created)
+	}
+}
+
+// GetCouponHandler returns the coupon with the code, with how many times it was redeemed.
+func GetCouponHandler(couponService coupons.Coupons) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		coupon, err := couponService.GetCoupon(c.Param("code"))
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, coupon)
+	}
+}
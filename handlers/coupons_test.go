@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"trafilea-tech-challenge/pkg/cart"
+	"trafilea-tech-challenge/pkg/coupons"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+func TestApplyCoupon_Success(t *testing.T) {
+	// Given
+	cartService := &cart.CartMock{}
+	cartService.On("ApplyCoupon", "123", "TENOFF").Return(models.Cart{ID: "123", Coupons: []string{"TENOFF"}}, nil)
+
+	r := gin.Default()
+	r.POST("/carts/:cart_id/coupons", ApplyCouponHandler(cartService))
+	req, err := http.NewRequest("POST", "/carts/123/coupons", bytes.NewBufferString(`{"code": "TENOFF"}`))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var updated models.Cart
+	err = json.Unmarshal(w.Body.Bytes(), &updated)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, []string{"TENOFF"}, updated.Coupons)
+}
+
+func TestApplyCoupon_Error(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "unknown coupon",
+			err:            fmt.Errorf("%w: coupon TENOFF doesn't exist", coupons.ErrCouponNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "coupon_not_found",
+		},
+		{
+			name:           "not applicable",
+			err:            fmt.Errorf("%w: coupon TENOFF needs a subtotal of at least 50.00 USD", coupons.ErrCouponNotApplicable),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "coupon_not_applicable",
+		},
+		{
+			name:           "exhausted",
+			err:            fmt.Errorf("%w: coupon TENOFF has no redemptions left", coupons.ErrCouponExhausted),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "coupon_exhausted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			cartService := &cart.CartMock{}
+			cartService.On("ApplyCoupon", "123", "TENOFF").Return(models.Cart{}, tt.err)
+
+			r := gin.Default()
+			r.Use(ErrorHandler())
+			r.POST("/carts/:cart_id/coupons", ApplyCouponHandler(cartService))
+			req, err := http.NewRequest("POST", "/carts/123/coupons", bytes.NewBufferString(`{"code": "TENOFF"}`))
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(w, req)
+
+			// Then
+			var body problem
+			err = json.Unmarshal(w.Body.Bytes(), &body)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedStatus, w.Code)
+			require.Equal(t, tt.expectedCode, body.Code)
+		})
+	}
+}
+
+func TestRemoveCoupon_Not_In_Cart(t *testing.T) {
+	// Given
+	cartService := &cart.CartMock{}
+	cartService.On("RemoveCoupon", "123", "TENOFF").Return(models.Cart{}, fmt.Errorf("%w: coupon TENOFF is not applied to the cart", cart.ErrCouponNotInCart))
+
+	r := gin.Default()
+	r.Use(ErrorHandler())
+	r.DELETE("/carts/:cart_id/coupons/:code", RemoveCouponHandler(cartService))
+	req, err := http.NewRequest("DELETE", "/carts/123/coupons/TENOFF", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var body problem
+	err = json.Unmarshal(w.Body.Bytes(), &body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Equal(t, "coupon_not_in_cart", body.Code)
+}
+
+func TestCreateCoupon_Success(t *testing.T) {
+	// Given
+	coupon := models.Coupon{Code: "TENOFF", Effect: "fixed_off", Amount: usd(10), MinSubtotal: usd(50), MaxRedemptionsPerUser: 1}
+	couponService := &coupons.CouponsMock{}
+	couponService.On("CreateCoupon", coupon).Return(coupon, nil)
+
+	r := gin.Default()
+	r.POST("/admin/coupons", CreateCouponHandler(couponService))
+	reqBody := []byte(`{"code": "TENOFF", "effect": "fixed_off", "amount": 10, "min_subtotal": 50, "max_redemptions_per_user": 1}`)
+	req, err := http.NewRequest("POST", "/admin/coupons", bytes.NewBuffer(reqBody))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var created models.Coupon
+	err = json.Unmarshal(w.Body.Bytes(), &created)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, coupon, created)
+}
+
+func TestCreateCoupon_Invalid(t *testing.T) {
+	// Given
+	coupon := models.Coupon{Code: "TENOFF", Effect: "free_item"}
+	couponService := &coupons.CouponsMock{}
+	couponService.On("CreateCoupon", coupon).Return(models.Coupon{}, fmt.Errorf("%w: effect \"free_item\" is not supported", coupons.ErrInvalidCoupon))
+
+	r := gin.Default()
+	r.Use(ErrorHandler())
+	r.POST("/admin/coupons", CreateCouponHandler(couponService))
+	req, err := http.NewRequest("POST", "/admin/coupons", bytes.NewBufferString(`{"code": "TENOFF", "effect": "free_item"}`))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var body problem
+	err = json.Unmarshal(w.Body.Bytes(), &body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	require.Equal(t, "invalid_coupon", body.Code)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"trafilea-tech-challenge/pkg/cart"
+	"trafilea-tech-challenge/pkg/catalog"
+	"trafilea-tech-challenge/pkg/coupons"
+	"trafilea-tech-challenge/pkg/inventory"
+	"trafilea-tech-challenge/pkg/orders"
+)
+
+const problemContentType = "application/problem+json"
+
+var (
+	errBadRequest   = errors.New("malformed request")
+	errUnauthorized = errors.New("unauthorized")
+)
+
+// problem is the body of every error response, following RFC 7807. Code identifies the error for clients.
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+	Code   string `json:"code"`
+}
+
+// errorMappings maps the domain errors to the status and code of their response, checked in order with errors.Is.
+var errorMappings = []struct {
+	target error
+	status int
+	code   string
+}{
+	{target: errBadRequest, status: http.StatusBadRequest, code: "bad_request"},
+	{target: errUnauthorized, status: http.StatusUnauthorized, code: "unauthorized"},
+	{target: cart.ErrCartNotFound, status: http.StatusNotFound, code: "cart_not_found"},
+	{target: cart.ErrProductNotInCart, status: http.StatusNotFound, code: "product_not_in_cart"},
+	{target: cart.ErrOrderNotFound, status: http.StatusNotFound, code: "order_not_found"},
+	{target: catalog.ErrProductNotFound, status: http.StatusNotFound, code: "product_not_found"},
+	{target: coupons.ErrCouponNotFound, status: http.StatusNotFound, code: "coupon_not_found"},
+	{target: cart.ErrCouponNotInCart, status: http.StatusNotFound, code: "coupon_not_in_cart"},
+	{target: catalog.ErrProductExists, status: http.StatusConflict, code: "product_exists"},
+	{target: coupons.ErrCouponExists, status: http.StatusConflict, code: "coupon_exists"},
+	{target: coupons.ErrCouponExhausted, status: http.StatusConflict, code: "coupon_exhausted"},
+	{target: cart.ErrCartCheckedOut, status: http.StatusConflict, code: "cart_checked_out"},
+	{target: cart.ErrOrderExists, status: http.StatusConflict, code: "order_exists"},
+	{target: orders.ErrIllegalTransition, status: http.StatusConflict, code: "illegal_transition"},
+	{target: inventory.ErrInsufficientStock, status: http.StatusConflict, code: "insufficient_stock"},
+	{target: orders.ErrReservationExpired, status: http.StatusConflict, code: "reservation_expired"},
+	{target: cart.ErrConflict, status: http.StatusConflict, code: "conflict"},
+	{target: cart.ErrValidation, status: http.StatusUnprocessableEntity, code: "validation_failed"},
+	{target: catalog.ErrInvalidProduct, status: http.StatusUnprocessableEntity, code: "invalid_product"},
+	{target: inventory.ErrInvalidStock, status: http.StatusUnprocessableEntity, code: "invalid_stock"},
+	{target: catalog.ErrPriceNotFound, status: http.StatusUnprocessableEntity, code: "price_not_found"},
+	{target: coupons.ErrInvalidCoupon, status: http.StatusUnprocessableEntity, code: "invalid_coupon"},
+	{target: coupons.ErrCouponNotApplicable, status: http.StatusUnprocessableEntity, code: "coupon_not_applicable"},
+}
+
+// ErrorHandler writes the last error added to the context by a handler as a problem+json response.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		status, code := http.StatusInternalServerError, "internal_error"
+		for _, mapping := range errorMappings {
+			if errors.Is(err, mapping.target) {
+				status, code = mapping.status, mapping.code
+				break
+			}
+		}
+
+		c.Header("Content-Type", problemContentType)
+		c.JSON(status, problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(status),
+			Status: status,
+			Detail: err.Error(),
+			Code:   code,
+		})
+	}
+}
+
+func bindError(err error) error {
+	return fmt.Errorf("%w: %v", errBadRequest, err)
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"trafilea-tech-challenge/pkg/cart"
+	"trafilea-tech-challenge/pkg/catalog"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/orders"
+)
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "cart not found",
+			err:            fmt.Errorf("cart with ID 1 doesn't exist: %w", cart.ErrCartNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "cart_not_found",
+		},
+		{
+			name:           "product not in cart",
+			err:            fmt.Errorf("product coffee does not exist in cart: %w", cart.ErrProductNotInCart),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "product_not_in_cart",
+		},
+		{
+			name:           "order not found",
+			err:            fmt.Errorf("order 1234 doesn't exist: %w", cart.ErrOrderNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "order_not_found",
+		},
+		{
+			name:           "cart checked out",
+			err:            fmt.Errorf("cart with ID 1 is checked out: %w", cart.ErrCartCheckedOut),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "cart_checked_out",
+		},
+		{
+			name:           "illegal order transition",
+			err:            fmt.Errorf("%w: order 1 can't go from shipped to cancelled", orders.ErrIllegalTransition),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "illegal_transition",
+		},
+		{
+			name:           "reservation expired",
+			err:            fmt.Errorf("%w: the stock held for order 1 was given back", orders.ErrReservationExpired),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "reservation_expired",
+		},
+		{
+			name:           "conflict",
+			err:            cart.ErrConflict,
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "conflict",
+		},
+		{
+			name:           "validation",
+			err:            fmt.Errorf("%w: invalid category", cart.ErrValidation),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "validation_failed",
+		},
+		{
+			name:           "no price in the cart currency",
+			err:            fmt.Errorf("%w: product COF-001 has no price in GBP", catalog.ErrPriceNotFound),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "price_not_found",
+		},
+		{
+			name:           "malformed request",
+			err:            bindError(errors.New("unexpected EOF")),
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "bad_request",
+		},
+		{
+			name:           "unknown error",
+			err:            errors.New("disk is full"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   "internal_error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			r := gin.Default()
+			r.Use(ErrorHandler())
+			r.GET("/fail", func(c *gin.Context) {
+				_ = c.Error(tt.err)
+			})
+			req, err := http.NewRequest("GET", "/fail", nil)
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(w, req)
+
+			// Then
+			var body problem
+			err = json.Unmarshal(w.Body.Bytes(), &body)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedStatus, w.Code)
+			require.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+			require.Equal(t, tt.expectedStatus, body.Status)
+			require.Equal(t, tt.expectedCode, body.Code)
+			require.Equal(t, tt.err.Error(), body.Detail)
+		})
+	}
+}
+
+func TestAddProductToCart_Validation_Error(t *testing.T) {
+	// Given
+	cartService := &cart.CartMock{}
+	cartService.On("AddProductToCart", "1", "COF-001", 0).Return(models.Cart{}, fmt.Errorf("%w: product quantity must be greater than 0", cart.ErrValidation))
+
+	r := gin.Default()
+	r.Use(ErrorHandler())
+	r.POST("/carts/:cart_id/products", AddProductToCartHandler(cartService))
+	reqBody := []byte(`{"sku": "COF-001", "quantity": 0}`)
+	req, err := http.NewRequest("POST", "/carts/1/products", bytes.NewBuffer(reqBody))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestAddProductToCart_Missing_SKU(t *testing.T) {
+	// Given
+	cartService := &cart.CartMock{}
+
+	r := gin.Default()
+	r.Use(ErrorHandler())
+	r.POST("/carts/:cart_id/products", AddProductToCartHandler(cartService))
+	reqBody := []byte(`{"name": "coffeeA", "category": "coffee", "price": 1}`)
+	req, err := http.NewRequest("POST", "/carts/1/products", bytes.NewBuffer(reqBody))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	cartService.AssertNotCalled(t, "AddProductToCart")
+}
+
+func TestGetOrder_Malformed_ID(t *testing.T) {
+	// Given
+	cartService := &cart.CartMock{}
+
+	r := gin.Default()
+	r.Use(ErrorHandler())
+	r.GET("/orders/:order_id", GetOrderHandler(cartService))
+	req, err := http.NewRequest("GET", "/orders/abc", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	cartService.AssertNotCalled(t, "GetOrder")
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"trafilea-tech-challenge/pkg/cart"
+)
+
+// GetGuestCartHandler returns the cart of a guest, found by the token it was given when the cart was created.
+func GetGuestCartHandler(cartService cart.Cart) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		guestCart, err := cartService.GetGuestCart(c.Param("guest_token"))
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, guestCart)
+	}
+}
+
+// MergeGuestCartHandler merges the guest cart into the cart of the user who just logged in. The strategy resolving the
+// products both carts have defaults to the one the shop is configured with.
+func MergeGuestCartHandler(cartService cart.Cart, defaultStrategy cart.MergeStrategy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request struct {
+			GuestToken string             `json:"guest_token" binding:"required"`
+			Strategy   cart.MergeStrategy `json:"strategy"`
+		}
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			_ = c.Error(bindError(err))
+			return
+		}
+
+		strategy := defaultStrategy
+		if request.Strategy != "" {
+			strategy = request.Strategy
+		}
+
+		merged, err := cartService.MergeGuestCart(request.GuestToken, c.Param("user_id"), strategy)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, merged)
+	}
+}
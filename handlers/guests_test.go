@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"trafilea-tech-challenge/pkg/cart"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+func TestGetGuestCart_Success(t *testing.T) {
+	// Given
+	cartService := &cart.CartMock{}
+	cartService.On("GetGuestCart", "token1").Return(models.CartDetails{
+		Cart: models.Cart{ID: "1", GuestToken: "token1", Items: []models.LineItem{}},
+	}, nil)
+
+	r := gin.Default()
+	r.GET("/guests/:guest_token/cart", GetGuestCartHandler(cartService))
+	req, err := http.NewRequest("GET", "/guests/token1/cart", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var details models.CartDetails
+	err = json.Unmarshal(w.Body.Bytes(), &details)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "token1", details.GuestToken)
+}
+
+func TestMergeGuestCart_Success(t *testing.T) {
+	tests := []struct {
+		name             string
+		body             string
+		expectedStrategy cart.MergeStrategy
+	}{
+		{
+			name:             "default strategy",
+			body:             `{"guest_token": "token1"}`,
+			expectedStrategy: cart.MergeSum,
+		},
+		{
+			name:             "requested strategy",
+			body:             `{"guest_token": "token1", "strategy": "newest"}`,
+			expectedStrategy: cart.MergeNewest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			cartService := &cart.CartMock{}
+			cartService.On("MergeGuestCart", "token1", "19", tt.expectedStrategy).Return(models.Cart{ID: "1", UserID: "19", Items: []models.LineItem{}}, nil)
+
+			r := gin.Default()
+			r.POST("/users/:user_id/cart/merge", MergeGuestCartHandler(cartService, cart.MergeSum))
+			req, err := http.NewRequest("POST", "/users/19/cart/merge", bytes.NewBufferString(tt.body))
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(w, req)
+
+			// Then
+			var merged models.Cart
+			err = json.Unmarshal(w.Body.Bytes(), &merged)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, "19", merged.UserID)
+			cartService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMergeGuestCart_Error(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "missing guest token",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "guest cart not found",
+			body:           `{"guest_token": "token1"}`,
+			err:            fmt.Errorf("%w: guest cart doesn't exist", cart.ErrCartNotFound),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "unsupported strategy",
+			body:           `{"guest_token": "token1", "strategy": "oldest"}`,
+			err:            fmt.Errorf(`%w: merge strategy "oldest" is not supported`, cart.ErrValidation),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			cartService := &cart.CartMock{}
+			cartService.On("MergeGuestCart", "token1", "19", mock.Anything).Return(models.Cart{}, tt.err)
+
+			r := gin.Default()
+			r.Use(ErrorHandler())
+			r.POST("/users/:user_id/cart/merge", MergeGuestCartHandler(cartService, cart.MergeSum))
+			req, err := http.NewRequest("POST", "/users/19/cart/merge", bytes.NewBufferString(tt.body))
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(w, req)
+
+			// Then
+			require.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
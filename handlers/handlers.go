@@ -3,8 +3,11 @@ package handlers
 import (
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strconv"
 	"trafilea-tech-challenge/pkg/cart"
 	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+	"trafilea-tech-challenge/pkg/orders"
 )
 
 func CreateOrderForCart(cartService cart.Cart) gin.HandlerFunc {
@@ -12,7 +15,7 @@ func CreateOrderForCart(cartService cart.Cart) gin.HandlerFunc {
 		cartID := c.Param("cart_id")
 		order, err := cartService.CreateOrderForCart(cartID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			_ = c.Error(err)
 			return
 		}
 
@@ -20,6 +23,82 @@ func CreateOrderForCart(cartService cart.Cart) gin.HandlerFunc {
 	}
 }
 
+func GetOrderHandler(cartService cart.Cart) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderID, err := orderIDParam(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		order, err := cartService.GetOrder(orderID)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+// TransitionOrderHandler moves the order to the given status.
+func TransitionOrderHandler(orderService orders.Orders, to models.OrderStatus) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderID, err := orderIDParam(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		order, err := orderService.Transition(orderID, to)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+func GetUserOrdersHandler(cartService cart.Cart) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("user_id")
+		orders, err := cartService.GetUserOrders(userID)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, orders)
+	}
+}
+
+func GetCartHandler(cartService cart.Cart) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cartID := c.Param("cart_id")
+		userCart, err := cartService.GetCart(cartID)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, userCart)
+	}
+}
+
+func GetUserCartHandler(cartService cart.Cart) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("user_id")
+		userCart, err := cartService.GetUserCart(userID)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, userCart)
+	}
+}
+
 func UpdateProductQuantityInCart(cartService cart.Cart) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var request struct {
@@ -27,20 +106,29 @@ func UpdateProductQuantityInCart(cartService cart.Cart) gin.HandlerFunc {
 		}
 
 		if err := c.ShouldBindJSON(&request); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			_ = c.Error(bindError(err))
 			return
 		}
 
-		if request.NewQuantity < 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "product quantity must be greater than 0"})
+		product := c.Param("product")
+		cartID := c.Param("cart_id")
+		updatedCart, err := cartService.UpdateProductQuantity(cartID, product, request.NewQuantity)
+		if err != nil {
+			_ = c.Error(err)
 			return
 		}
 
+		c.JSON(http.StatusOK, updatedCart)
+	}
+}
+
+func RemoveProductFromCartHandler(cartService cart.Cart) gin.HandlerFunc {
+	return func(c *gin.Context) {
 		product := c.Param("product")
 		cartID := c.Param("cart_id")
-		updatedCart, err := cartService.UpdateProductQuantity(cartID, product, request.NewQuantity)
+		updatedCart, err := cartService.RemoveProduct(cartID, product)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			_ = c.Error(err)
 			return
 		}
 
@@ -51,57 +139,116 @@ func UpdateProductQuantityInCart(cartService cart.Cart) gin.HandlerFunc {
 func CreateCartHandler(cartService cart.Cart) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var request struct {
-			UserID string `json:"user_id"`
+			UserID   string         `json:"user_id"`
+			Name     string         `json:"name"`
+			Currency money.Currency `json:"currency"`
 		}
 
 		if err := c.ShouldBindJSON(&request); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			_ = c.Error(bindError(err))
+			return
+		}
+
+		userCart, err := cartService.CreateCart(request.UserID, request.Name, request.Currency)
+		if err != nil {
+			_ = c.Error(err)
 			return
 		}
 
-		userCart := cartService.CreateCart(request.UserID)
 		c.JSON(http.StatusOK, userCart)
 	}
 }
 
-func AddProductToCartHandler(cartService cart.Cart) gin.HandlerFunc {
+// GetUserCartsHandler lists the open carts of the user, in the order they were created.
+func GetUserCartsHandler(cartService cart.Cart) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		carts, err := cartService.GetUserCarts(c.Param("user_id"))
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, carts)
+	}
+}
+
+// CreateUserCartHandler creates another cart for the user, next to the ones they already have.
+func CreateUserCartHandler(cartService cart.Cart) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var request struct {
-			Name     string `json:"name"`
-			Category string `json:"category"`
-			Price    int    `json:"price"`
+			Name     string         `json:"name"`
+			Currency money.Currency `json:"currency"`
 		}
 
 		if err := c.ShouldBindJSON(&request); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			_ = c.Error(bindError(err))
 			return
 		}
 
-		if !isValidCategory(request.Category) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category"})
+		userCart, err := cartService.CreateCart(c.Param("user_id"), request.Name, request.Currency)
+		if err != nil {
+			_ = c.Error(err)
 			return
 		}
 
-		if request.Name == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "no empty values allowed"})
+		c.JSON(http.StatusOK, userCart)
+	}
+}
+
+func RenameCartHandler(cartService cart.Cart) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request struct {
+			Name string `json:"name" binding:"required"`
+		}
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			_ = c.Error(bindError(err))
 			return
 		}
 
-		if request.Price <= 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "price must be greater than 0"})
+		renamedCart, err := cartService.RenameCart(c.Param("cart_id"), request.Name)
+		if err != nil {
+			_ = c.Error(err)
 			return
 		}
 
-		cartID := c.Param("cart_id")
-		product := models.Product{
-			Name:     request.Name,
-			Category: request.Category,
-			Price:    request.Price,
+		c.JSON(http.StatusOK, renamedCart)
+	}
+}
+
+func DeleteCartHandler(cartService cart.Cart) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := cartService.DeleteCart(c.Param("cart_id")); err != nil {
+			_ = c.Error(err)
+			return
 		}
 
-		res, err := cartService.AddProductToCart(cartID, product)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// AddProductToCartHandler adds a product of the catalog to the cart. The quantity defaults to 1.
+func AddProductToCartHandler(cartService cart.Cart) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request struct {
+			SKU      string `json:"sku" binding:"required"`
+			Quantity *int   `json:"quantity"`
+		}
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			_ = c.Error(bindError(err))
+			return
+		}
+
+		quantity := 1
+		if request.Quantity != nil {
+			quantity = *request.Quantity
+		}
+
+		cartID := c.Param("cart_id")
+		res, err := cartService.AddProductToCart(cartID, request.SKU, quantity)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			_ = c.Error(err)
 			return
 		}
 
@@ -109,6 +256,11 @@ func AddProductToCartHandler(cartService cart.Cart) gin.HandlerFunc {
 	}
 }
 
-func isValidCategory(category string) bool {
-	return category == models.CoffeeCategory || category == models.EquipmentCategory || category == models.AccessoriesCategory
+func orderIDParam(c *gin.Context) (int, error) {
+	orderID, err := strconv.Atoi(c.Param("order_id"))
+	if err != nil {
+		return 0, bindError(err)
+	}
+
+	return orderID, nil
 }
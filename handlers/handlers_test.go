@@ -3,6 +3,7 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
 	"net/http"
@@ -10,12 +11,18 @@ import (
 	"testing"
 	"trafilea-tech-challenge/pkg/cart"
 	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+	"trafilea-tech-challenge/pkg/orders"
 )
 
+func usd(amount int64) money.Money {
+	return money.FromMajor(amount, money.USD)
+}
+
 func TestCreateCart_Success(t *testing.T) {
 	// Given
 	cartService := &cart.CartMock{}
-	cartService.On("CreateCart", "123").Return(models.Cart{}, nil)
+	cartService.On("CreateCart", "123", "", money.Currency("")).Return(models.Cart{}, nil)
 
 	r := gin.Default()
 	r.POST("/carts", CreateCartHandler(cartService))
@@ -32,6 +39,163 @@ func TestCreateCart_Success(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestCreateCart_In_Another_Currency(t *testing.T) {
+	// Given
+	cartService := &cart.CartMock{}
+	cartService.On("CreateCart", "123", "", money.EUR).Return(models.Cart{ID: "1", UserID: "123", Currency: money.EUR}, nil)
+
+	r := gin.Default()
+	r.POST("/carts", CreateCartHandler(cartService))
+	reqBody := []byte(`{"user_id": "123", "currency": "EUR"}`)
+	req, err := http.NewRequest("POST", "/carts", bytes.NewBuffer(reqBody))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var userCart models.Cart
+	err = json.Unmarshal(w.Body.Bytes(), &userCart)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, money.EUR, userCart.Currency)
+}
+
+func TestCreateCart_Currency_Not_Sold(t *testing.T) {
+	// Given
+	cartService := &cart.CartMock{}
+	cartService.On("CreateCart", "123", "", money.JPY).Return(models.Cart{}, fmt.Errorf("%w: currency \"JPY\" is not sold", cart.ErrValidation))
+
+	r := gin.Default()
+	r.Use(ErrorHandler())
+	r.POST("/carts", CreateCartHandler(cartService))
+	reqBody := []byte(`{"user_id": "123", "currency": "JPY"}`)
+	req, err := http.NewRequest("POST", "/carts", bytes.NewBuffer(reqBody))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestGetUserCarts_Success(t *testing.T) {
+	// Given
+	cartService := &cart.CartMock{}
+	cartService.On("GetUserCarts", "19").Return([]models.Cart{
+		{ID: "1", UserID: "19", Name: "office", Items: []models.LineItem{}},
+		{ID: "2", UserID: "19", Name: "home", Items: []models.LineItem{}},
+	}, nil)
+
+	r := gin.Default()
+	r.GET("/users/:user_id/carts", GetUserCartsHandler(cartService))
+	req, err := http.NewRequest("GET", "/users/19/carts", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var carts []models.Cart
+	err = json.Unmarshal(w.Body.Bytes(), &carts)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, carts, 2)
+	require.Equal(t, "home", carts[1].Name)
+}
+
+func TestCreateUserCart_Success(t *testing.T) {
+	// Given
+	cartService := &cart.CartMock{}
+	cartService.On("CreateCart", "19", "office", money.EUR).Return(models.Cart{ID: "1", UserID: "19", Name: "office", Currency: money.EUR}, nil)
+
+	r := gin.Default()
+	r.POST("/users/:user_id/carts", CreateUserCartHandler(cartService))
+	req, err := http.NewRequest("POST", "/users/19/carts", bytes.NewBufferString(`{"name": "office", "currency": "EUR"}`))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var userCart models.Cart
+	err = json.Unmarshal(w.Body.Bytes(), &userCart)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "office", userCart.Name)
+}
+
+func TestRenameCart_Success(t *testing.T) {
+	// Given
+	cartService := &cart.CartMock{}
+	cartService.On("RenameCart", "1", "home").Return(models.Cart{ID: "1", UserID: "19", Name: "home"}, nil)
+
+	r := gin.Default()
+	r.PUT("/carts/:cart_id/name", RenameCartHandler(cartService))
+	req, err := http.NewRequest("PUT", "/carts/1/name", bytes.NewBufferString(`{"name": "home"}`))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var renamedCart models.Cart
+	err = json.Unmarshal(w.Body.Bytes(), &renamedCart)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "home", renamedCart.Name)
+}
+
+func TestDeleteCart(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "deleted",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "checked out",
+			err:            fmt.Errorf("%w: cart with ID 1 is already checked out", cart.ErrCartCheckedOut),
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "not found",
+			err:            fmt.Errorf("%w: cart with ID 1 doesn't exist", cart.ErrCartNotFound),
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			cartService := &cart.CartMock{}
+			cartService.On("DeleteCart", "1").Return(tt.err)
+
+			r := gin.Default()
+			r.Use(ErrorHandler())
+			r.DELETE("/carts/:cart_id", DeleteCartHandler(cartService))
+			req, err := http.NewRequest("DELETE", "/carts/1", nil)
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(w, req)
+
+			// Then
+			require.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
 func TestCreateOrderForCart_Success(t *testing.T) {
 	// Given
 	cartService := &cart.CartMock{}
@@ -58,22 +222,41 @@ func TestCreateOrderForCart_Success(t *testing.T) {
 	require.Equal(t, "123", response.CartID)
 }
 
+func TestCreateOrderForCart_Missing_Checkout(t *testing.T) {
+	// Given
+	cartService := &cart.CartMock{}
+	cartService.On("CreateOrderForCart", "123").Return(models.Order{},
+		fmt.Errorf("%w: cart needs its email before ordering", cart.ErrValidation))
+
+	r := gin.Default()
+	r.Use(ErrorHandler())
+	r.POST("/carts/:cart_id/orders", CreateOrderForCart(cartService))
+	req, err := http.NewRequest("POST", "/carts/123/orders", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	require.Contains(t, w.Body.String(), "cart needs its email before ordering")
+}
+
 func TestUpdateProductQuantityInCart_Success(t *testing.T) {
 	// Given
 	cartService := &cart.CartMock{}
 	cartService.On("UpdateProductQuantity", "1", "coffeeTest", 2).Return(models.Cart{
 		ID:     "1",
 		UserID: "19",
-		Products: []models.Product{
+		Items: []models.LineItem{
 			{
-				Name:     "coffeeTest",
-				Category: models.CoffeeCategory,
-				Price:    10,
-			},
-			{
-				Name:     "coffeeTest",
-				Category: models.CoffeeCategory,
-				Price:    10,
+				Product: models.Product{
+					Name:     "coffeeTest",
+					Category: models.CoffeeCategory,
+					Price:    usd(10),
+				},
+				Quantity: 2,
 			},
 		},
 	}, nil)
@@ -97,22 +280,23 @@ func TestAddProductToCart_Success(t *testing.T) {
 	cartService := &cart.CartMock{}
 
 	product := models.Product{
+		SKU:      "COF-001",
 		Name:     "coffeeA",
 		Category: models.CoffeeCategory,
-		Price:    15,
+		Price:    usd(15),
 	}
 
-	cartService.On("AddProductToCart", "1", product).Return(models.Cart{
+	cartService.On("AddProductToCart", "1", "COF-001", 1).Return(models.Cart{
 		ID:     "1",
 		UserID: "19",
-		Products: []models.Product{
-			product,
+		Items: []models.LineItem{
+			{Product: product, Quantity: 1},
 		},
 	}, nil)
 
 	r := gin.Default()
 	r.POST("/carts/:cart_id/products", AddProductToCartHandler(cartService))
-	reqBody := []byte(`{"name": "coffeeA", "category": "coffee", "price": 15}`)
+	reqBody := []byte(`{"sku": "COF-001"}`)
 	req, err := http.NewRequest("POST", "/carts/1/products", bytes.NewBuffer(reqBody))
 	require.NoError(t, err)
 	w := httptest.NewRecorder()
@@ -124,5 +308,157 @@ func TestAddProductToCart_Success(t *testing.T) {
 	var userCart models.Cart
 	err = json.Unmarshal(w.Body.Bytes(), &userCart)
 	require.Equal(t, http.StatusOK, w.Code)
-	require.Equal(t, 1, len(userCart.Products))
+	require.Equal(t, 1, len(userCart.Items))
+}
+
+func TestRemoveProductFromCart_Success(t *testing.T) {
+	// Given
+	cartService := &cart.CartMock{}
+	cartService.On("RemoveProduct", "1", "coffeeTest").Return(models.Cart{
+		ID:     "1",
+		UserID: "19",
+		Items:  []models.LineItem{},
+	}, nil)
+
+	r := gin.Default()
+	r.DELETE("/carts/:cart_id/products/:product", RemoveProductFromCartHandler(cartService))
+	req, err := http.NewRequest("DELETE", "/carts/1/products/coffeeTest", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var userCart models.Cart
+	err = json.Unmarshal(w.Body.Bytes(), &userCart)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, 0, len(userCart.Items))
+}
+
+func TestGetCart_Success(t *testing.T) {
+	// Given
+	cartService := &cart.CartMock{}
+	cartService.On("GetCart", "1").Return(models.CartDetails{
+		Cart:    models.Cart{ID: "1", UserID: "19", Items: []models.LineItem{}},
+		Pricing: models.Pricing{Shipping: usd(20), Price: usd(0)},
+	}, nil)
+
+	r := gin.Default()
+	r.GET("/carts/:cart_id", GetCartHandler(cartService))
+	req, err := http.NewRequest("GET", "/carts/1", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var details models.CartDetails
+	err = json.Unmarshal(w.Body.Bytes(), &details)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "1", details.ID)
+	require.Equal(t, usd(20), details.Pricing.Shipping)
+}
+
+func TestGetUserCart_Success(t *testing.T) {
+	// Given
+	cartService := &cart.CartMock{}
+	cartService.On("GetUserCart", "19").Return(models.CartDetails{
+		Cart: models.Cart{ID: "1", UserID: "19", Items: []models.LineItem{}},
+	}, nil)
+
+	r := gin.Default()
+	r.GET("/users/:user_id/cart", GetUserCartHandler(cartService))
+	req, err := http.NewRequest("GET", "/users/19/cart", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var details models.CartDetails
+	err = json.Unmarshal(w.Body.Bytes(), &details)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "19", details.UserID)
+}
+
+func TestGetOrder_Success(t *testing.T) {
+	// Given
+	cartService := &cart.CartMock{}
+	cartService.On("GetOrder", 12345678).Return(models.Order{
+		CartID: "1",
+		UserID: "19",
+		Totals: models.Total{Order: 12345678},
+	}, nil)
+
+	r := gin.Default()
+	r.GET("/orders/:order_id", GetOrderHandler(cartService))
+	req, err := http.NewRequest("GET", "/orders/12345678", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var order models.Order
+	err = json.Unmarshal(w.Body.Bytes(), &order)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, 12345678, order.Totals.Order)
+}
+
+func TestGetUserOrders_Success(t *testing.T) {
+	// Given
+	cartService := &cart.CartMock{}
+	cartService.On("GetUserOrders", "19").Return([]models.Order{
+		{CartID: "1", UserID: "19", Totals: models.Total{Order: 1}},
+		{CartID: "2", UserID: "19", Totals: models.Total{Order: 2}},
+	}, nil)
+
+	r := gin.Default()
+	r.GET("/users/:user_id/orders", GetUserOrdersHandler(cartService))
+	req, err := http.NewRequest("GET", "/users/19/orders", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var orders []models.Order
+	err = json.Unmarshal(w.Body.Bytes(), &orders)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, orders, 2)
+}
+
+func TestTransitionOrder_Success(t *testing.T) {
+	// Given
+	orderService := &orders.OrdersMock{}
+	orderService.On("Transition", 12345678, models.OrderPaid).Return(models.Order{
+		Totals: models.Total{Order: 12345678},
+		Status: models.OrderPaid,
+	}, nil)
+
+	r := gin.Default()
+	r.POST("/orders/:order_id/pay", TransitionOrderHandler(orderService, models.OrderPaid))
+	req, err := http.NewRequest("POST", "/orders/12345678/pay", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var order models.Order
+	err = json.Unmarshal(w.Body.Bytes(), &order)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, models.OrderPaid, order.Status)
 }
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"trafilea-tech-challenge/pkg/inventory"
+)
+
+func GetStockHandler(inventoryService inventory.Inventory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stock, err := inventoryService.GetStock(c.Param("sku"))
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, stock)
+	}
+}
+
+// SetStockHandler sets how many units of the product the shop has, e.g. after counting them or receiving more.
+func SetStockHandler(inventoryService inventory.Inventory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request struct {
+			OnHand *int `json:"on_hand" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			_ = c.Error(bindError(err))
+			return
+		}
+
+		stock, err := inventoryService.SetStock(c.Param("sku"), *request.OnHand)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, stock)
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"trafilea-tech-challenge/pkg/inventory"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+func TestSetStock_Success(t *testing.T) {
+	// Given
+	stock := models.Stock{SKU: "COF-001", OnHand: 10, Reserved: 2}
+	inventoryService := &inventory.InventoryMock{}
+	inventoryService.On("SetStock", "COF-001", 10).Return(stock, nil)
+
+	r := gin.Default()
+	r.PUT("/admin/stock/:sku", SetStockHandler(inventoryService))
+	req, err := http.NewRequest("PUT", "/admin/stock/COF-001", bytes.NewBuffer([]byte(`{"on_hand": 10}`)))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var updated models.Stock
+	err = json.Unmarshal(w.Body.Bytes(), &updated)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, stock, updated)
+}
+
+func TestSetStock_Missing_On_Hand(t *testing.T) {
+	// Given
+	inventoryService := &inventory.InventoryMock{}
+
+	r := gin.Default()
+	r.Use(ErrorHandler())
+	r.PUT("/admin/stock/:sku", SetStockHandler(inventoryService))
+	req, err := http.NewRequest("PUT", "/admin/stock/COF-001", bytes.NewBuffer([]byte(`{}`)))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSetStock_Below_Reserved(t *testing.T) {
+	// Given
+	inventoryService := &inventory.InventoryMock{}
+	inventoryService.On("SetStock", "COF-001", 1).Return(models.Stock{}, fmt.Errorf("%w: 2 units of COF-001 are reserved", inventory.ErrInsufficientStock))
+
+	r := gin.Default()
+	r.Use(ErrorHandler())
+	r.PUT("/admin/stock/:sku", SetStockHandler(inventoryService))
+	req, err := http.NewRequest("PUT", "/admin/stock/COF-001", bytes.NewBuffer([]byte(`{"on_hand": 1}`)))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// When
+	r.ServeHTTP(w, req)
+
+	// Then
+	var body problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Equal(t, http.StatusConflict, w.Code)
+	require.Equal(t, "insufficient_stock", body.Code)
+}
@@ -1,204 +1,564 @@
 package cart
 
 import (
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
-	"math/rand"
+	"reflect"
+	"strings"
 	"time"
+	"trafilea-tech-challenge/pkg/catalog"
+	"trafilea-tech-challenge/pkg/coupons"
+	"trafilea-tech-challenge/pkg/inventory"
 	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+	"trafilea-tech-challenge/pkg/promotions"
 	"trafilea-tech-challenge/pkg/storage"
 )
 
-const (
-	fixedShippingPrice = 20
-)
+// maxOrderIDAttempts bounds how many IDs are tried when the generated one already belongs to another order.
+const maxOrderIDAttempts = 3
+
+// maxCartNameLength is how many characters the name of a cart can have.
+const maxCartNameLength = 50
 
 type Cart interface {
-	CreateCart(userID string) models.Cart
-	AddProductToCart(cartID string, product models.Product) (models.Cart, error)
+	CreateCart(userID, name string, currency money.Currency) (models.Cart, error)
+	RenameCart(cartID, name string) (models.Cart, error)
+	DeleteCart(cartID string) error
+	AddProductToCart(cartID, sku string, quantity int) (models.Cart, error)
 	UpdateProductQuantity(cartID, product string, quantity int) (models.Cart, error)
+	RemoveProduct(cartID, product string) (models.Cart, error)
+	UpdateCheckout(cartID string, checkout models.Checkout) (models.Cart, error)
+	ApplyCoupon(cartID, code string) (models.Cart, error)
+	RemoveCoupon(cartID, code string) (models.Cart, error)
 	CreateOrderForCart(cartID string) (models.Order, error)
+	GetCart(cartID string) (models.CartDetails, error)
+	GetUserCart(userID string) (models.CartDetails, error)
+	GetUserCarts(userID string) ([]models.Cart, error)
+	GetGuestCart(guestToken string) (models.CartDetails, error)
+	MergeGuestCart(guestToken, userID string, strategy MergeStrategy) (models.Cart, error)
+	GetOrder(orderID int) (models.Order, error)
+	GetUserOrders(userID string) ([]models.Order, error)
 }
 
 type cart struct {
-	CartRepo storage.CartRepository
+	CartRepo   storage.CartRepository
+	OrderRepo  storage.OrderRepository
+	Catalog    catalog.Catalog
+	Inventory  inventory.Inventory
+	Promotions promotions.Registry
+	Coupons    coupons.Coupons
+	Rates      money.Rates
+	Shipping   ShippingMethods
+	Taxes      TaxCalculator
+	OrderIDs   OrderIDGenerator
 }
 
-func NewCart(storage storage.CartRepository) Cart {
+func NewCart(storage storage.CartRepository, orders storage.OrderRepository, catalog catalog.Catalog, inventory inventory.Inventory, promotions promotions.Registry, coupons coupons.Coupons, rates money.Rates, shipping ShippingMethods, taxes TaxCalculator, orderIDs OrderIDGenerator) Cart {
 	return &cart{
-		CartRepo: storage,
+		CartRepo:   storage,
+		OrderRepo:  orders,
+		Catalog:    catalog,
+		Inventory:  inventory,
+		Promotions: promotions,
+		Coupons:    coupons,
+		Rates:      rates,
+		Shipping:   shipping,
+		Taxes:      taxes,
+		OrderIDs:   orderIDs,
 	}
 }
 
+// CreateOrderForCart places an order for the cart, shipped to its shipping address with its delivery method and
+// taxed as shipped there.
 func (c *cart) CreateOrderForCart(cartID string) (models.Order, error) {
 	userCart, err := c.CartRepo.GetCartByID(cartID)
 	if err != nil {
 		return models.Order{}, err
 	}
 
-	orderID := generateOrderID()
+	if userCart.CheckedOut {
+		return models.Order{}, ErrCartCheckedOut
+	}
+
+	if len(userCart.Items) == 0 {
+		return models.Order{}, validationError("cart is empty")
+	}
+
+	if missing := missingCheckout(userCart.Checkout); len(missing) > 0 {
+		return models.Order{}, validationError(fmt.Sprintf("cart needs its %v before ordering", strings.Join(missing, ", ")))
+	}
+
+	// Checking out locks the cart, so the order is priced with exactly the products it was placed with.
+	userCart, err = c.CartRepo.CheckoutCart(cartID)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	savedOrder, err := c.placeOrder(userCart)
+	if err != nil {
+		// The order wasn't placed, so the cart is given back to its user to change it, e.g. ordering fewer units
+		// of a product that ran out.
+		if _, reopenErr := c.CartRepo.ReopenCart(cartID); reopenErr != nil {
+			return models.Order{}, errors.Join(err, reopenErr)
+		}
+		return models.Order{}, err
+	}
+
+	return savedOrder, nil
+}
+
+// placeOrder prices, ships and taxes the order for the checked out cart, and saves it.
+func (c *cart) placeOrder(userCart models.Cart) (models.Order, error) {
+	createdAt := time.Now().UTC()
 	order := models.Order{
-		CartID: cartID,
-		Totals: models.Total{
-			Order:    orderID,
-			Shipping: fixedShippingPrice,
-		},
+		CartID:    userCart.ID,
+		UserID:    userCart.UserID,
+		Items:     userCart.Items,
+		Status:    models.OrderPending,
+		History:   []models.StatusChange{{Status: models.OrderPending, At: createdAt}},
+		CreatedAt: createdAt,
+		// The prices of the cart were converted with its rate, so the order is placed with the same one
+		ExchangeRate: userCart.ExchangeRate,
+		Checkout:     userCart.Checkout,
+		Coupons:      userCart.Coupons,
+	}
+	region := userCart.Checkout.ShippingAddress.Destination()
+
+	quote, err := c.Shipping.Quote(userCart, userCart.Checkout.DeliveryMethod, region)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	// Coupons may have expired or run out since they were applied, and the order isn't placed without them.
+	applied, err := c.checkCoupons(userCart)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	pricing := c.price(userCart, quote, applied)
+	order.Totals.Shipping = pricing.Shipping
+	order.Totals.ShippingMethod = quote.Method
+	order.Totals.ShippingRate = quote.Rate
+	order.Totals.Price = pricing.Price
+	order.Totals.Products = pricing.Products
+	order.Totals.Discounts = pricing.Discounts
+	order.Totals.Adjustments = pricing.Adjustments
+
+	taxes, err := c.Taxes.Calculate(order.Items, pricing.Discounts, region)
+	if err != nil {
+		return models.Order{}, err
+	}
+	applyTaxes(&order, taxes)
+
+	return c.saveOrder(order)
+}
+
+// applyTaxes records the taxes on the order and its line items, adding them to the price unless it included them.
+func applyTaxes(order *models.Order, taxes Taxes) {
+	order.TaxRegion = taxes.Region
+	order.Totals.Tax = taxes.Total
+	order.Totals.TaxInclusive = taxes.Inclusive
+	if !taxes.Inclusive {
+		order.Totals.Price = order.Totals.Price.Add(taxes.Total)
+	}
+
+	if len(taxes.Lines) == 0 {
+		return
+	}
+
+	order.Items = append([]models.LineItem{}, order.Items...)
+	for i := range order.Items {
+		tax := taxes.Lines[i]
+		order.Items[i].Tax = &tax
+	}
+}
+
+// saveOrder reserves the stock of the order, redeems its coupons and stores it under a new ID, retrying with another
+// one if the ID is already taken.
+func (c *cart) saveOrder(order models.Order) (models.Order, error) {
+	var err error
+	for attempt := 0; attempt < maxOrderIDAttempts; attempt++ {
+		order.Totals.Order = c.OrderIDs.NextID()
+
+		_, err = c.Inventory.Reserve(order.Totals.Order, order.Items)
+		if errors.Is(err, ErrReservationExists) {
+			continue
+		}
+		if err != nil {
+			return models.Order{}, err
+		}
+
+		// Redeeming fails if another order took the last redemptions of a coupon since the cart was priced.
+		err = c.Coupons.Redeem(order.Totals.Order, order.UserID, order.Coupons)
+		if err != nil {
+			_ = c.Inventory.Release(order.Totals.Order)
+			if errors.Is(err, ErrRedemptionExists) {
+				continue
+			}
+			return models.Order{}, err
+		}
+
+		var saved models.Order
+		saved, err = c.OrderRepo.CreateOrder(order)
+		if err == nil {
+			return saved, nil
+		}
+
+		// The order wasn't stored, so the units reserved and the coupons redeemed for it are given back.
+		_ = c.Coupons.Release(order.Totals.Order)
+		_ = c.Inventory.Release(order.Totals.Order)
+		if !errors.Is(err, ErrOrderExists) {
+			return models.Order{}, err
+		}
 	}
 
-	productsQuantityByCategory := getProductsQuantityByCategory(userCart)
-	if productsQuantityByCategory.Equipment > 3 {
-		order.Totals.Shipping = 0
+	return models.Order{}, err
+}
+
+func (c *cart) GetOrder(orderID int) (models.Order, error) {
+	return c.OrderRepo.GetOrderByID(orderID)
+}
+
+func (c *cart) GetUserOrders(userID string) ([]models.Order, error) {
+	return c.OrderRepo.GetOrdersByUserID(userID)
+}
+
+func (c *cart) GetCart(cartID string) (models.CartDetails, error) {
+	userCart, err := c.CartRepo.GetCartByID(cartID)
+	if err != nil {
+		return models.CartDetails{}, err
 	}
 
-	totalSpent, totalProducts, discount := calculateOrderDetails(userCart)
-	order.Totals.Price = totalSpent
-	order.Totals.Products = totalProducts
-	order.Totals.Discounts = discount
+	return models.CartDetails{Cart: userCart, Pricing: c.preview(userCart)}, nil
+}
 
-	return order, nil
+// GetUserCart returns the open cart the user created last.
+func (c *cart) GetUserCart(userID string) (models.CartDetails, error) {
+	userCart, err := c.CartRepo.GetCartByUserID(userID)
+	if err != nil {
+		return models.CartDetails{}, err
+	}
+
+	return models.CartDetails{Cart: userCart, Pricing: c.preview(userCart)}, nil
 }
 
 func (c *cart) UpdateProductQuantity(cartID, product string, quantity int) (models.Cart, error) {
-	updatedCart, err := c.CartRepo.UpdateProductQuantity(cartID, product, quantity)
+	if quantity < 0 {
+		return models.Cart{}, validationError("product quantity must not be negative")
+	}
+
+	userCart, err := c.CartRepo.GetCartByID(cartID)
 	if err != nil {
 		return models.Cart{}, err
 	}
+	item, ok := findItem(userCart, product)
+	if !ok {
+		if err := checkNotFree(userCart, product); err != nil {
+			return models.Cart{}, err
+		}
+	}
 
-	productsQuantityByCategory := getProductsQuantityByCategory(updatedCart)
-	productCategory := getProductCategoryByName(updatedCart, product)
-	hasFreeCoffee := true
-	if productCategory == models.CoffeeCategory {
-		hasFreeCoffee = hasAlreadyFreeCoffee(updatedCart)
+	// Products without a SKU aren't sold by the catalog, so their stock isn't tracked
+	if ok && quantity > 0 && item.Product.SKU != "" {
+		if err := c.Inventory.CheckAvailability(item.Product.SKU, quantity); err != nil {
+			return models.Cart{}, err
+		}
 	}
-	if productsQuantityByCategory.Coffee >= 2 && !hasFreeCoffee {
-		updatedCart, err = c.CartRepo.AddProduct(cartID, models.Product{
-			Name:     "extraCoffee",
-			Category: models.CoffeeCategory,
-			Price:    0,
-		})
 
-		if err != nil {
+	updatedCart, err := c.CartRepo.UpdateProductQuantity(cartID, product, quantity)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	return c.refreshFreeItems(cartID, updatedCart)
+}
+
+func (c *cart) RemoveProduct(cartID, product string) (models.Cart, error) {
+	userCart, err := c.CartRepo.GetCartByID(cartID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+	if _, ok := findItem(userCart, product); !ok {
+		if err := checkNotFree(userCart, product); err != nil {
 			return models.Cart{}, err
 		}
 	}
 
-	return updatedCart, nil
+	updatedCart, err := c.CartRepo.RemoveProduct(cartID, product)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	return c.refreshFreeItems(cartID, updatedCart)
 }
 
-func (c *cart) AddProductToCart(cartID string, product models.Product) (models.Cart, error) {
-	updatedCart, err := c.CartRepo.AddProduct(cartID, product)
+// AddProductToCart adds the product with the given SKU to the cart, at the price the catalog has for it in the cart
+// currency, as long as there are enough units in stock for the quantity the cart ends up with.
+func (c *cart) AddProductToCart(cartID, sku string, quantity int) (models.Cart, error) {
+	if quantity <= 0 {
+		return models.Cart{}, validationError("product quantity must be greater than 0")
+	}
+
+	product, err := c.Catalog.GetProduct(sku)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	userCart, err := c.CartRepo.GetCartByID(cartID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	product, err = catalog.PriceIn(product, currencyOf(userCart), userCart.ExchangeRate)
 	if err != nil {
 		return models.Cart{}, err
 	}
 
-	productsQuantityByCategory := getProductsQuantityByCategory(updatedCart)
-	hasFreeCoffee := hasAlreadyFreeCoffee(updatedCart)
-	if productsQuantityByCategory.Coffee >= 2 && !hasFreeCoffee {
-		updatedCart, err = c.CartRepo.AddProduct(cartID, models.Product{
-			Name:     "extraCoffee",
-			Category: models.CoffeeCategory,
-			Price:    0,
-		})
+	// Stock is only held once the order is placed, so the units may run out before that
+	item, _ := findItem(userCart, product.Name)
 
+	if err := c.Inventory.CheckAvailability(sku, item.Quantity+quantity); err != nil {
+		return models.Cart{}, err
+	}
+
+	updatedCart, err := c.CartRepo.AddProduct(cartID, product, quantity)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	return c.refreshFreeItems(cartID, updatedCart)
+}
+
+// CreateCart creates a cart priced in the given currency, the catalog one if it's empty, fixing the exchange rate its
+// prices are converted with. Users can have several open carts, so a new one is created every time, optionally named.
+// Without a user, a guest cart is created with a new guest token.
+func (c *cart) CreateCart(userID, name string, currency money.Currency) (models.Cart, error) {
+	name, err := cartName(name)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	if currency == "" {
+		currency = c.Rates.Base
+	}
+
+	if !c.Rates.Supports(currency) {
+		return models.Cart{}, validationError(fmt.Sprintf("currency %q is not sold, try one of %v", currency, c.Rates.Currencies()))
+	}
+
+	newCart := models.Cart{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Name:      name,
+		Items:     []models.LineItem{},
+		Currency:  currency,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if rate, ok := c.Rates.Rate(currency); ok {
+		newCart.ExchangeRate = &rate
+	}
+
+	if userID == "" {
+		token, err := newGuestToken()
 		if err != nil {
 			return models.Cart{}, err
 		}
+		newCart.GuestToken = token
 	}
 
-	return updatedCart, nil
+	return c.CartRepo.CreateCart(userID, newCart)
 }
 
-func (c *cart) CreateCart(userID string) models.Cart {
-	newCart := models.Cart{
-		ID:       uuid.New().String(),
-		UserID:   userID,
-		Products: []models.Product{},
+// GetUserCarts returns the open carts of the user, in the order they were created.
+func (c *cart) GetUserCarts(userID string) ([]models.Cart, error) {
+	return c.CartRepo.GetCartsByUserID(userID)
+}
+
+func (c *cart) RenameCart(cartID, name string) (models.Cart, error) {
+	name, err := cartName(name)
+	if err != nil {
+		return models.Cart{}, err
 	}
 
-	return c.CartRepo.CreateCart(userID, newCart)
+	if name == "" {
+		return models.Cart{}, validationError("cart name is required")
+	}
+
+	return c.CartRepo.RenameCart(cartID, name)
 }
 
-func calculateOrderDetails(cart models.Cart) (int, int, int) {
-	categoryTotals := make(map[string]int)
-	totalSpent := 0
+// DeleteCart deletes an open cart along with its products and coupons. Checked out carts can't be deleted, since
+// their orders were placed for them.
+func (c *cart) DeleteCart(cartID string) error {
+	return c.CartRepo.DeleteCart(cartID)
+}
 
-	for _, product := range cart.Products {
-		category := product.Category
-		price := product.Price
+// cartName returns the name without surrounding spaces, as long as it isn't too long.
+func cartName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if len([]rune(name)) > maxCartNameLength {
+		return "", validationError(fmt.Sprintf("cart name must have at most %v characters", maxCartNameLength))
+	}
 
-		if _, exists := categoryTotals[category]; !exists {
-			categoryTotals[category] = 0
-		}
+	return name, nil
+}
 
-		categoryTotals[category] += price
-		totalSpent += price
+// ApplyCoupon applies the coupon with the code to the cart, as long as an order for the cart could redeem it.
+// Its discount is previewed with the cart and taken once the order is placed.
+func (c *cart) ApplyCoupon(cartID, code string) (models.Cart, error) {
+	code = coupons.NormalizeCode(code)
+	if code == "" {
+		return models.Cart{}, validationError("coupon code is required")
+	}
+
+	userCart, err := c.CartRepo.GetCartByID(cartID)
+	if err != nil {
+		return models.Cart{}, err
 	}
 
-	var discount int
+	for _, applied := range userCart.Coupons {
+		if applied == code {
+			return models.Cart{}, validationError(fmt.Sprintf("coupon %v is already applied to the cart", code))
+		}
+	}
 
-	if categoryTotals[models.AccessoriesCategory] > 70 {
-		discount = int(float64(totalSpent) * 0.10)
-		totalSpent -= discount
+	if _, err := c.Coupons.Check(code, userCart); err != nil {
+		return models.Cart{}, err
 	}
 
-	return totalSpent, len(cart.Products), discount
+	return c.CartRepo.AddCoupon(cartID, code)
 }
 
-func getProductCategoryByName(cart models.Cart, productName string) string {
-	for _, product := range cart.Products {
-		if product.Name == productName {
-			return product.Category
+func (c *cart) RemoveCoupon(cartID, code string) (models.Cart, error) {
+	return c.CartRepo.RemoveCoupon(cartID, coupons.NormalizeCode(code))
+}
+
+// findItem returns the line item of the product the user added to the cart, if the cart has it.
+func findItem(userCart models.Cart, product string) (models.LineItem, bool) {
+	for _, item := range userCart.Items {
+		if !item.Free && item.Product.Name == product {
+			return item, true
 		}
 	}
 
-	return ""
+	return models.LineItem{}, false
 }
 
-func hasAlreadyFreeCoffee(cart models.Cart) bool {
-	for _, product := range cart.Products {
-		if product.Category == models.CoffeeCategory && product.Price == 0 {
-			return true
+// checkNotFree fails if the product is only in the cart as a free line item, which users can't change.
+func checkNotFree(userCart models.Cart, product string) error {
+	for _, item := range userCart.Items {
+		if item.Free && item.Product.Name == product {
+			return validationError(fmt.Sprintf("%v is given away by promotion %v and can't be changed", product, item.PromotionID))
 		}
 	}
 
-	return false
+	return nil
+}
+
+// currencyOf returns the currency of the cart, the catalog one for the carts created before carts had a currency.
+func currencyOf(userCart models.Cart) money.Currency {
+	if userCart.Currency == "" {
+		return money.DefaultCurrency
+	}
+
+	return userCart.Currency
 }
 
-func getProductsQuantityByCategory(cart models.Cart) productsByCategory {
-	prodsByCategory := productsByCategory{}
-	for _, prod := range cart.Products {
-		switch prod.Category {
-		case models.CoffeeCategory:
-			prodsByCategory.Coffee++
-		case models.EquipmentCategory:
-			prodsByCategory.Equipment++
-		case models.AccessoriesCategory:
-			prodsByCategory.Accessories++
+// inCartCurrency converts an amount of the catalog currency into the currency of the cart.
+func inCartCurrency(userCart models.Cart, amount money.Money) money.Money {
+	if userCart.ExchangeRate == nil || amount.Currency != userCart.ExchangeRate.From {
+		return amount
+	}
+
+	return userCart.ExchangeRate.Convert(amount, money.HalfUp)
+}
+
+// refreshFreeItems replaces the free line items of the cart by the ones the enabled promotions give away for the rest
+// of it, so they're derived again every time the cart changes. The cart is left as it is if they're the same.
+func (c *cart) refreshFreeItems(cartID string, userCart models.Cart) (models.Cart, error) {
+	// The free items don't depend on what shipping costs
+	result := c.Promotions.Evaluate(userCart, money.Zero(currencyOf(userCart)))
+
+	var current []models.LineItem
+	for _, item := range userCart.Items {
+		if item.Free {
+			current = append(current, item)
 		}
 	}
+	if len(current) == 0 && len(result.FreeItems) == 0 || reflect.DeepEqual(current, result.FreeItems) {
+		return userCart, nil
+	}
 
-	return prodsByCategory
+	return c.CartRepo.SetFreeItems(cartID, result.FreeItems)
 }
 
-func generateOrderID() int {
-	source := rand.NewSource(time.Now().UnixNano())
-	r := rand.New(source)
+// price calculates what an order for the cart would cost with the promotions running right now and the applied
+// coupons on top of them, shipped as quoted.
+func (c *cart) price(userCart models.Cart, quote ShippingQuote, applied []models.Coupon) models.Pricing {
+	result := c.Promotions.Evaluate(userCart, quote.Rate)
+	for _, coupon := range applied {
+		promotions.Apply(coupons.Promotion(coupon), userCart, &result)
+	}
+	totalSpent, totalProducts, discount := calculateOrderDetails(userCart, result)
+
+	return models.Pricing{
+		Products:       totalProducts,
+		Subtotal:       result.Subtotal,
+		Discounts:      discount,
+		Shipping:       result.Shipping,
+		ShippingMethod: quote.Method,
+		Price:          totalSpent,
+		Adjustments:    result.Adjustments,
+	}
+}
 
-	digits := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+// preview prices the cart as shipped with its delivery method, the default one until the user chooses, to its
+// shipping address. Carts that can't be shipped without knowing where to, or at all, are previewed without
+// shipping, which is worked out when the order is placed. Coupons that can't be redeemed right now are left out.
+func (c *cart) preview(userCart models.Cart) models.Pricing {
+	var region string
+	if userCart.Checkout.ShippingAddress != nil {
+		region = userCart.Checkout.ShippingAddress.Destination()
+	}
 
-	r.Shuffle(len(digits), func(i, j int) {
-		digits[i], digits[j] = digits[j], digits[i]
-	})
+	quote, err := c.Shipping.Quote(userCart, userCart.Checkout.DeliveryMethod, region)
+	if err != nil {
+		quote = ShippingQuote{Rate: money.Zero(currencyOf(userCart))}
+	}
+
+	var applied []models.Coupon
+	for _, code := range userCart.Coupons {
+		if coupon, err := c.Coupons.Check(code, userCart); err == nil {
+			applied = append(applied, coupon)
+		}
+	}
+
+	return c.price(userCart, quote, applied)
+}
 
-	result := 0
-	for i := 0; i < 8; i++ {
-		result = result*10 + digits[i]
+// checkCoupons returns the coupons applied to the cart, as long as an order for it can redeem all of them.
+func (c *cart) checkCoupons(userCart models.Cart) ([]models.Coupon, error) {
+	applied := make([]models.Coupon, 0, len(userCart.Coupons))
+	for _, code := range userCart.Coupons {
+		coupon, err := c.Coupons.Check(code, userCart)
+		if err != nil {
+			return nil, err
+		}
+		applied = append(applied, coupon)
 	}
 
-	return result
+	return applied, nil
 }
 
-type productsByCategory struct {
-	Coffee      int
-	Equipment   int
-	Accessories int
+// calculateOrderDetails returns the amount to pay, the number of products bought and the discount of the order.
+func calculateOrderDetails(cart models.Cart, result promotions.Result) (money.Money, int, money.Money) {
+	totalProducts := 0
+	for _, item := range cart.Items {
+		totalProducts += item.Quantity
+	}
+
+	return result.Subtotal.Sub(result.Discount), totalProducts, result.Discount
 }
@@ -6,6 +6,7 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
 )
 
 // CartMock is an autogenerated mock type for the CartMock type
@@ -13,23 +14,23 @@ type CartMock struct {
 	mock.Mock
 }
 
-// AddProductToCart provides a mock function with given fields: cartID, product
-func (_m *CartMock) AddProductToCart(cartID string, product models.Product) (models.Cart, error) {
-	ret := _m.Called(cartID, product)
+// AddProductToCart provides a mock function with given fields: cartID, sku, quantity
+func (_m *CartMock) AddProductToCart(cartID string, sku string, quantity int) (models.Cart, error) {
+	ret := _m.Called(cartID, sku, quantity)
 
 	var r0 models.Cart
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, models.Product) (models.Cart, error)); ok {
-		return rf(cartID, product)
+	if rf, ok := ret.Get(0).(func(string, string, int) (models.Cart, error)); ok {
+		return rf(cartID, sku, quantity)
 	}
-	if rf, ok := ret.Get(0).(func(string, models.Product) models.Cart); ok {
-		r0 = rf(cartID, product)
+	if rf, ok := ret.Get(0).(func(string, string, int) models.Cart); ok {
+		r0 = rf(cartID, sku, quantity)
 	} else {
 		r0 = ret.Get(0).(models.Cart)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, models.Product) error); ok {
-		r1 = rf(cartID, product)
+	if rf, ok := ret.Get(1).(func(string, string, int) error); ok {
+		r1 = rf(cartID, sku, quantity)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -37,18 +38,52 @@ func (_m *CartMock) AddProductToCart(cartID string, product models.Product) (mod
 	return r0, r1
 }
 
-// CreateCart provides a mock function with given fields: userID
-func (_m *CartMock) CreateCart(userID string) models.Cart {
-	ret := _m.Called(userID)
+// ApplyCoupon provides a mock function with given fields: cartID, code
+func (_m *CartMock) ApplyCoupon(cartID string, code string) (models.Cart, error) {
+	ret := _m.Called(cartID, code)
 
 	var r0 models.Cart
-	if rf, ok := ret.Get(0).(func(string) models.Cart); ok {
-		r0 = rf(userID)
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (models.Cart, error)); ok {
+		return rf(cartID, code)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) models.Cart); ok {
+		r0 = rf(cartID, code)
 	} else {
 		r0 = ret.Get(0).(models.Cart)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(cartID, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateCart provides a mock function with given fields: userID, name, currency
+func (_m *CartMock) CreateCart(userID string, name string, currency money.Currency) (models.Cart, error) {
+	ret := _m.Called(userID, name, currency)
+
+	var r0 models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, money.Currency) (models.Cart, error)); ok {
+		return rf(userID, name, currency)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, money.Currency) models.Cart); ok {
+		r0 = rf(userID, name, currency)
+	} else {
+		r0 = ret.Get(0).(models.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, money.Currency) error); ok {
+		r1 = rf(userID, name, currency)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // CreateOrderForCart provides a mock function with given fields: cartID
@@ -75,6 +110,288 @@ func (_m *CartMock) CreateOrderForCart(cartID string) (models.Order, error) {
 	return r0, r1
 }
 
+// DeleteCart provides a mock function with given fields: cartID
+func (_m *CartMock) DeleteCart(cartID string) error {
+	ret := _m.Called(cartID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(cartID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetCart provides a mock function with given fields: cartID
+func (_m *CartMock) GetCart(cartID string) (models.CartDetails, error) {
+	ret := _m.Called(cartID)
+
+	var r0 models.CartDetails
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.CartDetails, error)); ok {
+		return rf(cartID)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.CartDetails); ok {
+		r0 = rf(cartID)
+	} else {
+		r0 = ret.Get(0).(models.CartDetails)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(cartID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuestCart provides a mock function with given fields: guestToken
+func (_m *CartMock) GetGuestCart(guestToken string) (models.CartDetails, error) {
+	ret := _m.Called(guestToken)
+
+	var r0 models.CartDetails
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.CartDetails, error)); ok {
+		return rf(guestToken)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.CartDetails); ok {
+		r0 = rf(guestToken)
+	} else {
+		r0 = ret.Get(0).(models.CartDetails)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guestToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrder provides a mock function with given fields: orderID
+func (_m *CartMock) GetOrder(orderID int) (models.Order, error) {
+	ret := _m.Called(orderID)
+
+	var r0 models.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (models.Order, error)); ok {
+		return rf(orderID)
+	}
+	if rf, ok := ret.Get(0).(func(int) models.Order); ok {
+		r0 = rf(orderID)
+	} else {
+		r0 = ret.Get(0).(models.Order)
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserCart provides a mock function with given fields: userID
+func (_m *CartMock) GetUserCart(userID string) (models.CartDetails, error) {
+	ret := _m.Called(userID)
+
+	var r0 models.CartDetails
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.CartDetails, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.CartDetails); ok {
+		r0 = rf(userID)
+	} else {
+		r0 = ret.Get(0).(models.CartDetails)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserCarts provides a mock function with given fields: userID
+func (_m *CartMock) GetUserCarts(userID string) ([]models.Cart, error) {
+	ret := _m.Called(userID)
+
+	var r0 []models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]models.Cart, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []models.Cart); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Cart)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserOrders provides a mock function with given fields: userID
+func (_m *CartMock) GetUserOrders(userID string) ([]models.Order, error) {
+	ret := _m.Called(userID)
+
+	var r0 []models.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]models.Order, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []models.Order); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MergeGuestCart provides a mock function with given fields: guestToken, userID, strategy
+func (_m *CartMock) MergeGuestCart(guestToken string, userID string, strategy MergeStrategy) (models.Cart, error) {
+	ret := _m.Called(guestToken, userID, strategy)
+
+	var r0 models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, MergeStrategy) (models.Cart, error)); ok {
+		return rf(guestToken, userID, strategy)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, MergeStrategy) models.Cart); ok {
+		r0 = rf(guestToken, userID, strategy)
+	} else {
+		r0 = ret.Get(0).(models.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, MergeStrategy) error); ok {
+		r1 = rf(guestToken, userID, strategy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveCoupon provides a mock function with given fields: cartID, code
+func (_m *CartMock) RemoveCoupon(cartID string, code string) (models.Cart, error) {
+	ret := _m.Called(cartID, code)
+
+	var r0 models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (models.Cart, error)); ok {
+		return rf(cartID, code)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) models.Cart); ok {
+		r0 = rf(cartID, code)
+	} else {
+		r0 = ret.Get(0).(models.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(cartID, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveProduct provides a mock function with given fields: cartID, product
+func (_m *CartMock) RemoveProduct(cartID string, product string) (models.Cart, error) {
+	ret := _m.Called(cartID, product)
+
+	var r0 models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (models.Cart, error)); ok {
+		return rf(cartID, product)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) models.Cart); ok {
+		r0 = rf(cartID, product)
+	} else {
+		r0 = ret.Get(0).(models.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(cartID, product)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RenameCart provides a mock function with given fields: cartID, name
+func (_m *CartMock) RenameCart(cartID string, name string) (models.Cart, error) {
+	ret := _m.Called(cartID, name)
+
+	var r0 models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (models.Cart, error)); ok {
+		return rf(cartID, name)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) models.Cart); ok {
+		r0 = rf(cartID, name)
+	} else {
+		r0 = ret.Get(0).(models.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(cartID, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateCheckout provides a mock function with given fields: cartID, checkout
+func (_m *CartMock) UpdateCheckout(cartID string, checkout models.Checkout) (models.Cart, error) {
+	ret := _m.Called(cartID, checkout)
+
+	var r0 models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, models.Checkout) (models.Cart, error)); ok {
+		return rf(cartID, checkout)
+	}
+	if rf, ok := ret.Get(0).(func(string, models.Checkout) models.Cart); ok {
+		r0 = rf(cartID, checkout)
+	} else {
+		r0 = ret.Get(0).(models.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, models.Checkout) error); ok {
+		r1 = rf(cartID, checkout)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateProductQuantity provides a mock function with given fields: cartID, product, quantity
 func (_m *CartMock) UpdateProductQuantity(cartID string, product string, quantity int) (models.Cart, error) {
 	ret := _m.Called(cartID, product, quantity)
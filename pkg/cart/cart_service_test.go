@@ -2,84 +2,505 @@ package cart
 
 import (
 	"errors"
+	"fmt"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"strings"
 	"testing"
+	"trafilea-tech-challenge/pkg/catalog"
+	"trafilea-tech-challenge/pkg/coupons"
+	"trafilea-tech-challenge/pkg/inventory"
 	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+	"trafilea-tech-challenge/pkg/promotions"
 	"trafilea-tech-challenge/pkg/storage"
 )
 
+func usd(amount int64) money.Money {
+	return money.FromMajor(amount, money.USD)
+}
+
+func newTestPromotions(t *testing.T) promotions.Registry {
+	registry, err := promotions.NewRegistry(promotions.Defaults()...)
+	require.NoError(t, err)
+	return registry
+}
+
+// newTestCoupons has no coupons until the test creates them.
+func newTestCoupons() coupons.Coupons {
+	return coupons.NewCoupons(storage.NewCouponRepo())
+}
+
+// newTestRates sells in USD, the catalog currency, and in EUR.
+func newTestRates(t *testing.T) money.Rates {
+	toEUR, err := money.ParseRate(money.USD, money.EUR, "0.92")
+	require.NoError(t, err)
+	rates, err := money.NewRates(money.USD, toEUR)
+	require.NoError(t, err)
+	return rates
+}
+
+func eur(amount int64) money.Money {
+	return money.FromMajor(amount, money.EUR)
+}
+
+func newTestOrders() *storage.OrderRepositoryMock {
+	orders := &storage.OrderRepositoryMock{}
+	orders.On("CreateOrder", mock.Anything).Return(func(order models.Order) (models.Order, error) {
+		return order, nil
+	})
+	return orders
+}
+
+// newTestInventory has every product in stock.
+func newTestInventory() *inventory.InventoryMock {
+	stock := &inventory.InventoryMock{}
+	stock.On("CheckAvailability", mock.Anything, mock.Anything).Return(nil)
+	stock.On("Reserve", mock.Anything, mock.Anything).Return(models.Reservation{}, nil)
+	stock.On("Release", mock.Anything).Return(nil)
+	return stock
+}
+
+// readyToOrder gives the cart the checkout its order needs, shipped and billed to the region with the default
+// delivery method.
+func readyToOrder(userCart models.Cart, region string) models.Cart {
+	country, subdivision, _ := strings.Cut(region, "-")
+	address := &models.Address{Name: "Ada Lovelace", Line1: "1 Main St", City: "Springfield", Region: subdivision,
+		PostalCode: "12345", Country: country}
+	userCart.Checkout = models.Checkout{Email: "ada@example.com", ShippingAddress: address, BillingAddress: address,
+		DeliveryMethod: DefaultShippingMethod}
+	return userCart
+}
+
+func checkedOut(userCart models.Cart) models.Cart {
+	userCart.CheckedOut = true
+	return userCart
+}
+
+// freeExtraCoffee is the free line item of the extra coffee given away to carts with 2 coffees, in USD.
+func freeExtraCoffee() models.LineItem {
+	return models.LineItem{
+		Product:     models.Product{Name: "extraCoffee", Category: models.CoffeeCategory, Price: usd(0)},
+		Quantity:    1,
+		Free:        true,
+		PromotionID: promotions.ExtraCoffeeID,
+	}
+}
+
 func TestCreateCart_Success(t *testing.T) {
 	// Given
 	userID := "12345"
 	testCart := models.Cart{
 		ID:       mock.Anything,
 		UserID:   userID,
-		Products: []models.Product{},
+		Items:    []models.LineItem{},
+		Currency: money.USD,
 	}
 
 	repo := &storage.CartRepositoryMock{}
-	repo.On("CreateCart", userID, mock.Anything).Return(testCart)
-	cartService := NewCart(repo)
+	repo.On("CreateCart", userID, mock.MatchedBy(func(newCart models.Cart) bool {
+		return newCart.Currency == money.USD && newCart.ExchangeRate == nil
+	})).Return(testCart, nil)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
 
 	// When
-	userCart := cartService.CreateCart(userID)
+	userCart, err := cartService.CreateCart(userID, "", "")
 
 	// Then
+	require.NoError(t, err)
 	require.Equal(t, userCart, testCart)
 }
 
+func TestCreateCart_Error_Storing_Cart(t *testing.T) {
+	// Given
+	repo := &storage.CartRepositoryMock{}
+	repo.On("CreateCart", "12345", mock.Anything).Return(models.Cart{}, errors.New("database is locked"))
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	_, err := cartService.CreateCart("12345", "", "")
+
+	// Then
+	require.EqualError(t, err, "database is locked")
+}
+
+func TestCreateCart_In_Another_Currency(t *testing.T) {
+	// Given
+	repo := &storage.CartRepositoryMock{}
+	repo.On("CreateCart", "12345", mock.Anything).Return(func(_ string, newCart models.Cart) (models.Cart, error) {
+		return newCart, nil
+	})
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	userCart, err := cartService.CreateCart("12345", "", money.EUR)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, money.EUR, userCart.Currency)
+	require.NotNil(t, userCart.ExchangeRate)
+	require.Equal(t, "1 USD = 0.92 EUR", userCart.ExchangeRate.String())
+}
+
+func TestCreateCart_Currency_Not_Sold(t *testing.T) {
+	// Given
+	repo := &storage.CartRepositoryMock{}
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	_, err := cartService.CreateCart("12345", "", money.JPY)
+
+	// Then
+	require.ErrorIs(t, err, ErrValidation)
+	require.EqualError(t, err, `invalid request: currency "JPY" is not sold, try one of [USD EUR]`)
+	repo.AssertNotCalled(t, "CreateCart", mock.Anything, mock.Anything)
+}
+
+func TestCreateCart_Several_Carts_Per_User(t *testing.T) {
+	// Given
+	repo := storage.NewCartRepo(map[string]models.Cart{})
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	office, err := cartService.CreateCart("12345", "office", "")
+	require.NoError(t, err)
+	home, err := cartService.CreateCart("12345", " home ", money.EUR)
+	require.NoError(t, err)
+
+	// Then
+	require.NotEqual(t, office.ID, home.ID)
+	require.Equal(t, "home", home.Name)
+	require.False(t, home.CreatedAt.IsZero())
+
+	userCarts, err := cartService.GetUserCarts("12345")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{office.ID, home.ID}, []string{userCarts[0].ID, userCarts[1].ID})
+}
+
+func TestRenameCart_Success(t *testing.T) {
+	// Given
+	repo := &storage.CartRepositoryMock{}
+	repo.On("RenameCart", "cart1", "home").Return(models.Cart{ID: "cart1", UserID: "12345", Name: "home"}, nil)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	renamedCart, err := cartService.RenameCart("cart1", "  home")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "home", renamedCart.Name)
+	repo.AssertExpectations(t)
+}
+
+func TestRenameCart_Invalid_Name(t *testing.T) {
+	tests := []struct {
+		name    string
+		newName string
+		message string
+	}{
+		{
+			name:    "blank",
+			newName: "   ",
+			message: "invalid request: cart name is required",
+		},
+		{
+			name:    "too long",
+			newName: strings.Repeat("a", maxCartNameLength+1),
+			message: "invalid request: cart name must have at most 50 characters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo := &storage.CartRepositoryMock{}
+			cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+			// When
+			_, err := cartService.RenameCart("cart1", tt.newName)
+
+			// Then
+			require.ErrorIs(t, err, ErrValidation)
+			require.EqualError(t, err, tt.message)
+			repo.AssertNotCalled(t, "RenameCart", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestDeleteCart(t *testing.T) {
+	// Given
+	repo := storage.NewCartRepo(map[string]models.Cart{})
+	_, err := repo.CreateCart("12345", models.Cart{ID: "cart1", UserID: "12345", Items: []models.LineItem{}})
+	require.NoError(t, err)
+	_, err = repo.CreateCart("12345", models.Cart{ID: "cart2", UserID: "12345", Items: []models.LineItem{}})
+	require.NoError(t, err)
+	_, err = repo.CheckoutCart("cart2")
+	require.NoError(t, err)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	deleteErr := cartService.DeleteCart("cart1")
+	checkedOutErr := cartService.DeleteCart("cart2")
+
+	// Then
+	require.NoError(t, deleteErr)
+	require.ErrorIs(t, checkedOutErr, ErrCartCheckedOut)
+	userCarts, err := cartService.GetUserCarts("12345")
+	require.NoError(t, err)
+	require.Empty(t, userCarts)
+}
+
+func TestAddProductToCart_Prices_In_Cart_Currency(t *testing.T) {
+	// Given a cart in EUR, and products with and without a price in EUR
+	rates := newTestRates(t)
+	rate, _ := rates.Rate(money.EUR)
+	eurCart := models.Cart{ID: "cart1", UserID: "12345", Items: []models.LineItem{}, Currency: money.EUR, ExchangeRate: &rate}
+	mug := models.Product{SKU: "ACC-001", Name: "mug", Category: models.AccessoriesCategory, Price: usd(15)}
+	grinder := models.Product{SKU: "EQ-001", Name: "grinder", Category: models.EquipmentCategory, Price: usd(50),
+		Prices: []money.Money{eur(45)}}
+
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", "cart1").Return(eurCart, nil)
+	repo.On("AddProduct", "cart1", mock.Anything, 1).Return(func(_ string, product models.Product, quantity int) (models.Cart, error) {
+		updated := eurCart
+		updated.Items = []models.LineItem{{Product: product, Quantity: quantity}}
+		return updated, nil
+	})
+	products := &catalog.CatalogMock{}
+	products.On("GetProduct", "ACC-001").Return(mug, nil)
+	products.On("GetProduct", "EQ-001").Return(grinder, nil)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, products, newTestInventory(), newTestPromotions(t), newTestCoupons(), rates, DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	withMug, mugErr := cartService.AddProductToCart("cart1", "ACC-001", 1)
+	withGrinder, grinderErr := cartService.AddProductToCart("cart1", "EQ-001", 1)
+
+	// Then
+	require.NoError(t, mugErr)
+	require.Equal(t, money.New(1380, money.EUR), withMug.Items[0].Product.Price, "converted with the rate of the cart")
+	require.NoError(t, grinderErr)
+	require.Equal(t, eur(45), withGrinder.Items[0].Product.Price, "taken from the price list")
+	require.Nil(t, withGrinder.Items[0].Product.Prices)
+}
+
+func TestCreateOrderForCart_In_Another_Currency(t *testing.T) {
+	// Given
+	rates := newTestRates(t)
+	rate, _ := rates.Rate(money.EUR)
+	testCart := readyToOrder(models.Cart{
+		ID:     "cart1",
+		UserID: "12345",
+		Items: []models.LineItem{
+			{Product: models.Product{Name: "mug", Category: models.AccessoriesCategory, Price: eur(70)}, Quantity: 1},
+		},
+		Currency:     money.EUR,
+		ExchangeRate: &rate,
+	}, "US")
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", "cart1").Return(testCart, nil)
+	repo.On("CheckoutCart", "cart1").Return(checkedOut(testCart), nil)
+	cartService := NewCart(repo, newTestOrders(), &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), rates, DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	order, err := cartService.CreateOrderForCart("cart1")
+
+	// Then the accessories reach the 70.01 USD of the discount, which are 64.41 EUR
+	require.NoError(t, err)
+	require.Equal(t, money.New(1840, money.EUR), order.Totals.Shipping)
+	require.Equal(t, eur(7), order.Totals.Discounts)
+	require.Equal(t, eur(63), order.Totals.Price)
+	require.Equal(t, &rate, order.ExchangeRate)
+}
+
+func TestCreateOrderForCart_Taxes(t *testing.T) {
+	tests := []struct {
+		name      string
+		region    string
+		inclusive bool
+		tax       models.Tax
+		price     money.Money
+	}{
+		{
+			name:   "added to the price",
+			region: "US-CA",
+			tax:    models.Tax{Rate: "7.25", Amount: money.New(508, money.USD)},
+			price:  money.New(7508, money.USD),
+		},
+		{
+			name:      "included in the price",
+			region:    "ES",
+			inclusive: true,
+			tax:       models.Tax{Rate: "21", Amount: money.New(1215, money.USD)},
+			price:     usd(70),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			testCart := readyToOrder(models.Cart{
+				ID:     "cart1",
+				UserID: "12345",
+				Items: []models.LineItem{
+					{Product: models.Product{Name: "mug", Category: models.AccessoriesCategory, Price: usd(70)}, Quantity: 1},
+				},
+			}, tt.region)
+			repo := &storage.CartRepositoryMock{}
+			repo.On("GetCartByID", "cart1").Return(testCart, nil)
+			repo.On("CheckoutCart", "cart1").Return(checkedOut(testCart), nil)
+			cartService := NewCart(repo, newTestOrders(), &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), newTestTaxCalculator(t), NewSequenceOrderIDGenerator(1))
+
+			// When
+			order, err := cartService.CreateOrderForCart("cart1")
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, tt.region, order.TaxRegion)
+			require.Equal(t, tt.tax.Amount, order.Totals.Tax)
+			require.Equal(t, tt.inclusive, order.Totals.TaxInclusive)
+			require.Equal(t, tt.price, order.Totals.Price)
+			require.Equal(t, &tt.tax, order.Items[0].Tax)
+			require.Nil(t, testCart.Items[0].Tax, "the cart items aren't changed")
+		})
+	}
+}
+
+func TestCreateOrderForCart_No_Tax_Rules_For_Region(t *testing.T) {
+	// Given
+	testCart := readyToOrder(models.Cart{
+		ID:     "cart1",
+		UserID: "12345",
+		Items: []models.LineItem{
+			{Product: models.Product{Name: "mug", Category: models.AccessoriesCategory, Price: usd(70)}, Quantity: 1},
+		},
+	}, "FR")
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", "cart1").Return(testCart, nil)
+	repo.On("CheckoutCart", "cart1").Return(checkedOut(testCart), nil)
+	repo.On("ReopenCart", "cart1").Return(testCart, nil)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), newTestTaxCalculator(t), NewSequenceOrderIDGenerator(1))
+
+	// When
+	_, err := cartService.CreateOrderForCart("cart1")
+
+	// Then
+	require.ErrorIs(t, err, ErrValidation)
+	repo.AssertCalled(t, "ReopenCart", "cart1")
+}
+
+func TestCreateOrderForCart_Shipping_Method(t *testing.T) {
+	// Given
+	testCart := readyToOrder(models.Cart{
+		ID:     "cart1",
+		UserID: "12345",
+		Items: []models.LineItem{
+			{Product: models.Product{Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10), Weight: 250}, Quantity: 2},
+		},
+	}, "US-NY")
+	testCart.Checkout.DeliveryMethod = "express"
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", "cart1").Return(testCart, nil)
+	repo.On("CheckoutCart", "cart1").Return(checkedOut(testCart), nil)
+	shipping, err := NewShippingMethods("standard", map[string]ShippingCalculator{
+		"standard": NewFlatRateShipping(usd(20)),
+		"express": NewZoneShipping(map[string]ShippingCalculator{
+			"US": NewWeightShipping(WeightBracket{UpTo: 1000, Price: usd(35)}),
+		}),
+	})
+	require.NoError(t, err)
+	cartService := NewCart(repo, newTestOrders(), &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), shipping, NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	order, err := cartService.CreateOrderForCart("cart1")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "express", order.Totals.ShippingMethod)
+	require.Equal(t, usd(35), order.Totals.ShippingRate)
+	require.Equal(t, usd(35), order.Totals.Shipping)
+}
+
+func TestCreateOrderForCart_Delivery_Method_No_Longer_Offered(t *testing.T) {
+	// Given a cart whose delivery method the shop stopped offering after it was chosen
+	testCart := readyToOrder(models.Cart{
+		ID:     "cart1",
+		UserID: "12345",
+		Items: []models.LineItem{
+			{Product: models.Product{Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 2},
+		},
+	}, "US")
+	testCart.Checkout.DeliveryMethod = "drone"
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", "cart1").Return(testCart, nil)
+	repo.On("CheckoutCart", "cart1").Return(checkedOut(testCart), nil)
+	repo.On("ReopenCart", "cart1").Return(testCart, nil)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	_, err := cartService.CreateOrderForCart("cart1")
+
+	// Then
+	require.ErrorIs(t, err, ErrValidation)
+	repo.AssertCalled(t, "ReopenCart", "cart1")
+}
+
 func TestAddProductToCart_Success_Extra_Coffee(t *testing.T) {
 	// Given
 	cartID := "test_cart_id"
 	userID := "12345"
 
 	coffeeProd := models.Product{
+		SKU:      "COF-002",
 		Name:     "coffee2",
 		Category: models.CoffeeCategory,
-		Price:    20,
+		Price:    usd(20),
 	}
 
 	testCart := models.Cart{
 		ID:     cartID,
 		UserID: userID,
-		Products: []models.Product{
+		Items: []models.LineItem{
 			{
-				Name:     "coffee1",
-				Category: models.CoffeeCategory,
-				Price:    10,
+				Product: models.Product{
+					Name:     "coffee1",
+					Category: models.CoffeeCategory,
+					Price:    usd(10),
+				},
+				Quantity: 1,
 			},
 			{
-				Name:     "coffee2",
-				Category: models.CoffeeCategory,
-				Price:    20,
+				Product: models.Product{
+					Name:     "coffee2",
+					Category: models.CoffeeCategory,
+					Price:    usd(20),
+				},
+				Quantity: 1,
 			},
 		},
 	}
 
 	repo := &storage.CartRepositoryMock{}
-	repo.On("AddProduct", cartID, coffeeProd).Return(testCart, nil)
-
-	extraCoffee := models.Product{
-		Name:     "extraCoffee",
-		Category: models.CoffeeCategory,
-		Price:    0,
-	}
+	repo.On("GetCartByID", cartID).Return(models.Cart{ID: cartID, UserID: userID, Items: testCart.Items[:1]}, nil)
+	repo.On("AddProduct", cartID, coffeeProd, 1).Return(testCart, nil)
 
 	updatedTestCart := testCart
-	updatedTestCart.Products = append(updatedTestCart.Products, extraCoffee)
+	updatedTestCart.Items = append(updatedTestCart.Items, freeExtraCoffee())
 
-	repo.On("AddProduct", cartID, extraCoffee).Return(updatedTestCart, nil)
-	cartService := NewCart(repo)
+	repo.On("SetFreeItems", cartID, []models.LineItem{freeExtraCoffee()}).Return(updatedTestCart, nil)
+	products := &catalog.CatalogMock{}
+	products.On("GetProduct", "COF-002").Return(coffeeProd, nil)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, products, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
 
 	// When
-	updatedCart, err := cartService.AddProductToCart(cartID, coffeeProd)
+	updatedCart, err := cartService.AddProductToCart(cartID, "COF-002", 1)
 
 	// Then
 	require.NoError(t, err)
 	require.Equal(t, cartID, updatedCart.ID)
 	require.Equal(t, userID, updatedCart.UserID)
-	require.Equal(t, 3, len(updatedCart.Products))
+	require.Equal(t, 3, len(updatedCart.Items))
+	require.True(t, updatedCart.Items[2].Free)
+	repo.AssertExpectations(t)
 }
 
 func TestCreateOrderForCart_Error_Getting_Cart(t *testing.T) {
@@ -87,7 +508,7 @@ func TestCreateOrderForCart_Error_Getting_Cart(t *testing.T) {
 	cartID := "test_cart_id"
 	repo := &storage.CartRepositoryMock{}
 	repo.On("GetCartByID", cartID).Return(models.Cart{}, errors.New("cart does not exist"))
-	cartService := NewCart(repo)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
 
 	// When
 	order, err := cartService.CreateOrderForCart(cartID)
@@ -102,25 +523,32 @@ func TestCreateOrderForCart_Success(t *testing.T) {
 	// Given
 	cartID := "test_cart_id"
 	userID := "12345"
-	testCart := models.Cart{
+	testCart := readyToOrder(models.Cart{
 		ID:     cartID,
 		UserID: userID,
-		Products: []models.Product{
+		Items: []models.LineItem{
 			{
-				Name:     "coffee1",
-				Category: models.CoffeeCategory,
-				Price:    10,
+				Product: models.Product{
+					Name:     "coffee1",
+					Category: models.CoffeeCategory,
+					Price:    usd(10),
+				},
+				Quantity: 1,
 			},
 			{
-				Name:     "eq1",
-				Category: models.EquipmentCategory,
-				Price:    20,
+				Product: models.Product{
+					Name:     "eq1",
+					Category: models.EquipmentCategory,
+					Price:    usd(20),
+				},
+				Quantity: 1,
 			},
 		},
-	}
+	}, "US")
 	repo := &storage.CartRepositoryMock{}
 	repo.On("GetCartByID", cartID).Return(testCart, nil)
-	cartService := NewCart(repo)
+	repo.On("CheckoutCart", cartID).Return(checkedOut(testCart), nil)
+	cartService := NewCart(repo, newTestOrders(), &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
 
 	// When
 	order, err := cartService.CreateOrderForCart(testCart.ID)
@@ -128,49 +556,73 @@ func TestCreateOrderForCart_Success(t *testing.T) {
 	// Then
 	require.NoError(t, err)
 	require.Equal(t, cartID, order.CartID)
+	require.Equal(t, 1, order.Totals.Order)
+	require.Equal(t, userID, order.UserID)
+	require.Equal(t, testCart.Items, order.Items)
+	require.False(t, order.CreatedAt.IsZero())
+	require.Equal(t, models.OrderPending, order.Status)
+	require.Equal(t, []models.StatusChange{{Status: models.OrderPending, At: order.CreatedAt}}, order.History)
 	require.Equal(t, 2, order.Totals.Products)
-	require.Equal(t, fixedShippingPrice, order.Totals.Shipping)
-	require.Equal(t, 0, order.Totals.Discounts)
+	require.Equal(t, usd(20), order.Totals.Shipping)
+	require.Equal(t, DefaultShippingMethod, order.Totals.ShippingMethod)
+	require.Equal(t, usd(20), order.Totals.ShippingRate)
+	require.Equal(t, usd(0), order.Totals.Discounts)
 }
 
 func TestCreateOrderForCart_Success_With_Discounts(t *testing.T) {
 	// Given
 	cartID := "test_cart_id"
 	userID := "12345"
-	testCart := models.Cart{
+	testCart := readyToOrder(models.Cart{
 		ID:     cartID,
 		UserID: userID,
-		Products: []models.Product{
+		Items: []models.LineItem{
 			{
-				Name:     "acc1",
-				Category: models.AccessoriesCategory,
-				Price:    80,
+				Product: models.Product{
+					Name:     "acc1",
+					Category: models.AccessoriesCategory,
+					Price:    usd(80),
+				},
+				Quantity: 1,
 			},
 			{
-				Name:     "eq1",
-				Category: models.EquipmentCategory,
-				Price:    20,
+				Product: models.Product{
+					Name:     "eq1",
+					Category: models.EquipmentCategory,
+					Price:    usd(20),
+				},
+				Quantity: 1,
 			},
 			{
-				Name:     "eq2",
-				Category: models.EquipmentCategory,
-				Price:    30,
+				Product: models.Product{
+					Name:     "eq2",
+					Category: models.EquipmentCategory,
+					Price:    usd(30),
+				},
+				Quantity: 1,
 			},
 			{
-				Name:     "eq3",
-				Category: models.EquipmentCategory,
-				Price:    20,
+				Product: models.Product{
+					Name:     "eq3",
+					Category: models.EquipmentCategory,
+					Price:    usd(20),
+				},
+				Quantity: 1,
 			},
 			{
-				Name:     "eq4",
-				Category: models.EquipmentCategory,
-				Price:    50,
+				Product: models.Product{
+					Name:     "eq4",
+					Category: models.EquipmentCategory,
+					Price:    usd(50),
+				},
+				Quantity: 1,
 			},
 		},
-	}
+	}, "US")
 	repo := &storage.CartRepositoryMock{}
 	repo.On("GetCartByID", cartID).Return(testCart, nil)
-	cartService := NewCart(repo)
+	repo.On("CheckoutCart", cartID).Return(checkedOut(testCart), nil)
+	cartService := NewCart(repo, newTestOrders(), &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
 
 	// When
 	order, err := cartService.CreateOrderForCart(testCart.ID)
@@ -179,9 +631,9 @@ func TestCreateOrderForCart_Success_With_Discounts(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, cartID, order.CartID)
 	require.Equal(t, 5, order.Totals.Products)
-	require.Equal(t, 0, order.Totals.Shipping)
-	require.Equal(t, 20, order.Totals.Discounts)
-	require.Equal(t, 180, order.Totals.Price)
+	require.Equal(t, usd(0), order.Totals.Shipping)
+	require.Equal(t, usd(20), order.Totals.Discounts)
+	require.Equal(t, usd(180), order.Totals.Price)
 }
 
 func TestUpdateProductQuantity_Success(t *testing.T) {
@@ -191,37 +643,33 @@ func TestUpdateProductQuantity_Success(t *testing.T) {
 	testCart := models.Cart{
 		ID:     cartID,
 		UserID: userID,
-		Products: []models.Product{
+		Items: []models.LineItem{
 			{
-				Name:     "acc1",
-				Category: models.AccessoriesCategory,
-				Price:    80,
+				Product: models.Product{
+					Name:     "acc1",
+					Category: models.AccessoriesCategory,
+					Price:    usd(80),
+				},
+				Quantity: 1,
 			},
 			{
-				Name:     "coffee1",
-				Category: models.CoffeeCategory,
-				Price:    20,
-			},
-			{
-				Name:     "coffee1",
-				Category: models.CoffeeCategory,
-				Price:    20,
+				Product: models.Product{
+					Name:     "coffee1",
+					Category: models.CoffeeCategory,
+					Price:    usd(20),
+				},
+				Quantity: 2,
 			},
 		},
 	}
 	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", cartID).Return(testCart, nil)
 	repo.On("UpdateProductQuantity", cartID, "coffee1", 2).Return(testCart, nil)
 
-	extraCoffee := models.Product{
-		Name:     "extraCoffee",
-		Category: models.CoffeeCategory,
-		Price:    0,
-	}
-
 	updatedTestCart := testCart
-	updatedTestCart.Products = append(updatedTestCart.Products, extraCoffee)
-	repo.On("AddProduct", cartID, extraCoffee).Return(updatedTestCart, nil)
-	cartService := NewCart(repo)
+	updatedTestCart.Items = append(updatedTestCart.Items, freeExtraCoffee())
+	repo.On("SetFreeItems", cartID, []models.LineItem{freeExtraCoffee()}).Return(updatedTestCart, nil)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
 
 	// When
 	userCart, err := cartService.UpdateProductQuantity(cartID, "coffee1", 2)
@@ -230,5 +678,425 @@ func TestUpdateProductQuantity_Success(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, cartID, userCart.ID)
 	require.Equal(t, userID, userCart.UserID)
-	require.Equal(t, 4, len(userCart.Products))
+	require.Equal(t, 3, len(userCart.Items))
+}
+
+func TestCreateOrderForCart_Success_With_Quantities(t *testing.T) {
+	// Given
+	cartID := "test_cart_id"
+	testCart := readyToOrder(models.Cart{
+		ID:     cartID,
+		UserID: "12345",
+		Items: []models.LineItem{
+			{
+				Product:  models.Product{Name: "acc1", Category: models.AccessoriesCategory, Price: usd(40)},
+				Quantity: 2,
+			},
+			{
+				Product:  models.Product{Name: "eq1", Category: models.EquipmentCategory, Price: usd(25)},
+				Quantity: 4,
+			},
+		},
+	}, "US")
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", cartID).Return(testCart, nil)
+	repo.On("CheckoutCart", cartID).Return(checkedOut(testCart), nil)
+	cartService := NewCart(repo, newTestOrders(), &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	order, err := cartService.CreateOrderForCart(cartID)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 6, order.Totals.Products)
+	require.Equal(t, usd(0), order.Totals.Shipping)
+	require.Equal(t, usd(18), order.Totals.Discounts)
+	require.Equal(t, usd(162), order.Totals.Price)
+}
+
+func TestRemoveProduct_Success_Loses_Extra_Coffee(t *testing.T) {
+	// Given
+	cartID := "test_cart_id"
+	coffee1 := models.LineItem{Product: models.Product{Name: "coffee1", Category: models.CoffeeCategory, Price: usd(20)}, Quantity: 1}
+	coffee2 := models.LineItem{Product: models.Product{Name: "coffee2", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 1}
+	testCart := models.Cart{
+		ID:     cartID,
+		UserID: "12345",
+		Items:  []models.LineItem{coffee1, freeExtraCoffee()},
+	}
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", cartID).Return(models.Cart{ID: cartID, UserID: "12345", Items: []models.LineItem{coffee1, coffee2, freeExtraCoffee()}}, nil)
+	repo.On("RemoveProduct", cartID, "coffee2").Return(testCart, nil)
+
+	updatedTestCart := testCart
+	updatedTestCart.Items = testCart.Items[:1]
+	repo.On("SetFreeItems", cartID, []models.LineItem(nil)).Return(updatedTestCart, nil)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	userCart, err := cartService.RemoveProduct(cartID, "coffee2")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 1, len(userCart.Items))
+	require.Equal(t, "coffee1", userCart.Items[0].Product.Name)
+	repo.AssertExpectations(t)
+}
+
+func TestRemoveProduct_Error_Product_Not_In_Cart(t *testing.T) {
+	// Given
+	cartID := "test_cart_id"
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", cartID).Return(models.Cart{ID: cartID, UserID: "12345"}, nil)
+	repo.On("RemoveProduct", cartID, "coffee1").Return(models.Cart{}, errors.New("product coffee1 does not exist in cart"))
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	userCart, err := cartService.RemoveProduct(cartID, "coffee1")
+
+	// Then
+	require.Error(t, err)
+	require.Equal(t, models.Cart{}, userCart)
+}
+
+func TestFreeItems_Can_Not_Be_Changed(t *testing.T) {
+	// Given a cart given the extra coffee
+	cartID := "test_cart_id"
+	testCart := models.Cart{
+		ID:     cartID,
+		UserID: "12345",
+		Items: []models.LineItem{
+			{Product: models.Product{Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 2},
+			freeExtraCoffee(),
+		},
+	}
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", cartID).Return(testCart, nil)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	_, updateErr := cartService.UpdateProductQuantity(cartID, "extraCoffee", 3)
+	_, removeErr := cartService.RemoveProduct(cartID, "extraCoffee")
+
+	// Then
+	for _, err := range []error{updateErr, removeErr} {
+		require.ErrorIs(t, err, ErrValidation)
+		require.EqualError(t, err, "invalid request: extraCoffee is given away by promotion extra-coffee and can't be changed")
+	}
+	repo.AssertNotCalled(t, "UpdateProductQuantity", mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "RemoveProduct", mock.Anything, mock.Anything)
+}
+
+func TestGetCart_Success_With_Pricing(t *testing.T) {
+	// Given
+	cartID := "test_cart_id"
+	testCart := models.Cart{
+		ID:     cartID,
+		UserID: "12345",
+		Items: []models.LineItem{
+			{
+				Product:  models.Product{Name: "acc1", Category: models.AccessoriesCategory, Price: usd(80)},
+				Quantity: 1,
+			},
+			{
+				Product:  models.Product{Name: "coffee1", Category: models.CoffeeCategory, Price: usd(20)},
+				Quantity: 1,
+			},
+		},
+	}
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", cartID).Return(testCart, nil)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	details, err := cartService.GetCart(cartID)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, testCart, details.Cart)
+	require.Equal(t, models.Pricing{
+		Products:       2,
+		Subtotal:       usd(100),
+		Discounts:      usd(10),
+		Shipping:       usd(20),
+		ShippingMethod: DefaultShippingMethod,
+		Price:          usd(90),
+		Adjustments: []models.Adjustment{{
+			ID:          promotions.AccessoriesDiscountID,
+			Description: "10% off for at least 70.01 USD of accessories products",
+			Items:       []string{"acc1", "coffee1"},
+			Amount:      usd(10),
+		}},
+	}, details.Pricing)
+}
+
+func TestGetCart_Preview_Without_Shipping(t *testing.T) {
+	// Given a default shipping method that needs to know where the cart is shipped to
+	testCart := models.Cart{
+		ID:     "cart1",
+		UserID: "12345",
+		Items: []models.LineItem{
+			{Product: models.Product{Name: "coffee1", Category: models.CoffeeCategory, Price: usd(20)}, Quantity: 1},
+		},
+	}
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", "cart1").Return(testCart, nil)
+	shipping, err := NewShippingMethods("standard", map[string]ShippingCalculator{
+		"standard": NewZoneShipping(map[string]ShippingCalculator{"US": NewFlatRateShipping(usd(20))}),
+	})
+	require.NoError(t, err)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), shipping, NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	details, err := cartService.GetCart("cart1")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, usd(0), details.Pricing.Shipping)
+	require.Empty(t, details.Pricing.ShippingMethod)
+}
+
+func TestGetUserCart_Error_No_Cart(t *testing.T) {
+	// Given
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByUserID", "12345").Return(models.Cart{}, errors.New("user 12345 doesn't have a cart"))
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	details, err := cartService.GetUserCart("12345")
+
+	// Then
+	require.Error(t, err)
+	require.Equal(t, models.CartDetails{}, details)
+}
+
+func TestAddProductToCart_Validation_Error(t *testing.T) {
+	// Given
+	repo := &storage.CartRepositoryMock{}
+	products := &catalog.CatalogMock{}
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, products, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	_, err := cartService.AddProductToCart("test_cart_id", "COF-001", 0)
+
+	// Then
+	require.ErrorIs(t, err, ErrValidation)
+	products.AssertNotCalled(t, "GetProduct", mock.Anything)
+	repo.AssertNotCalled(t, "AddProduct", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAddProductToCart_Error_Unknown_SKU(t *testing.T) {
+	// Given
+	repo := &storage.CartRepositoryMock{}
+	products := &catalog.CatalogMock{}
+	products.On("GetProduct", "TEA-001").Return(models.Product{}, fmt.Errorf("%w: product with SKU TEA-001 doesn't exist", catalog.ErrProductNotFound))
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, products, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	_, err := cartService.AddProductToCart("test_cart_id", "TEA-001", 1)
+
+	// Then
+	require.ErrorIs(t, err, catalog.ErrProductNotFound)
+	repo.AssertNotCalled(t, "AddProduct", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateProductQuantity_Validation_Error(t *testing.T) {
+	// Given
+	repo := &storage.CartRepositoryMock{}
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	_, err := cartService.UpdateProductQuantity("test_cart_id", "coffee1", -1)
+
+	// Then
+	require.ErrorIs(t, err, ErrValidation)
+}
+
+func TestCreateOrderForCart_Retries_Taken_Order_ID(t *testing.T) {
+	// Given
+	cartID := "test_cart_id"
+	testCart := readyToOrder(models.Cart{
+		ID:     cartID,
+		UserID: "12345",
+		Items:  []models.LineItem{{Product: models.Product{Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 1}},
+	}, "US")
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", cartID).Return(testCart, nil)
+	repo.On("CheckoutCart", cartID).Return(checkedOut(testCart), nil)
+	orders := &storage.OrderRepositoryMock{}
+	orders.On("CreateOrder", mock.MatchedBy(func(order models.Order) bool { return order.Totals.Order == 7 })).
+		Return(models.Order{}, fmt.Errorf("%w: order 7 already exists", ErrOrderExists))
+	orders.On("CreateOrder", mock.Anything).Return(func(order models.Order) (models.Order, error) {
+		return order, nil
+	})
+	stock := newTestInventory()
+	cartService := NewCart(repo, orders, &catalog.CatalogMock{}, stock, newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(7))
+
+	// When
+	order, err := cartService.CreateOrderForCart(cartID)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 8, order.Totals.Order)
+	stock.AssertCalled(t, "Release", 7)
+	stock.AssertNotCalled(t, "Release", 8)
+}
+
+func TestCreateOrderForCart_Error_Cart_Checked_Out(t *testing.T) {
+	// Given
+	cartID := "test_cart_id"
+	testCart := models.Cart{
+		ID:         cartID,
+		UserID:     "12345",
+		Items:      []models.LineItem{{Product: models.Product{Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 1}},
+		CheckedOut: true,
+	}
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", cartID).Return(testCart, nil)
+	orders := &storage.OrderRepositoryMock{}
+	cartService := NewCart(repo, orders, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	order, err := cartService.CreateOrderForCart(cartID)
+
+	// Then
+	require.ErrorIs(t, err, ErrCartCheckedOut)
+	require.Equal(t, models.Order{}, order)
+	repo.AssertNotCalled(t, "CheckoutCart", cartID)
+	orders.AssertNotCalled(t, "CreateOrder", mock.Anything)
+}
+
+func TestCreateOrderForCart_Error_Empty_Cart(t *testing.T) {
+	// Given
+	cartID := "test_cart_id"
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", cartID).Return(models.Cart{ID: cartID, UserID: "12345", Items: []models.LineItem{}}, nil)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	_, err := cartService.CreateOrderForCart(cartID)
+
+	// Then
+	require.ErrorIs(t, err, ErrValidation)
+	repo.AssertNotCalled(t, "CheckoutCart", cartID)
+}
+
+func TestGetOrder_Error_Not_Found(t *testing.T) {
+	// Given
+	orders := &storage.OrderRepositoryMock{}
+	orders.On("GetOrderByID", 1234).Return(models.Order{}, fmt.Errorf("%w: order 1234 doesn't exist", ErrOrderNotFound))
+	cartService := NewCart(&storage.CartRepositoryMock{}, orders, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	_, err := cartService.GetOrder(1234)
+
+	// Then
+	require.ErrorIs(t, err, ErrOrderNotFound)
+}
+
+func TestGetUserOrders_Success(t *testing.T) {
+	// Given
+	userOrders := []models.Order{
+		{CartID: "cart1", UserID: "12345", Totals: models.Total{Order: 1}},
+		{CartID: "cart2", UserID: "12345", Totals: models.Total{Order: 2}},
+	}
+	orders := &storage.OrderRepositoryMock{}
+	orders.On("GetOrdersByUserID", "12345").Return(userOrders, nil)
+	cartService := NewCart(&storage.CartRepositoryMock{}, orders, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	result, err := cartService.GetUserOrders("12345")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, userOrders, result)
+}
+
+func TestAddProductToCart_Error_Insufficient_Stock(t *testing.T) {
+	// Given
+	cartID := "test_cart_id"
+	coffee := models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", cartID).Return(models.Cart{ID: cartID, UserID: "12345", Items: []models.LineItem{{Product: coffee, Quantity: 2}}}, nil)
+	products := &catalog.CatalogMock{}
+	products.On("GetProduct", "COF-001").Return(coffee, nil)
+	stock := &inventory.InventoryMock{}
+	stock.On("CheckAvailability", "COF-001", 5).Return(fmt.Errorf("%w: only 4 units of COF-001 are available", inventory.ErrInsufficientStock))
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, products, stock, newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	_, err := cartService.AddProductToCart(cartID, "COF-001", 3)
+
+	// Then
+	require.ErrorIs(t, err, ErrInsufficientStock)
+	repo.AssertNotCalled(t, "AddProduct", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateProductQuantity_Error_Insufficient_Stock(t *testing.T) {
+	// Given
+	cartID := "test_cart_id"
+	coffee := models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", cartID).Return(models.Cart{ID: cartID, UserID: "12345", Items: []models.LineItem{{Product: coffee, Quantity: 2}}}, nil)
+	stock := &inventory.InventoryMock{}
+	stock.On("CheckAvailability", "COF-001", 5).Return(fmt.Errorf("%w: only 4 units of COF-001 are available", inventory.ErrInsufficientStock))
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, stock, newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	_, err := cartService.UpdateProductQuantity(cartID, "coffee1", 5)
+
+	// Then
+	require.ErrorIs(t, err, ErrInsufficientStock)
+	repo.AssertNotCalled(t, "UpdateProductQuantity", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateOrderForCart_Error_Insufficient_Stock_Reopens_Cart(t *testing.T) {
+	// Given
+	cartID := "test_cart_id"
+	testCart := readyToOrder(models.Cart{
+		ID:     cartID,
+		UserID: "12345",
+		Items:  []models.LineItem{{Product: models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 3}},
+	}, "US")
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", cartID).Return(testCart, nil)
+	repo.On("CheckoutCart", cartID).Return(checkedOut(testCart), nil)
+	repo.On("ReopenCart", cartID).Return(testCart, nil)
+	orders := &storage.OrderRepositoryMock{}
+	stock := &inventory.InventoryMock{}
+	stock.On("Reserve", 1, testCart.Items).Return(models.Reservation{}, fmt.Errorf("%w: only 2 units of COF-001 are available", inventory.ErrInsufficientStock))
+	cartService := NewCart(repo, orders, &catalog.CatalogMock{}, stock, newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	_, err := cartService.CreateOrderForCart(cartID)
+
+	// Then
+	require.ErrorIs(t, err, ErrInsufficientStock)
+	repo.AssertCalled(t, "ReopenCart", cartID)
+	orders.AssertNotCalled(t, "CreateOrder", mock.Anything)
+}
+
+func TestCreateOrderForCart_Error_Reopening_Cart(t *testing.T) {
+	// Given a cart that can't be given back once its order fails
+	cartID := "test_cart_id"
+	testCart := readyToOrder(models.Cart{
+		ID:     cartID,
+		UserID: "12345",
+		Items:  []models.LineItem{{Product: models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 3}},
+	}, "US")
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", cartID).Return(testCart, nil)
+	repo.On("CheckoutCart", cartID).Return(checkedOut(testCart), nil)
+	repo.On("ReopenCart", cartID).Return(models.Cart{}, errors.New("database is locked"))
+	orders := &storage.OrderRepositoryMock{}
+	stock := &inventory.InventoryMock{}
+	stock.On("Reserve", 1, testCart.Items).Return(models.Reservation{}, fmt.Errorf("%w: only 2 units of COF-001 are available", inventory.ErrInsufficientStock))
+	cartService := NewCart(repo, orders, &catalog.CatalogMock{}, stock, newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	_, err := cartService.CreateOrderForCart(cartID)
+
+	// Then
+	require.ErrorIs(t, err, ErrInsufficientStock)
+	require.EqualError(t, err, "insufficient stock: only 2 units of COF-001 are available\ndatabase is locked")
 }
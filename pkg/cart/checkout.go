@@ -0,0 +1,96 @@
+package cart
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+const (
+	// maxRegionCodeLength bounds the ISO 3166-2 subdivision codes, such as NY or CAT
+	maxRegionCodeLength = 3
+	upperLetters        = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+)
+
+// UpdateCheckout validates the fields of the checkout that are set and stores them in the cart, leaving the rest as
+// they were.
+func (c *cart) UpdateCheckout(cartID string, checkout models.Checkout) (models.Cart, error) {
+	if checkout.Email != "" {
+		email, err := mail.ParseAddress(checkout.Email)
+		if err != nil || email.Name != "" || email.Address != checkout.Email {
+			return models.Cart{}, validationError(fmt.Sprintf("email %q is not valid", checkout.Email))
+		}
+	}
+
+	var err error
+	if checkout.ShippingAddress, err = validateAddress(checkout.ShippingAddress, "shipping address"); err != nil {
+		return models.Cart{}, err
+	}
+	if checkout.BillingAddress, err = validateAddress(checkout.BillingAddress, "billing address"); err != nil {
+		return models.Cart{}, err
+	}
+
+	if checkout.DeliveryMethod != "" && !c.Shipping.Offers(checkout.DeliveryMethod) {
+		return models.Cart{}, validationError(fmt.Sprintf("delivery method %q is not offered, try one of %v",
+			checkout.DeliveryMethod, c.Shipping.Names()))
+	}
+
+	return c.CartRepo.UpdateCheckout(cartID, checkout)
+}
+
+// validateAddress checks that the address has every required field, and returns it with its codes in upper case.
+func validateAddress(address *models.Address, field string) (*models.Address, error) {
+	if address == nil {
+		return nil, nil
+	}
+
+	normalized := *address
+	normalized.Country = strings.ToUpper(strings.TrimSpace(address.Country))
+	normalized.Region = strings.ToUpper(strings.TrimSpace(address.Region))
+
+	required := []struct {
+		name  string
+		value string
+	}{
+		{name: "name", value: normalized.Name},
+		{name: "line1", value: normalized.Line1},
+		{name: "city", value: normalized.City},
+		{name: "postal_code", value: normalized.PostalCode},
+		{name: "country", value: normalized.Country},
+	}
+	for _, r := range required {
+		if strings.TrimSpace(r.value) == "" {
+			return nil, validationError(fmt.Sprintf("%v %v is required", field, r.name))
+		}
+	}
+
+	if len(normalized.Country) != 2 || strings.Trim(normalized.Country, upperLetters) != "" {
+		return nil, validationError(fmt.Sprintf("%v country %q is not an ISO 3166-1 alpha-2 code", field, address.Country))
+	}
+
+	if len(normalized.Region) > maxRegionCodeLength || strings.Trim(normalized.Region, upperLetters+"0123456789") != "" {
+		return nil, validationError(fmt.Sprintf("%v region %q is not an ISO 3166-2 subdivision code", field, address.Region))
+	}
+
+	return &normalized, nil
+}
+
+// missingCheckout returns the fields the checkout still needs before its order can be placed.
+func missingCheckout(checkout models.Checkout) []string {
+	var missing []string
+	if checkout.Email == "" {
+		missing = append(missing, "email")
+	}
+	if checkout.ShippingAddress == nil {
+		missing = append(missing, "shipping address")
+	}
+	if checkout.BillingAddress == nil {
+		missing = append(missing, "billing address")
+	}
+	if checkout.DeliveryMethod == "" {
+		missing = append(missing, "delivery method")
+	}
+
+	return missing
+}
@@ -0,0 +1,149 @@
+package cart
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"trafilea-tech-challenge/pkg/catalog"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/storage"
+)
+
+func newTestAddress() *models.Address {
+	return &models.Address{Name: "Ada Lovelace", Line1: "1 Main St", City: "New York", Region: "NY", PostalCode: "10001", Country: "US"}
+}
+
+func TestUpdateCheckout_Success(t *testing.T) {
+	// Given an address with its codes in lower case
+	address := newTestAddress()
+	address.Country, address.Region = " us", "ny "
+	normalized := newTestAddress()
+	checkout := models.Checkout{Email: "ada@example.com", ShippingAddress: address, DeliveryMethod: DefaultShippingMethod}
+	expected := models.Cart{ID: "cart1", UserID: "12345", Checkout: models.Checkout{
+		Email: "ada@example.com", ShippingAddress: normalized, DeliveryMethod: DefaultShippingMethod,
+	}}
+	repo := &storage.CartRepositoryMock{}
+	repo.On("UpdateCheckout", "cart1", expected.Checkout).Return(expected, nil)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	updated, err := cartService.UpdateCheckout("cart1", checkout)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expected, updated)
+	repo.AssertExpectations(t)
+}
+
+func TestUpdateCheckout_Validation_Error(t *testing.T) {
+	withAddress := func(change func(address *models.Address)) *models.Address {
+		address := newTestAddress()
+		change(address)
+		return address
+	}
+
+	tests := []struct {
+		name     string
+		checkout models.Checkout
+		message  string
+	}{
+		{
+			name:     "invalid email",
+			checkout: models.Checkout{Email: "ada at example.com"},
+			message:  `invalid request: email "ada at example.com" is not valid`,
+		},
+		{
+			name:     "email with a name",
+			checkout: models.Checkout{Email: "Ada <ada@example.com>"},
+			message:  `invalid request: email "Ada <ada@example.com>" is not valid`,
+		},
+		{
+			name:     "missing field",
+			checkout: models.Checkout{ShippingAddress: withAddress(func(a *models.Address) { a.PostalCode = " " })},
+			message:  "invalid request: shipping address postal_code is required",
+		},
+		{
+			name:     "invalid country",
+			checkout: models.Checkout{BillingAddress: withAddress(func(a *models.Address) { a.Country = "USA" })},
+			message:  `invalid request: billing address country "USA" is not an ISO 3166-1 alpha-2 code`,
+		},
+		{
+			name:     "invalid region",
+			checkout: models.Checkout{ShippingAddress: withAddress(func(a *models.Address) { a.Region = "New York" })},
+			message:  `invalid request: shipping address region "New York" is not an ISO 3166-2 subdivision code`,
+		},
+		{
+			name:     "delivery method not offered",
+			checkout: models.Checkout{DeliveryMethod: "drone"},
+			message:  `invalid request: delivery method "drone" is not offered, try one of [standard]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo := &storage.CartRepositoryMock{}
+			cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+			// When
+			_, err := cartService.UpdateCheckout("cart1", tt.checkout)
+
+			// Then
+			require.ErrorIs(t, err, ErrValidation)
+			require.EqualError(t, err, tt.message)
+			repo.AssertNotCalled(t, "UpdateCheckout", "cart1", tt.checkout)
+		})
+	}
+}
+
+func TestCreateOrderForCart_Error_Missing_Checkout(t *testing.T) {
+	// Given a cart with products but nowhere to ship them
+	testCart := models.Cart{
+		ID:     "cart1",
+		UserID: "12345",
+		Items: []models.LineItem{
+			{Product: models.Product{Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 1},
+		},
+	}
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", "cart1").Return(testCart, nil)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	_, err := cartService.CreateOrderForCart("cart1")
+
+	// Then
+	require.ErrorIs(t, err, ErrValidation)
+	require.EqualError(t, err, "invalid request: cart needs its email, shipping address, billing address, delivery method before ordering")
+	repo.AssertNotCalled(t, "CheckoutCart", "cart1")
+}
+
+func TestGetCart_Preview_Ships_To_The_Shipping_Address(t *testing.T) {
+	// Given
+	testCart := readyToOrder(models.Cart{
+		ID:     "cart1",
+		UserID: "12345",
+		Items: []models.LineItem{
+			{Product: models.Product{Name: "coffee1", Category: models.CoffeeCategory, Price: usd(20)}, Quantity: 1},
+		},
+	}, "US-AK")
+	testCart.Checkout.DeliveryMethod = "express"
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", "cart1").Return(testCart, nil)
+	shipping, err := NewShippingMethods("standard", map[string]ShippingCalculator{
+		"standard": NewFlatRateShipping(usd(20)),
+		"express": NewZoneShipping(map[string]ShippingCalculator{
+			"US":    NewFlatRateShipping(usd(30)),
+			"US-AK": NewFlatRateShipping(usd(60)),
+		}),
+	})
+	require.NoError(t, err)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), shipping, NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	details, err := cartService.GetCart("cart1")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "express", details.Pricing.ShippingMethod)
+	require.Equal(t, usd(60), details.Pricing.Shipping)
+}
@@ -0,0 +1,260 @@
+package cart
+
+import (
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"trafilea-tech-challenge/pkg/catalog"
+	"trafilea-tech-challenge/pkg/coupons"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/promotions"
+	"trafilea-tech-challenge/pkg/storage"
+)
+
+// newTestCouponRepo has a TENOFF coupon for 10 off carts of at least 50, which can be redeemed once, and a
+// FREESHIP coupon for free shipping.
+func newTestCouponRepo(t *testing.T) storage.CouponRepository {
+	repo := storage.NewCouponRepo()
+	_, err := repo.CreateCoupon(models.Coupon{Code: "TENOFF", Effect: promotions.FixedOffEffect, Amount: usd(10), MinSubtotal: usd(50), MaxRedemptions: 1})
+	require.NoError(t, err)
+	_, err = repo.CreateCoupon(models.Coupon{Code: "FREESHIP", Effect: promotions.FreeShippingEffect})
+	require.NoError(t, err)
+	return repo
+}
+
+func newCouponTestCart(coupons ...string) models.Cart {
+	return readyToOrder(models.Cart{
+		ID:      "cart1",
+		UserID:  "12345",
+		Items:   []models.LineItem{{Product: models.Product{Name: "grinder", Category: models.EquipmentCategory, Price: usd(60)}, Quantity: 1}},
+		Coupons: coupons,
+	}, "US")
+}
+
+func TestApplyCoupon_Success(t *testing.T) {
+	// Given
+	testCart := newCouponTestCart()
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", "cart1").Return(testCart, nil)
+	repo.On("AddCoupon", "cart1", "TENOFF").Return(newCouponTestCart("TENOFF"), nil)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), coupons.NewCoupons(newTestCouponRepo(t)), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	updated, err := cartService.ApplyCoupon("cart1", " tenoff")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []string{"TENOFF"}, updated.Coupons)
+	repo.AssertExpectations(t)
+}
+
+func TestApplyCoupon_Error(t *testing.T) {
+	tests := []struct {
+		name        string
+		cart        models.Cart
+		code        string
+		expectedErr error
+		message     string
+	}{
+		{
+			name:        "no code",
+			cart:        newCouponTestCart(),
+			code:        " ",
+			expectedErr: ErrValidation,
+			message:     "invalid request: coupon code is required",
+		},
+		{
+			name:        "already applied",
+			cart:        newCouponTestCart("TENOFF"),
+			code:        "tenoff",
+			expectedErr: ErrValidation,
+			message:     "invalid request: coupon TENOFF is already applied to the cart",
+		},
+		{
+			name:        "unknown coupon",
+			cart:        newCouponTestCart(),
+			code:        "NOPE",
+			expectedErr: coupons.ErrCouponNotFound,
+			message:     "coupon NOPE doesn't exist",
+		},
+		{
+			name: "subtotal too low",
+			cart: func() models.Cart {
+				userCart := newCouponTestCart()
+				userCart.Items[0].Product.Price = usd(40)
+				return userCart
+			}(),
+			code:        "TENOFF",
+			expectedErr: coupons.ErrCouponNotApplicable,
+			message:     "coupon not applicable: coupon TENOFF needs a subtotal of at least 50.00 USD",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo := &storage.CartRepositoryMock{}
+			repo.On("GetCartByID", "cart1").Return(tt.cart, nil)
+			cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), coupons.NewCoupons(newTestCouponRepo(t)), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+			// When
+			_, err := cartService.ApplyCoupon("cart1", tt.code)
+
+			// Then
+			require.ErrorIs(t, err, tt.expectedErr)
+			require.EqualError(t, err, tt.message)
+			repo.AssertNotCalled(t, "AddCoupon", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRemoveCoupon(t *testing.T) {
+	// Given
+	repo := &storage.CartRepositoryMock{}
+	repo.On("RemoveCoupon", "cart1", "TENOFF").Return(newCouponTestCart(), nil)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	updated, err := cartService.RemoveCoupon("cart1", "tenoff")
+
+	// Then
+	require.NoError(t, err)
+	require.Empty(t, updated.Coupons)
+	repo.AssertExpectations(t)
+}
+
+func TestGetCart_Preview_Leaves_Out_Coupons_Not_Applicable(t *testing.T) {
+	// Given a cart whose subtotal dropped below what TENOFF needs after applying it
+	testCart := newCouponTestCart("TENOFF", "FREESHIP")
+	testCart.Items[0].Product.Price = usd(40)
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", "cart1").Return(testCart, nil)
+	cartService := NewCart(repo, &storage.OrderRepositoryMock{}, &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), coupons.NewCoupons(newTestCouponRepo(t)), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	details, err := cartService.GetCart("cart1")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, usd(0), details.Pricing.Discounts)
+	require.Equal(t, usd(0), details.Pricing.Shipping)
+	require.Equal(t, usd(40), details.Pricing.Price)
+}
+
+func TestCreateOrderForCart_Redeems_Coupons(t *testing.T) {
+	// Given
+	testCart := newCouponTestCart("TENOFF", "FREESHIP")
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", "cart1").Return(testCart, nil)
+	repo.On("CheckoutCart", "cart1").Return(checkedOut(testCart), nil)
+	couponRepo := newTestCouponRepo(t)
+	cartService := NewCart(repo, newTestOrders(), &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), coupons.NewCoupons(couponRepo), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	order, err := cartService.CreateOrderForCart("cart1")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []string{"TENOFF", "FREESHIP"}, order.Coupons)
+	require.Equal(t, usd(10), order.Totals.Discounts)
+	require.Equal(t, usd(0), order.Totals.Shipping)
+	require.Equal(t, usd(50), order.Totals.Price)
+	redeemed, err := couponRepo.CountUserRedemptions("TENOFF", "12345")
+	require.NoError(t, err)
+	require.Equal(t, 1, redeemed)
+}
+
+func TestCreateOrderForCart_Error_Coupon_Exhausted_Reopens_Cart(t *testing.T) {
+	// Given TENOFF was redeemed by another order after it was applied to the cart
+	testCart := newCouponTestCart("FREESHIP", "TENOFF")
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", "cart1").Return(testCart, nil)
+	repo.On("CheckoutCart", "cart1").Return(checkedOut(testCart), nil)
+	repo.On("ReopenCart", "cart1").Return(testCart, nil)
+	couponRepo := newTestCouponRepo(t)
+	require.NoError(t, couponRepo.Redeem(99, []models.Redemption{{Code: "TENOFF", UserID: "67890", OrderID: 99}}))
+	orders := &storage.OrderRepositoryMock{}
+	stock := newTestInventory()
+	cartService := NewCart(repo, orders, &catalog.CatalogMock{}, stock, newTestPromotions(t), coupons.NewCoupons(couponRepo), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	_, err := cartService.CreateOrderForCart("cart1")
+
+	// Then
+	require.ErrorIs(t, err, coupons.ErrCouponExhausted)
+	repo.AssertCalled(t, "ReopenCart", "cart1")
+	stock.AssertNotCalled(t, "Reserve", mock.Anything, mock.Anything)
+	orders.AssertNotCalled(t, "CreateOrder", mock.Anything)
+	freeShipping, _ := couponRepo.GetCoupon("FREESHIP")
+	require.Equal(t, 0, freeShipping.Redemptions)
+}
+
+func TestCreateOrderForCart_Error_Saving_Order_Releases_Coupons(t *testing.T) {
+	// Given
+	testCart := newCouponTestCart("TENOFF")
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", "cart1").Return(testCart, nil)
+	repo.On("CheckoutCart", "cart1").Return(checkedOut(testCart), nil)
+	repo.On("ReopenCart", "cart1").Return(testCart, nil)
+	orders := &storage.OrderRepositoryMock{}
+	orders.On("CreateOrder", mock.Anything).Return(models.Order{}, errors.New("disk full"))
+	couponRepo := newTestCouponRepo(t)
+	stock := newTestInventory()
+	cartService := NewCart(repo, orders, &catalog.CatalogMock{}, stock, newTestPromotions(t), coupons.NewCoupons(couponRepo), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	_, err := cartService.CreateOrderForCart("cart1")
+
+	// Then the coupon can still be redeemed
+	require.EqualError(t, err, "disk full")
+	stock.AssertCalled(t, "Release", 1)
+	coupon, _ := couponRepo.GetCoupon("TENOFF")
+	require.Equal(t, 0, coupon.Redemptions)
+}
+
+func TestCreateOrderForCart_Adjustments_Add_Up_To_Totals(t *testing.T) {
+	// Given a cart getting the extra coffee, the accessories discount and a coupon on top of them
+	testCart := readyToOrder(models.Cart{
+		ID:     "cart1",
+		UserID: "12345",
+		Items: []models.LineItem{
+			{Product: models.Product{Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 2},
+			freeExtraCoffee(),
+			{Product: models.Product{Name: "bag", Category: models.AccessoriesCategory, Price: usd(80)}, Quantity: 1},
+		},
+		Coupons: []string{"TENOFF", "FREESHIP"},
+	}, "US")
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByID", "cart1").Return(testCart, nil)
+	repo.On("CheckoutCart", "cart1").Return(checkedOut(testCart), nil)
+	cartService := NewCart(repo, newTestOrders(), &catalog.CatalogMock{}, newTestInventory(), newTestPromotions(t), coupons.NewCoupons(newTestCouponRepo(t)), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+
+	// When
+	details, err := cartService.GetCart("cart1")
+	require.NoError(t, err)
+	order, err := cartService.CreateOrderForCart("cart1")
+
+	// Then
+	require.NoError(t, err)
+	paid := []string{"coffee1", "bag"}
+	require.Equal(t, []models.Adjustment{
+		{ID: promotions.ExtraCoffeeID, Description: "Free extraCoffee for at least 2 coffee products", Items: []string{"extraCoffee"}, Amount: usd(0)},
+		{ID: promotions.AccessoriesDiscountID, Description: "10% off for at least 70.01 USD of accessories products", Items: paid, Amount: usd(10)},
+		{ID: "coupon-TENOFF", Description: "Coupon TENOFF", Items: paid, Amount: usd(10)},
+		{ID: "coupon-FREESHIP", Description: "Coupon FREESHIP", Amount: usd(20), Shipping: true},
+	}, order.Totals.Adjustments)
+	require.Equal(t, order.Totals.Adjustments, details.Pricing.Adjustments)
+
+	discounts, waived := usd(0), usd(0)
+	for _, adjustment := range order.Totals.Adjustments {
+		if adjustment.Shipping {
+			waived = waived.Add(adjustment.Amount)
+		} else {
+			discounts = discounts.Add(adjustment.Amount)
+		}
+	}
+	require.Equal(t, order.Totals.Discounts, discounts)
+	require.Equal(t, order.Totals.ShippingRate.Sub(order.Totals.Shipping), waived)
+	require.Equal(t, usd(80), order.Totals.Price)
+}
@@ -0,0 +1,25 @@
+package cart
+
+import (
+	"errors"
+	"fmt"
+	"trafilea-tech-challenge/pkg/storage"
+)
+
+var (
+	ErrCartNotFound      = storage.ErrCartNotFound
+	ErrProductNotInCart  = storage.ErrProductNotInCart
+	ErrCartCheckedOut    = storage.ErrCartCheckedOut
+	ErrOrderNotFound     = storage.ErrOrderNotFound
+	ErrOrderExists       = storage.ErrOrderExists
+	ErrInsufficientStock = storage.ErrInsufficientStock
+	ErrReservationExists = storage.ErrReservationExists
+	ErrCouponNotInCart   = storage.ErrCouponNotInCart
+	ErrRedemptionExists  = storage.ErrRedemptionExists
+	ErrValidation        = errors.New("invalid request")
+	ErrConflict          = errors.New("conflict")
+)
+
+func validationError(message string) error {
+	return fmt.Errorf("%w: %v", ErrValidation, message)
+}
@@ -0,0 +1,228 @@
+package cart
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"trafilea-tech-challenge/pkg/catalog"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+// guestTokenBytes is how many random bytes guest tokens have, enough for them not to be guessed.
+const guestTokenBytes = 24
+
+// MergeStrategy resolves the conflicts of merging a guest cart into the cart of a user, for the products both
+// carts have.
+type MergeStrategy string
+
+const (
+	// MergeSum adds up the units of the product in both carts.
+	MergeSum MergeStrategy = "sum"
+	// MergeNewest keeps the units of the product in the guest cart, the one the user was shopping with last.
+	MergeNewest MergeStrategy = "newest"
+)
+
+// MergeStrategies are the strategies merges can be done with.
+var MergeStrategies = []MergeStrategy{MergeSum, MergeNewest}
+
+// Valid reports whether the strategy is one of MergeStrategies.
+func (s MergeStrategy) Valid() bool {
+	for _, strategy := range MergeStrategies {
+		if s == strategy {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *cart) GetGuestCart(guestToken string) (models.CartDetails, error) {
+	guestCart, err := c.CartRepo.GetCartByGuestToken(guestToken)
+	if err != nil {
+		return models.CartDetails{}, err
+	}
+
+	return models.CartDetails{Cart: guestCart, Pricing: c.preview(guestCart)}, nil
+}
+
+// MergeGuestCart combines the guest cart into the open cart the user who just logged in created last, resolving the
+// products both carts have with the strategy, and deletes the guest cart. A user without an open cart gets the guest
+// cart itself. The free items are derived again for the merged cart, while the stock of the merged quantities is
+// checked once the order is placed.
+func (c *cart) MergeGuestCart(guestToken, userID string, strategy MergeStrategy) (models.Cart, error) {
+	if userID == "" {
+		return models.Cart{}, validationError("user_id is required")
+	}
+
+	if !strategy.Valid() {
+		return models.Cart{}, validationError(fmt.Sprintf("merge strategy %q is not supported, try one of %v", strategy, MergeStrategies))
+	}
+
+	guestCart, err := c.CartRepo.GetCartByGuestToken(guestToken)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	products, err := c.catalogProducts(guestCart, userID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	mergedCart, err := c.CartRepo.MergeCart(guestCart.ID, userID, func(userCart, guestCart models.Cart) (models.Cart, error) {
+		if userCart.ID == "" {
+			guestCart.UserID, guestCart.GuestToken = userID, ""
+			return guestCart, nil
+		}
+
+		return mergeCarts(userCart, guestCart, strategy, products)
+	})
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	return c.refreshFreeItems(mergedCart.ID, mergedCart)
+}
+
+// catalogProducts returns the products of the guest cart as the catalog has them, by SKU, when the cart of the user
+// is in another currency and they have to be priced again. They're looked up before the carts are merged, since the
+// catalog may be stored along with the carts being changed.
+func (c *cart) catalogProducts(guestCart models.Cart, userID string) (map[string]models.Product, error) {
+	userCart, err := c.CartRepo.GetCartByUserID(userID)
+	if errors.Is(err, ErrCartNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if currencyOf(userCart) == currencyOf(guestCart) {
+		return nil, nil
+	}
+
+	products := make(map[string]models.Product, len(guestCart.Items))
+	for _, item := range guestCart.Items {
+		if item.Free {
+			continue
+		}
+
+		product, err := c.Catalog.GetProduct(item.Product.SKU)
+		if err != nil {
+			return nil, err
+		}
+		products[product.SKU] = product
+	}
+
+	return products, nil
+}
+
+// mergeCarts returns the cart of the user with the products, coupons and checkout of the guest cart added to it.
+// Products of a guest cart in another currency are priced again in the currency of the cart of the user, and the
+// checkout of the user is kept over the one of the guest.
+func mergeCarts(userCart, guestCart models.Cart, strategy MergeStrategy, products map[string]models.Product) (models.Cart, error) {
+	merged := userCart
+	merged.Items = make([]models.LineItem, 0, len(userCart.Items)+len(guestCart.Items))
+	for _, item := range userCart.Items {
+		if !item.Free {
+			merged.Items = append(merged.Items, item)
+		}
+	}
+
+	for _, item := range guestCart.Items {
+		if item.Free {
+			continue
+		}
+
+		i := indexOfItem(merged, item.Product.Name)
+		switch {
+		case i >= 0 && strategy == MergeSum:
+			merged.Items[i].Quantity += item.Quantity
+		case i >= 0:
+			merged.Items[i].Quantity = item.Quantity
+		default:
+			product, err := priceForCart(item.Product, guestCart, userCart, products)
+			if err != nil {
+				return models.Cart{}, err
+			}
+			merged.Items = append(merged.Items, models.LineItem{Product: product, Quantity: item.Quantity})
+		}
+	}
+
+	merged.Coupons = append([]string{}, userCart.Coupons...)
+	for _, code := range guestCart.Coupons {
+		if !hasCoupon(merged, code) {
+			merged.Coupons = append(merged.Coupons, code)
+		}
+	}
+	if len(merged.Coupons) == 0 {
+		merged.Coupons = nil
+	}
+
+	merged.Checkout = withMissingCheckout(userCart.Checkout, guestCart.Checkout)
+	return merged, nil
+}
+
+// priceForCart returns the product of the cart it was added to as priced for the cart it's moved to, at the price the
+// catalog has for it in the currency of that cart. A product missing from the catalog products was added, or the cart
+// it's moved to was created, while the carts were being merged.
+func priceForCart(product models.Product, from, to models.Cart, products map[string]models.Product) (models.Product, error) {
+	if currencyOf(from) == currencyOf(to) {
+		return product, nil
+	}
+
+	catalogProduct, ok := products[product.SKU]
+	if !ok {
+		return models.Product{}, fmt.Errorf("%w: %v", ErrConflict, "the carts changed while they were merged, try again")
+	}
+
+	return catalog.PriceIn(catalogProduct, currencyOf(to), to.ExchangeRate)
+}
+
+// indexOfItem returns the index of the line item of the product the user added to the cart, or -1 if it isn't in the
+// cart.
+func indexOfItem(userCart models.Cart, product string) int {
+	for i, item := range userCart.Items {
+		if !item.Free && item.Product.Name == product {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func hasCoupon(userCart models.Cart, code string) bool {
+	for _, applied := range userCart.Coupons {
+		if applied == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withMissingCheckout returns the checkout with the fields it doesn't have taken from the other one.
+func withMissingCheckout(checkout, other models.Checkout) models.Checkout {
+	if checkout.Email == "" {
+		checkout.Email = other.Email
+	}
+	if checkout.ShippingAddress == nil {
+		checkout.ShippingAddress = other.ShippingAddress
+	}
+	if checkout.BillingAddress == nil {
+		checkout.BillingAddress = other.BillingAddress
+	}
+	if checkout.DeliveryMethod == "" {
+		checkout.DeliveryMethod = other.DeliveryMethod
+	}
+
+	return checkout
+}
+
+// newGuestToken returns a random token identifying a guest cart, safe to be used in URLs.
+func newGuestToken() (string, error) {
+	token := make([]byte, guestTokenBytes)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
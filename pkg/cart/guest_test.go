@@ -0,0 +1,263 @@
+package cart
+
+import (
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"trafilea-tech-challenge/pkg/catalog"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+	"trafilea-tech-challenge/pkg/storage"
+)
+
+func newGuestTestService(t *testing.T, repo storage.CartRepository, products catalog.Catalog) Cart {
+	return NewCart(repo, &storage.OrderRepositoryMock{}, products, newTestInventory(), newTestPromotions(t), newTestCoupons(), newTestRates(t), DefaultShippingMethods(), NewNoTaxCalculator(), NewSequenceOrderIDGenerator(1))
+}
+
+func coffee(sku, name string, price money.Money) models.Product {
+	return models.Product{SKU: sku, Name: name, Category: models.CoffeeCategory, Price: price}
+}
+
+func TestCreateCart_For_Guest(t *testing.T) {
+	// Given
+	repo := storage.NewCartRepo(map[string]models.Cart{})
+	cartService := newGuestTestService(t, repo, &catalog.CatalogMock{})
+
+	// When
+	first, err := cartService.CreateCart("", "", "")
+	require.NoError(t, err)
+	second, err := cartService.CreateCart("", "", "")
+	require.NoError(t, err)
+
+	// Then
+	require.NotEmpty(t, first.GuestToken)
+	require.NotEqual(t, first.GuestToken, second.GuestToken)
+	require.NotEqual(t, first.ID, second.ID)
+
+	guestCart, err := cartService.GetGuestCart(first.GuestToken)
+	require.NoError(t, err)
+	require.Equal(t, first.ID, guestCart.ID)
+}
+
+func TestGetGuestCart_Not_Found(t *testing.T) {
+	// Given
+	cartService := newGuestTestService(t, storage.NewCartRepo(map[string]models.Cart{}), &catalog.CatalogMock{})
+
+	// When
+	_, err := cartService.GetGuestCart("unknown")
+
+	// Then
+	require.ErrorIs(t, err, ErrCartNotFound)
+}
+
+func TestMergeGuestCart_Success(t *testing.T) {
+	espresso := coffee("COF-001", "espresso", usd(10))
+	decaf := coffee("COF-002", "decaf", usd(12))
+	address := &models.Address{Name: "Ada Lovelace", Line1: "1 Main St", City: "Springfield", PostalCode: "12345", Country: "US"}
+
+	tests := []struct {
+		name          string
+		strategy      MergeStrategy
+		expectedItems []models.LineItem
+	}{
+		{
+			name:     "sum adds up the units of both carts",
+			strategy: MergeSum,
+			expectedItems: []models.LineItem{
+				{Product: espresso, Quantity: 3},
+				{Product: decaf, Quantity: 1},
+				freeExtraCoffee(),
+			},
+		},
+		{
+			name:     "newest keeps the units of the guest cart",
+			strategy: MergeNewest,
+			expectedItems: []models.LineItem{
+				{Product: espresso, Quantity: 2},
+				{Product: decaf, Quantity: 1},
+				freeExtraCoffee(),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo := storage.NewCartRepo(map[string]models.Cart{})
+			userCart, err := repo.CreateCart("12345", models.Cart{
+				ID:       "cart1",
+				UserID:   "12345",
+				Items:    []models.LineItem{{Product: espresso, Quantity: 1}},
+				Currency: money.USD,
+				Coupons:  []string{"TENOFF"},
+				Checkout: models.Checkout{Email: "ada@example.com"},
+			})
+			require.NoError(t, err)
+			_, err = repo.CreateCart("", models.Cart{
+				ID:         "guest1",
+				GuestToken: "token1",
+				Items:      []models.LineItem{{Product: espresso, Quantity: 2}, {Product: decaf, Quantity: 1}},
+				Currency:   money.USD,
+				Coupons:    []string{"FREESHIP", "TENOFF"},
+				Checkout:   models.Checkout{Email: "guest@example.com", ShippingAddress: address},
+			})
+			require.NoError(t, err)
+			cartService := newGuestTestService(t, repo, &catalog.CatalogMock{})
+
+			// When
+			merged, err := cartService.MergeGuestCart("token1", "12345", tt.strategy)
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, userCart.ID, merged.ID)
+			require.Equal(t, "12345", merged.UserID)
+			require.Empty(t, merged.GuestToken)
+			require.Equal(t, tt.expectedItems, merged.Items)
+			require.Equal(t, []string{"TENOFF", "FREESHIP"}, merged.Coupons)
+			require.Equal(t, "ada@example.com", merged.Checkout.Email)
+			require.Equal(t, address, merged.Checkout.ShippingAddress)
+
+			_, err = repo.GetCartByGuestToken("token1")
+			require.ErrorIs(t, err, storage.ErrCartNotFound)
+			_, err = repo.GetCartByID("guest1")
+			require.ErrorIs(t, err, storage.ErrCartNotFound)
+		})
+	}
+}
+
+func TestMergeGuestCart_Hands_Guest_Cart_Over(t *testing.T) {
+	// Given a user without a cart
+	espresso := coffee("COF-001", "espresso", usd(10))
+	repo := storage.NewCartRepo(map[string]models.Cart{})
+	_, err := repo.CreateCart("", models.Cart{
+		ID:         "guest1",
+		GuestToken: "token1",
+		Items:      []models.LineItem{{Product: espresso, Quantity: 1}},
+		Currency:   money.USD,
+	})
+	require.NoError(t, err)
+	cartService := newGuestTestService(t, repo, &catalog.CatalogMock{})
+
+	// When
+	merged, err := cartService.MergeGuestCart("token1", "12345", MergeSum)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "guest1", merged.ID)
+	require.Equal(t, "12345", merged.UserID)
+	require.Empty(t, merged.GuestToken)
+	require.Equal(t, []models.LineItem{{Product: espresso, Quantity: 1}}, merged.Items)
+
+	userCart, err := repo.GetCartByUserID("12345")
+	require.NoError(t, err)
+	require.Equal(t, "guest1", userCart.ID)
+}
+
+func TestMergeGuestCart_Prices_In_User_Cart_Currency(t *testing.T) {
+	// Given a guest cart in EUR merged into a cart in USD
+	rates := newTestRates(t)
+	toEUR, _ := rates.Rate(money.EUR)
+	decaf := coffee("COF-002", "decaf", usd(12))
+	products := &catalog.CatalogMock{}
+	products.On("GetProduct", "COF-002").Return(decaf, nil)
+
+	repo := storage.NewCartRepo(map[string]models.Cart{})
+	_, err := repo.CreateCart("12345", models.Cart{ID: "cart1", UserID: "12345", Items: []models.LineItem{}, Currency: money.USD})
+	require.NoError(t, err)
+	_, err = repo.CreateCart("", models.Cart{
+		ID:           "guest1",
+		GuestToken:   "token1",
+		Items:        []models.LineItem{{Product: coffee("COF-002", "decaf", money.New(1104, money.EUR)), Quantity: 1}},
+		Currency:     money.EUR,
+		ExchangeRate: &toEUR,
+	})
+	require.NoError(t, err)
+	cartService := newGuestTestService(t, repo, products)
+
+	// When
+	merged, err := cartService.MergeGuestCart("token1", "12345", MergeSum)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []models.LineItem{{Product: decaf, Quantity: 1}}, merged.Items)
+	products.AssertExpectations(t)
+}
+
+func TestMergeGuestCart_User_Cart_Changed_Currency(t *testing.T) {
+	// Given a guest cart in EUR merged into the cart of a user in EUR, who creates a cart in USD meanwhile
+	rates := newTestRates(t)
+	toEUR, _ := rates.Rate(money.EUR)
+	guestCart := models.Cart{
+		ID:           "guest1",
+		GuestToken:   "token1",
+		Items:        []models.LineItem{{Product: coffee("COF-002", "decaf", money.New(1104, money.EUR)), Quantity: 1}},
+		Currency:     money.EUR,
+		ExchangeRate: &toEUR,
+	}
+	repo := &storage.CartRepositoryMock{}
+	repo.On("GetCartByGuestToken", "token1").Return(guestCart, nil)
+	repo.On("GetCartByUserID", "12345").Return(models.Cart{ID: "cart1", UserID: "12345", Currency: money.EUR, ExchangeRate: &toEUR}, nil)
+	repo.On("MergeCart", "guest1", "12345", mock.Anything).Return(func(_, _ string, merge storage.MergeFunc) (models.Cart, error) {
+		return merge(models.Cart{ID: "cart2", UserID: "12345", Currency: money.USD}, guestCart)
+	})
+	cartService := newGuestTestService(t, repo, &catalog.CatalogMock{})
+
+	// When
+	_, err := cartService.MergeGuestCart("token1", "12345", MergeSum)
+
+	// Then
+	require.ErrorIs(t, err, ErrConflict)
+	require.EqualError(t, err, "conflict: the carts changed while they were merged, try again")
+}
+
+func TestMergeGuestCart_Error(t *testing.T) {
+	tests := []struct {
+		name        string
+		guestToken  string
+		userID      string
+		strategy    MergeStrategy
+		expectedErr error
+		message     string
+	}{
+		{
+			name:        "user is required",
+			guestToken:  "token1",
+			strategy:    MergeSum,
+			expectedErr: ErrValidation,
+			message:     "invalid request: user_id is required",
+		},
+		{
+			name:        "strategy is not supported",
+			guestToken:  "token1",
+			userID:      "12345",
+			strategy:    "oldest",
+			expectedErr: ErrValidation,
+			message:     `invalid request: merge strategy "oldest" is not supported, try one of [sum newest]`,
+		},
+		{
+			name:        "guest cart doesn't exist",
+			guestToken:  "unknown",
+			userID:      "12345",
+			strategy:    MergeSum,
+			expectedErr: ErrCartNotFound,
+			message:     "guest cart doesn't exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo := storage.NewCartRepo(map[string]models.Cart{})
+			_, err := repo.CreateCart("", models.Cart{ID: "guest1", GuestToken: "token1", Items: []models.LineItem{}, Currency: money.USD})
+			require.NoError(t, err)
+			cartService := newGuestTestService(t, repo, &catalog.CatalogMock{})
+
+			// When
+			_, err = cartService.MergeGuestCart(tt.guestToken, tt.userID, tt.strategy)
+
+			// Then
+			require.ErrorIs(t, err, tt.expectedErr)
+			require.EqualError(t, err, tt.message)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package cart
+
+import (
+	"sync"
+	"time"
+)
+
+// OrderIDGenerator hands out the numbers identifying the orders. Implementations must be safe for concurrent use.
+type OrderIDGenerator interface {
+	NextID() int
+}
+
+// timeOrderIDGenerator hands out the milliseconds elapsed since the Unix epoch, bumped by one when orders are
+// placed within the same millisecond, so IDs never repeat and grow in the order the orders were placed.
+type timeOrderIDGenerator struct {
+	mu   sync.Mutex
+	last int
+	now  func() time.Time
+}
+
+func NewTimeOrderIDGenerator() OrderIDGenerator {
+	return &timeOrderIDGenerator{now: time.Now}
+}
+
+func (g *timeOrderIDGenerator) NextID() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.last = max(g.last+1, int(g.now().UnixMilli()))
+	return g.last
+}
+
+// sequenceOrderIDGenerator hands out consecutive IDs from a known start, so tests can predict them.
+type sequenceOrderIDGenerator struct {
+	mu   sync.Mutex
+	next int
+}
+
+func NewSequenceOrderIDGenerator(start int) OrderIDGenerator {
+	return &sequenceOrderIDGenerator{next: start}
+}
+
+func (g *sequenceOrderIDGenerator) NextID() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	id := g.next
+	g.next++
+	return id
+}
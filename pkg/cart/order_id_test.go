@@ -0,0 +1,76 @@
+package cart
+
+import (
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTimeOrderIDGenerator_Same_Millisecond(t *testing.T) {
+	// Given
+	now := time.UnixMilli(1700000000000)
+	generator := &timeOrderIDGenerator{now: func() time.Time { return now }}
+
+	// When
+	first := generator.NextID()
+	second := generator.NextID()
+
+	// Then
+	require.Equal(t, 1700000000000, first)
+	require.Equal(t, 1700000000001, second)
+}
+
+func TestTimeOrderIDGenerator_Clock_Going_Back(t *testing.T) {
+	// Given
+	now := time.UnixMilli(1700000000000)
+	generator := &timeOrderIDGenerator{now: func() time.Time { return now }}
+	first := generator.NextID()
+
+	// When
+	now = now.Add(-time.Second)
+	second := generator.NextID()
+
+	// Then
+	require.Greater(t, second, first)
+}
+
+func TestTimeOrderIDGenerator_Concurrent_IDs_Are_Unique(t *testing.T) {
+	// Given
+	generator := NewTimeOrderIDGenerator()
+	const clients, idsPerClient = 20, 500
+	ids := make(chan int, clients*idsPerClient)
+
+	// When
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < idsPerClient; j++ {
+				ids <- generator.NextID()
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	// Then
+	seen := make(map[int]bool)
+	for id := range ids {
+		require.False(t, seen[id], "order ID %v handed out twice", id)
+		seen[id] = true
+	}
+	require.Len(t, seen, clients*idsPerClient)
+}
+
+func TestSequenceOrderIDGenerator(t *testing.T) {
+	// Given
+	generator := NewSequenceOrderIDGenerator(100)
+
+	// When
+	ids := []int{generator.NextID(), generator.NextID(), generator.NextID()}
+
+	// Then
+	require.Equal(t, []int{100, 101, 102}, ids)
+}
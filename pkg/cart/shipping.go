@@ -0,0 +1,338 @@
+package cart
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+)
+
+// DefaultShippingMethod is how the shops that don't describe their shipping methods ship: a flat rate of 20.
+const DefaultShippingMethod = "standard"
+
+// defaultZone holds the rates of the regions that aren't in any other zone.
+const defaultZone = "default"
+
+// ShippingCalculator works out what shipping a cart to a region costs, in the cart currency. The amounts calculators
+// are built with are in the catalog currency, and carts in another one pay them converted with their exchange rate.
+// Implementations must be safe for concurrent use.
+type ShippingCalculator interface {
+	Quote(cart models.Cart, region string) (money.Money, error)
+}
+
+// flatRateShipping charges the same for every cart.
+type flatRateShipping struct {
+	price money.Money
+}
+
+func NewFlatRateShipping(price money.Money) ShippingCalculator {
+	return flatRateShipping{price: price}
+}
+
+func (s flatRateShipping) Quote(cart models.Cart, region string) (money.Money, error) {
+	return inCartCurrency(cart, s.price), nil
+}
+
+// WeightBracket is the price of shipping the carts that weigh up to UpTo grams.
+type WeightBracket struct {
+	UpTo  int         `json:"up_to" yaml:"up_to"`
+	Price money.Money `json:"price" yaml:"price"`
+}
+
+// weightShipping charges the price of the lightest bracket the cart fits in.
+type weightShipping struct {
+	brackets []WeightBracket
+}
+
+// NewWeightShipping takes the brackets in any order. Carts heavier than every bracket can't be shipped.
+func NewWeightShipping(brackets ...WeightBracket) ShippingCalculator {
+	sorted := append([]WeightBracket{}, brackets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].UpTo < sorted[j].UpTo })
+
+	return weightShipping{brackets: sorted}
+}
+
+func (s weightShipping) Quote(cart models.Cart, region string) (money.Money, error) {
+	weight := 0
+	for _, item := range cart.Items {
+		weight += item.Product.Weight * item.Quantity
+	}
+
+	for _, bracket := range s.brackets {
+		if weight <= bracket.UpTo {
+			return inCartCurrency(cart, bracket.Price), nil
+		}
+	}
+
+	return money.Money{}, validationError(fmt.Sprintf("the cart weighs %vg, more than this shipping method takes", weight))
+}
+
+// zoneShipping charges what the calculator of the destination zone does.
+type zoneShipping struct {
+	zones map[string]ShippingCalculator
+}
+
+// NewZoneShipping takes the calculator of every zone by region. Regions without a zone are shipped as their parent
+// one, e.g. US-NY as US, and the "default" zone, if any, takes the rest.
+func NewZoneShipping(zones map[string]ShippingCalculator) ShippingCalculator {
+	shipping := zoneShipping{zones: make(map[string]ShippingCalculator, len(zones))}
+	for region, calculator := range zones {
+		shipping.zones[strings.ToUpper(region)] = calculator
+	}
+
+	return shipping
+}
+
+func (s zoneShipping) Quote(cart models.Cart, region string) (money.Money, error) {
+	for zone, ok := strings.ToUpper(region), region != ""; ok; zone, ok = parentRegion(zone) {
+		if calculator, found := s.zones[zone]; found {
+			return calculator.Quote(cart, region)
+		}
+	}
+
+	if calculator, ok := s.zones[strings.ToUpper(defaultZone)]; ok {
+		return calculator.Quote(cart, region)
+	}
+
+	if region == "" {
+		return money.Money{}, validationError("region is required to work out the shipping")
+	}
+
+	return money.Money{}, validationError(fmt.Sprintf("there are no shipping rates to region %v", region))
+}
+
+// freeOverShipping ships for free the carts whose subtotal reaches the threshold.
+type freeOverShipping struct {
+	threshold  money.Money
+	calculator ShippingCalculator
+}
+
+// NewFreeOverShipping ships the carts below the threshold as the calculator does.
+func NewFreeOverShipping(threshold money.Money, calculator ShippingCalculator) ShippingCalculator {
+	return freeOverShipping{threshold: threshold, calculator: calculator}
+}
+
+func (s freeOverShipping) Quote(cart models.Cart, region string) (money.Money, error) {
+	subtotal := money.Zero(currencyOf(cart))
+	for _, item := range cart.Items {
+		subtotal = subtotal.Add(item.Product.Price.Mul(item.Quantity))
+	}
+
+	if subtotal.Cmp(inCartCurrency(cart, s.threshold)) >= 0 {
+		return money.Zero(currencyOf(cart)), nil
+	}
+
+	return s.calculator.Quote(cart, region)
+}
+
+// ShippingQuote is what shipping a cart with a Method costs.
+type ShippingQuote struct {
+	Method string
+	Rate   money.Money
+}
+
+// ShippingMethods are the ways the shop ships, each with its calculator. Carts whose user doesn't choose one are
+// shipped with the Default method.
+type ShippingMethods struct {
+	Default string
+	methods map[string]ShippingCalculator
+}
+
+func NewShippingMethods(defaultMethod string, methods map[string]ShippingCalculator) (ShippingMethods, error) {
+	if _, ok := methods[defaultMethod]; !ok {
+		return ShippingMethods{}, errors.New(fmt.Sprintf("the default shipping method %q is not one of the methods", defaultMethod))
+	}
+
+	return ShippingMethods{Default: defaultMethod, methods: methods}, nil
+}
+
+// DefaultShippingMethods ship every cart at a flat rate of 20 in the catalog currency.
+func DefaultShippingMethods() ShippingMethods {
+	return ShippingMethods{
+		Default: DefaultShippingMethod,
+		methods: map[string]ShippingCalculator{
+			DefaultShippingMethod: NewFlatRateShipping(money.FromMajor(20, money.DefaultCurrency)),
+		},
+	}
+}
+
+// Names returns the names of the methods, sorted.
+func (m ShippingMethods) Names() []string {
+	names := make([]string, 0, len(m.methods))
+	for name := range m.methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Offers reports whether the shop ships with the method.
+func (m ShippingMethods) Offers(method string) bool {
+	_, ok := m.methods[method]
+	return ok
+}
+
+// Quote works out what shipping the cart to the region with the method costs, the default method if it's empty.
+func (m ShippingMethods) Quote(cart models.Cart, method, region string) (ShippingQuote, error) {
+	if method == "" {
+		method = m.Default
+	}
+
+	calculator, ok := m.methods[method]
+	if !ok {
+		return ShippingQuote{}, validationError(fmt.Sprintf("shipping method %q is not offered, try one of %v", method, m.Names()))
+	}
+
+	rate, err := calculator.Quote(cart, region)
+	if err != nil {
+		return ShippingQuote{}, err
+	}
+
+	return ShippingQuote{Method: method, Rate: rate}, nil
+}
+
+// ShippingRules describe the shipping methods of the shop, e.g.
+//
+//	default_method: standard
+//	methods:
+//	  standard:
+//	    zones:
+//	      US: {flat: "20.00"}
+//	      default: {flat: "45.00"}
+//	    free_over: "150.00"
+//	  express:
+//	    weight:
+//	      - {up_to: 1000, price: "25.00"}
+//	      - {up_to: 5000, price: "40.00"}
+//
+// Amounts are in the catalog currency and weights in grams.
+type ShippingRules struct {
+	DefaultMethod string                  `json:"default_method" yaml:"default_method"`
+	Methods       map[string]ShippingRule `json:"methods" yaml:"methods"`
+}
+
+// ShippingRule describes a calculator: a Flat rate, Weight brackets or the rule of every zone, and optionally the
+// subtotal it ships for free from.
+type ShippingRule struct {
+	Flat     *money.Money            `json:"flat,omitempty" yaml:"flat,omitempty"`
+	Weight   []WeightBracket         `json:"weight,omitempty" yaml:"weight,omitempty"`
+	Zones    map[string]ShippingRule `json:"zones,omitempty" yaml:"zones,omitempty"`
+	FreeOver *money.Money            `json:"free_over,omitempty" yaml:"free_over,omitempty"`
+}
+
+// LoadShippingRules reads the shipping rules from a JSON or YAML file, depending on its extension.
+func LoadShippingRules(path string) (ShippingRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ShippingRules{}, err
+	}
+
+	var rules ShippingRules
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&rules)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&rules)
+	default:
+		return ShippingRules{}, errors.New(fmt.Sprintf("unsupported shipping rules file format %v", filepath.Ext(path)))
+	}
+	if err != nil {
+		return ShippingRules{}, errors.New(fmt.Sprintf("parsing %v: %v", path, err))
+	}
+
+	return rules, nil
+}
+
+// NewRulesShippingMethods checks the rules, so a mistake in the rules file is found on startup.
+func NewRulesShippingMethods(rules ShippingRules) (ShippingMethods, error) {
+	methods := make(map[string]ShippingCalculator, len(rules.Methods))
+	for name, rule := range rules.Methods {
+		calculator, err := rule.calculator()
+		if err != nil {
+			return ShippingMethods{}, errors.New(fmt.Sprintf("method %v: %v", name, err))
+		}
+		methods[name] = calculator
+	}
+
+	return NewShippingMethods(rules.DefaultMethod, methods)
+}
+
+func (r ShippingRule) calculator() (ShippingCalculator, error) {
+	var calculator ShippingCalculator
+	switch {
+	case r.Flat != nil && r.Weight == nil && r.Zones == nil:
+		if err := checkShippingAmount(*r.Flat, "flat"); err != nil {
+			return nil, err
+		}
+		calculator = NewFlatRateShipping(*r.Flat)
+	case r.Flat == nil && r.Weight != nil && r.Zones == nil:
+		seen := make(map[int]bool, len(r.Weight))
+		for _, bracket := range r.Weight {
+			if bracket.UpTo <= 0 {
+				return nil, errors.New("weight up_to must be greater than 0")
+			}
+			if seen[bracket.UpTo] {
+				return nil, errors.New(fmt.Sprintf("there's more than one weight bracket up to %vg", bracket.UpTo))
+			}
+			seen[bracket.UpTo] = true
+			if err := checkShippingAmount(bracket.Price, "weight price"); err != nil {
+				return nil, err
+			}
+		}
+		calculator = NewWeightShipping(r.Weight...)
+	case r.Flat == nil && r.Weight == nil && r.Zones != nil:
+		zones := make(map[string]ShippingCalculator, len(r.Zones))
+		for region, rule := range r.Zones {
+			zone, err := rule.calculator()
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("zone %v: %v", region, err))
+			}
+			zones[region] = zone
+		}
+		calculator = NewZoneShipping(zones)
+	default:
+		return nil, errors.New("exactly one of flat, weight or zones is required")
+	}
+
+	if r.FreeOver != nil {
+		if err := checkShippingAmount(*r.FreeOver, "free_over"); err != nil {
+			return nil, err
+		}
+		calculator = NewFreeOverShipping(*r.FreeOver, calculator)
+	}
+
+	return calculator, nil
+}
+
+func checkShippingAmount(amount money.Money, field string) error {
+	if amount.Currency != money.DefaultCurrency {
+		return errors.New(fmt.Sprintf("%v must be in %v, the catalog currency", field, money.DefaultCurrency))
+	}
+	if amount.IsNegative() {
+		return errors.New(fmt.Sprintf("%v must not be negative", field))
+	}
+
+	return nil
+}
+
+// parentRegion returns the region the given one is part of, e.g. US for US-NY.
+func parentRegion(region string) (string, bool) {
+	parent := strings.LastIndex(region, "-")
+	if parent < 0 {
+		return "", false
+	}
+
+	return region[:parent], true
+}
@@ -0,0 +1,240 @@
+package cart
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+)
+
+func newShippingTestCart(weight int, price money.Money) models.Cart {
+	return models.Cart{
+		ID: "cart1",
+		Items: []models.LineItem{
+			{Product: models.Product{Name: "coffee1", Category: models.CoffeeCategory, Price: price, Weight: weight}, Quantity: 2},
+		},
+	}
+}
+
+func TestShippingCalculators(t *testing.T) {
+	weight := NewWeightShipping(
+		WeightBracket{UpTo: 5000, Price: usd(30)},
+		WeightBracket{UpTo: 1000, Price: usd(10)},
+	)
+	zones := NewZoneShipping(map[string]ShippingCalculator{
+		"us":      NewFlatRateShipping(usd(20)),
+		"US-AK":   NewFlatRateShipping(usd(40)),
+		"default": NewFlatRateShipping(usd(50)),
+	})
+
+	tests := []struct {
+		name       string
+		calculator ShippingCalculator
+		cart       models.Cart
+		region     string
+		expected   money.Money
+	}{
+		{name: "flat", calculator: NewFlatRateShipping(usd(20)), cart: newShippingTestCart(0, usd(10)), expected: usd(20)},
+		{name: "lightest bracket", calculator: weight, cart: newShippingTestCart(500, usd(10)), expected: usd(10)},
+		{name: "up to a bracket", calculator: weight, cart: newShippingTestCart(2500, usd(10)), expected: usd(30)},
+		{name: "zone", calculator: zones, cart: newShippingTestCart(0, usd(10)), region: "US-AK", expected: usd(40)},
+		{name: "parent zone", calculator: zones, cart: newShippingTestCart(0, usd(10)), region: "us-ny", expected: usd(20)},
+		{name: "default zone", calculator: zones, cart: newShippingTestCart(0, usd(10)), region: "ES", expected: usd(50)},
+		{name: "below the threshold", calculator: NewFreeOverShipping(usd(50), zones), cart: newShippingTestCart(0, usd(20)), region: "US", expected: usd(20)},
+		{name: "over the threshold", calculator: NewFreeOverShipping(usd(50), zones), cart: newShippingTestCart(0, usd(25)), region: "US", expected: usd(0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			price, err := tt.calculator.Quote(tt.cart, tt.region)
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, price)
+		})
+	}
+}
+
+func TestShippingCalculators_In_Another_Currency(t *testing.T) {
+	// Given a cart of 2 x 25 EUR, which are 54.35 USD at 0.92
+	rate, _ := newTestRates(t).Rate(money.EUR)
+	cart := newShippingTestCart(0, eur(25))
+	cart.Currency, cart.ExchangeRate = money.EUR, &rate
+
+	// When
+	flat, flatErr := NewFlatRateShipping(usd(20)).Quote(cart, "")
+	free, freeErr := NewFreeOverShipping(usd(54), NewFlatRateShipping(usd(20))).Quote(cart, "")
+	notFree, notFreeErr := NewFreeOverShipping(usd(55), NewFlatRateShipping(usd(20))).Quote(cart, "")
+
+	// Then
+	require.NoError(t, flatErr)
+	require.Equal(t, money.New(1840, money.EUR), flat)
+	require.NoError(t, freeErr)
+	require.Equal(t, money.Zero(money.EUR), free)
+	require.NoError(t, notFreeErr)
+	require.Equal(t, money.New(1840, money.EUR), notFree)
+}
+
+func TestShippingCalculators_Cant_Ship(t *testing.T) {
+	zones := NewZoneShipping(map[string]ShippingCalculator{"US": NewFlatRateShipping(usd(20))})
+
+	tests := []struct {
+		name       string
+		calculator ShippingCalculator
+		region     string
+		message    string
+	}{
+		{
+			name:       "too heavy",
+			calculator: NewWeightShipping(WeightBracket{UpTo: 1000, Price: usd(10)}),
+			message:    "invalid request: the cart weighs 1200g, more than this shipping method takes",
+		},
+		{
+			name:       "no zone",
+			calculator: zones,
+			region:     "ES",
+			message:    "invalid request: there are no shipping rates to region ES",
+		},
+		{
+			name:       "no region",
+			calculator: zones,
+			message:    "invalid request: region is required to work out the shipping",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			_, err := tt.calculator.Quote(newShippingTestCart(600, usd(10)), tt.region)
+
+			// Then
+			require.ErrorIs(t, err, ErrValidation)
+			require.EqualError(t, err, tt.message)
+		})
+	}
+}
+
+func TestShippingMethods_Quote(t *testing.T) {
+	// Given
+	methods, err := NewShippingMethods("standard", map[string]ShippingCalculator{
+		"standard": NewFlatRateShipping(usd(20)),
+		"pickup":   NewFlatRateShipping(usd(0)),
+	})
+	require.NoError(t, err)
+	cart := newShippingTestCart(0, usd(10))
+
+	// When
+	standard, standardErr := methods.Quote(cart, "", "")
+	pickup, pickupErr := methods.Quote(cart, "pickup", "")
+	_, unknownErr := methods.Quote(cart, "drone", "")
+
+	// Then
+	require.NoError(t, standardErr)
+	require.Equal(t, ShippingQuote{Method: "standard", Rate: usd(20)}, standard)
+	require.NoError(t, pickupErr)
+	require.Equal(t, ShippingQuote{Method: "pickup", Rate: usd(0)}, pickup)
+	require.ErrorIs(t, unknownErr, ErrValidation)
+	require.EqualError(t, unknownErr, `invalid request: shipping method "drone" is not offered, try one of [pickup standard]`)
+}
+
+func TestNewRulesShippingMethods_Invalid_Rules(t *testing.T) {
+	flat := usd(20)
+	euros := eur(20)
+	negative := usd(-1)
+
+	tests := []struct {
+		name    string
+		rules   ShippingRules
+		message string
+	}{
+		{
+			name:    "no calculator",
+			rules:   ShippingRules{DefaultMethod: "standard", Methods: map[string]ShippingRule{"standard": {}}},
+			message: "method standard: exactly one of flat, weight or zones is required",
+		},
+		{
+			name: "more than one calculator",
+			rules: ShippingRules{DefaultMethod: "standard", Methods: map[string]ShippingRule{
+				"standard": {Flat: &flat, Weight: []WeightBracket{{UpTo: 1000, Price: usd(10)}}},
+			}},
+			message: "method standard: exactly one of flat, weight or zones is required",
+		},
+		{
+			name:    "not in the catalog currency",
+			rules:   ShippingRules{DefaultMethod: "standard", Methods: map[string]ShippingRule{"standard": {Flat: &euros}}},
+			message: "method standard: flat must be in USD, the catalog currency",
+		},
+		{
+			name: "negative threshold",
+			rules: ShippingRules{DefaultMethod: "standard", Methods: map[string]ShippingRule{
+				"standard": {Flat: &flat, FreeOver: &negative},
+			}},
+			message: "method standard: free_over must not be negative",
+		},
+		{
+			name: "repeated bracket",
+			rules: ShippingRules{DefaultMethod: "standard", Methods: map[string]ShippingRule{
+				"standard": {Weight: []WeightBracket{{UpTo: 1000, Price: usd(10)}, {UpTo: 1000, Price: usd(20)}}},
+			}},
+			message: "method standard: there's more than one weight bracket up to 1000g",
+		},
+		{
+			name: "invalid zone",
+			rules: ShippingRules{DefaultMethod: "standard", Methods: map[string]ShippingRule{
+				"standard": {Zones: map[string]ShippingRule{"US": {Flat: &negative}}},
+			}},
+			message: "method standard: zone US: flat must not be negative",
+		},
+		{
+			name:    "unknown default method",
+			rules:   ShippingRules{DefaultMethod: "express", Methods: map[string]ShippingRule{"standard": {Flat: &flat}}},
+			message: `the default shipping method "express" is not one of the methods`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			_, err := NewRulesShippingMethods(tt.rules)
+
+			// Then
+			require.EqualError(t, err, tt.message)
+		})
+	}
+}
+
+func TestLoadShippingRules(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "shipping.yaml")
+	content := `default_method: standard
+methods:
+  standard:
+    zones:
+      US: {flat: 20}
+      default: {flat: "45.00"}
+    free_over: 150
+  express:
+    weight:
+      - {up_to: 1000, price: 25}
+      - {up_to: 5000, price: 40}
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	// When
+	rules, err := LoadShippingRules(path)
+	require.NoError(t, err)
+	methods, methodsErr := NewRulesShippingMethods(rules)
+
+	// Then
+	require.NoError(t, methodsErr)
+	require.Equal(t, []string{"express", "standard"}, methods.Names())
+	quote, err := methods.Quote(newShippingTestCart(0, usd(10)), "", "ES")
+	require.NoError(t, err)
+	require.Equal(t, ShippingQuote{Method: "standard", Rate: usd(45)}, quote)
+	quote, err = methods.Quote(newShippingTestCart(1000, usd(10)), "express", "ES")
+	require.NoError(t, err)
+	require.Equal(t, ShippingQuote{Method: "express", Rate: usd(40)}, quote)
+}
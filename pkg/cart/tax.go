@@ -0,0 +1,263 @@
+package cart
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+)
+
+// maxTaxRateDecimals bounds the decimal places of the tax percentages, enough for rates such as 8.875%.
+const maxTaxRateDecimals = 4
+
+// defaultCategory holds the rate of the categories a region has no rate for.
+const defaultCategory = "default"
+
+// TaxCalculator works out the taxes of an order shipped to a region. Implementations must be safe for concurrent use.
+type TaxCalculator interface {
+	// Calculate returns the taxes of the line items once the order discount is taken off them.
+	Calculate(items []models.LineItem, discount money.Money, region string) (Taxes, error)
+}
+
+// Taxes are the taxes of an order: one per line item, in the same order, and their Total. Inclusive taxes are part of
+// the prices, such as VAT in Europe, rather than added on top of them, like sales tax in the US. Orders that aren't
+// taxed have no Lines.
+type Taxes struct {
+	Region    string
+	Inclusive bool
+	Lines     []models.Tax
+	Total     money.Money
+}
+
+// noTaxCalculator charges no taxes, for shops that don't have to collect them.
+type noTaxCalculator struct{}
+
+func NewNoTaxCalculator() TaxCalculator {
+	return noTaxCalculator{}
+}
+
+func (noTaxCalculator) Calculate(items []models.LineItem, discount money.Money, region string) (Taxes, error) {
+	return Taxes{Region: region, Total: money.Zero(discount.Currency)}, nil
+}
+
+// TaxRules is the table of tax rates by destination region and product category, e.g.
+//
+//	default_region: US
+//	regions:
+//	  US-CA:
+//	    rates: {default: "7.25", coffee: "0"}
+//	  ES:
+//	    inclusive: true
+//	    rates: {default: "21", coffee: "10"}
+//
+// Rates are percentages, and the "default" one applies to the categories a region has no rate for. Orders without a
+// region are taxed as DefaultRegion, and regions without rules as their parent one, e.g. US-NY as US.
+type TaxRules struct {
+	DefaultRegion string                    `json:"default_region" yaml:"default_region"`
+	Regions       map[string]RegionTaxRules `json:"regions" yaml:"regions"`
+}
+
+type RegionTaxRules struct {
+	Inclusive bool              `json:"inclusive" yaml:"inclusive"`
+	Rates     map[string]string `json:"rates" yaml:"rates"`
+}
+
+// LoadTaxRules reads the tax rules from a JSON or YAML file, depending on its extension.
+func LoadTaxRules(path string) (TaxRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return TaxRules{}, err
+	}
+
+	var rules TaxRules
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&rules)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&rules)
+	default:
+		return TaxRules{}, errors.New(fmt.Sprintf("unsupported tax rules file format %v", filepath.Ext(path)))
+	}
+	if err != nil {
+		return TaxRules{}, errors.New(fmt.Sprintf("parsing %v: %v", path, err))
+	}
+
+	return rules, nil
+}
+
+// regionTax are the parsed rules of a region.
+type regionTax struct {
+	inclusive bool
+	rates     map[string]taxRate
+}
+
+// rulesTaxCalculator taxes the orders with the rates of the tax rules.
+type rulesTaxCalculator struct {
+	defaultRegion string
+	regions       map[string]regionTax
+}
+
+// NewRulesTaxCalculator checks the rates of the rules, so a mistake in the rules file is found on startup.
+func NewRulesTaxCalculator(rules TaxRules) (TaxCalculator, error) {
+	calculator := &rulesTaxCalculator{
+		defaultRegion: strings.ToUpper(rules.DefaultRegion),
+		regions:       make(map[string]regionTax, len(rules.Regions)),
+	}
+
+	for region, regionRules := range rules.Regions {
+		tax := regionTax{inclusive: regionRules.Inclusive, rates: make(map[string]taxRate, len(regionRules.Rates))}
+		for category, value := range regionRules.Rates {
+			rate, err := parseTaxRate(value)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("region %v, category %v: %v", region, category, err))
+			}
+			tax.rates[category] = rate
+		}
+		calculator.regions[strings.ToUpper(region)] = tax
+	}
+
+	if calculator.defaultRegion != "" {
+		if _, _, ok := calculator.find(calculator.defaultRegion); !ok {
+			return nil, errors.New(fmt.Sprintf("there are no rules for the default region %v", rules.DefaultRegion))
+		}
+	}
+
+	return calculator, nil
+}
+
+func (c *rulesTaxCalculator) Calculate(items []models.LineItem, discount money.Money, region string) (Taxes, error) {
+	if region == "" {
+		region = c.defaultRegion
+	}
+	if region == "" {
+		return Taxes{}, validationError("region is required to calculate the taxes")
+	}
+
+	ruled, tax, ok := c.find(strings.ToUpper(region))
+	if !ok {
+		return Taxes{}, validationError(fmt.Sprintf("there are no tax rules for region %v", region))
+	}
+
+	taxes := Taxes{Region: ruled, Inclusive: tax.inclusive, Lines: make([]models.Tax, len(items)), Total: money.Zero(discount.Currency)}
+	for i, taxable := range taxableAmounts(items, discount) {
+		rate := tax.rate(items[i].Product.Category)
+		taxes.Lines[i] = models.Tax{Rate: rate.String(), Amount: rate.of(taxable, tax.inclusive)}
+		taxes.Total = taxes.Total.Add(taxes.Lines[i].Amount)
+	}
+
+	return taxes, nil
+}
+
+// find returns the rules of the region, or else of the closest parent region that has them.
+func (c *rulesTaxCalculator) find(region string) (string, regionTax, bool) {
+	for {
+		if tax, ok := c.regions[region]; ok {
+			return region, tax, true
+		}
+
+		var ok bool
+		if region, ok = parentRegion(region); !ok {
+			return "", regionTax{}, false
+		}
+	}
+}
+
+func (t regionTax) rate(category string) taxRate {
+	if rate, ok := t.rates[category]; ok {
+		return rate
+	}
+
+	return t.rates[defaultCategory]
+}
+
+// taxableAmounts returns what is paid for every line item once the discount is split among them in proportion to
+// their subtotals. Shares are rounded down on the running subtotal, so they always add up to the discount.
+func taxableAmounts(items []models.LineItem, discount money.Money) []money.Money {
+	var subtotal int64
+	for _, item := range items {
+		subtotal += item.Product.Price.Mul(item.Quantity).Amount
+	}
+
+	amounts := make([]money.Money, len(items))
+	var running int64
+	allocated := money.Zero(discount.Currency)
+	for i, item := range items {
+		line := item.Product.Price.Mul(item.Quantity)
+		share := money.Zero(discount.Currency)
+		if subtotal > 0 {
+			running += line.Amount
+			share = discount.MulRatio(running, subtotal, money.Down).Sub(allocated)
+			allocated = allocated.Add(share)
+		}
+		amounts[i] = line.Sub(share)
+	}
+
+	return amounts
+}
+
+// taxRate is a percentage, units / 10^decimals, kept exactly as it was written.
+type taxRate struct {
+	units    int64
+	decimals int
+}
+
+func parseTaxRate(value string) (taxRate, error) {
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" || strings.Trim(whole+fraction, "0123456789") != "" || strings.HasSuffix(value, ".") {
+		return taxRate{}, errors.New(fmt.Sprintf("tax rate %q is not a percentage", value))
+	}
+
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > maxTaxRateDecimals {
+		return taxRate{}, errors.New(fmt.Sprintf("tax rate %q has more than %d decimal places", value, maxTaxRateDecimals))
+	}
+
+	rate := taxRate{decimals: len(fraction)}
+	for _, digit := range whole + fraction {
+		rate.units = rate.units*10 + int64(digit-'0')
+		if rate.units > rate.hundred() {
+			return taxRate{}, errors.New(fmt.Sprintf("tax rate %q is over 100%%", value))
+		}
+	}
+
+	return rate, nil
+}
+
+// hundred is 100% in the units of the rate.
+func (r taxRate) hundred() int64 {
+	hundred := int64(100)
+	for i := 0; i < r.decimals; i++ {
+		hundred *= 10
+	}
+
+	return hundred
+}
+
+// of returns the tax of the amount, rounded to the minor unit. The amount of an inclusive tax already has it.
+func (r taxRate) of(amount money.Money, inclusive bool) money.Money {
+	if inclusive {
+		return amount.MulRatio(r.units, r.hundred()+r.units, money.HalfUp)
+	}
+
+	return amount.MulRatio(r.units, r.hundred(), money.HalfUp)
+}
+
+// String formats the percentage, e.g. "7.25".
+func (r taxRate) String() string {
+	if r.decimals == 0 {
+		return fmt.Sprintf("%d", r.units)
+	}
+
+	scale := r.hundred() / 100
+	return fmt.Sprintf("%d.%0*d", r.units/scale, r.decimals, r.units%scale)
+}
@@ -0,0 +1,189 @@
+package cart
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+)
+
+func newTestTaxCalculator(t *testing.T) TaxCalculator {
+	calculator, err := NewRulesTaxCalculator(TaxRules{
+		DefaultRegion: "US",
+		Regions: map[string]RegionTaxRules{
+			"US":    {Rates: map[string]string{"default": "0"}},
+			"US-CA": {Rates: map[string]string{"default": "7.25", models.CoffeeCategory: "0"}},
+			"ES":    {Inclusive: true, Rates: map[string]string{"default": "21", models.CoffeeCategory: "10"}},
+		},
+	})
+	require.NoError(t, err)
+	return calculator
+}
+
+func TestRulesTaxCalculator(t *testing.T) {
+	items := []models.LineItem{
+		{Product: models.Product{Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 2},
+		{Product: models.Product{Name: "mug", Category: models.AccessoriesCategory, Price: usd(30)}, Quantity: 1},
+	}
+
+	tests := []struct {
+		name      string
+		region    string
+		inclusive bool
+		expected  []models.Tax
+		total     money.Money
+	}{
+		{
+			name:   "exclusive, by category",
+			region: "US-CA",
+			expected: []models.Tax{
+				{Rate: "0", Amount: usd(0)},
+				{Rate: "7.25", Amount: money.New(218, money.USD)},
+			},
+			total: money.New(218, money.USD),
+		},
+		{
+			name:   "parent region",
+			region: "us-ny",
+			expected: []models.Tax{
+				{Rate: "0", Amount: usd(0)},
+				{Rate: "0", Amount: usd(0)},
+			},
+			total: usd(0),
+		},
+		{
+			name:      "inclusive",
+			region:    "ES",
+			inclusive: true,
+			expected: []models.Tax{
+				{Rate: "10", Amount: money.New(182, money.USD)},
+				{Rate: "21", Amount: money.New(521, money.USD)},
+			},
+			total: money.New(703, money.USD),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			taxes, err := newTestTaxCalculator(t).Calculate(items, usd(0), tt.region)
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, tt.inclusive, taxes.Inclusive)
+			require.Equal(t, tt.expected, taxes.Lines)
+			require.Equal(t, tt.total, taxes.Total)
+		})
+	}
+}
+
+func TestRulesTaxCalculator_Taxes_What_Is_Paid_After_The_Discount(t *testing.T) {
+	// Given a 10.00 discount on 20.00 of coffee and 30.00 of accessories, which take 4.00 and 6.00 of it
+	items := []models.LineItem{
+		{Product: models.Product{Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 2},
+		{Product: models.Product{Name: "mug", Category: models.AccessoriesCategory, Price: usd(30)}, Quantity: 1},
+	}
+
+	// When
+	taxes, err := newTestTaxCalculator(t).Calculate(items, usd(10), "US-CA")
+
+	// Then 7.25% of 24.00
+	require.NoError(t, err)
+	require.Equal(t, money.New(174, money.USD), taxes.Total)
+}
+
+func TestTaxableAmounts_Add_Up_To_The_Discount(t *testing.T) {
+	// Given
+	items := []models.LineItem{
+		{Product: models.Product{Name: "a", Price: money.New(100, money.USD)}, Quantity: 1},
+		{Product: models.Product{Name: "b", Price: money.New(100, money.USD)}, Quantity: 1},
+		{Product: models.Product{Name: "c", Price: money.New(100, money.USD)}, Quantity: 1},
+		{Product: models.Product{Name: "free", Price: money.Zero(money.USD)}, Quantity: 1},
+	}
+
+	// When
+	amounts := taxableAmounts(items, money.New(100, money.USD))
+
+	// Then
+	require.Equal(t, []money.Money{
+		money.New(67, money.USD),
+		money.New(67, money.USD),
+		money.New(66, money.USD),
+		money.Zero(money.USD),
+	}, amounts)
+}
+
+func TestRulesTaxCalculator_Region(t *testing.T) {
+	// Given
+	items := []models.LineItem{{Product: models.Product{Name: "mug", Category: models.AccessoriesCategory, Price: usd(30)}, Quantity: 1}}
+	noDefault, err := NewRulesTaxCalculator(TaxRules{Regions: map[string]RegionTaxRules{"ES": {Rates: map[string]string{"default": "21"}}}})
+	require.NoError(t, err)
+
+	// When
+	defaulted, defaultedErr := newTestTaxCalculator(t).Calculate(items, usd(0), "")
+	_, unknownErr := newTestTaxCalculator(t).Calculate(items, usd(0), "FR")
+	_, missingErr := noDefault.Calculate(items, usd(0), "")
+
+	// Then
+	require.NoError(t, defaultedErr)
+	require.Equal(t, "US", defaulted.Region)
+	require.ErrorIs(t, unknownErr, ErrValidation)
+	require.EqualError(t, unknownErr, "invalid request: there are no tax rules for region FR")
+	require.ErrorIs(t, missingErr, ErrValidation)
+}
+
+func TestNewRulesTaxCalculator_Invalid_Rules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   TaxRules
+		message string
+	}{
+		{
+			name:    "not a percentage",
+			rules:   TaxRules{Regions: map[string]RegionTaxRules{"ES": {Rates: map[string]string{"default": "21%"}}}},
+			message: `region ES, category default: tax rate "21%" is not a percentage`,
+		},
+		{
+			name:    "over 100%",
+			rules:   TaxRules{Regions: map[string]RegionTaxRules{"ES": {Rates: map[string]string{"default": "100.5"}}}},
+			message: `region ES, category default: tax rate "100.5" is over 100%`,
+		},
+		{
+			name:    "unknown default region",
+			rules:   TaxRules{DefaultRegion: "FR", Regions: map[string]RegionTaxRules{"ES": {Rates: map[string]string{"default": "21"}}}},
+			message: "there are no rules for the default region FR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			_, err := NewRulesTaxCalculator(tt.rules)
+
+			// Then
+			require.EqualError(t, err, tt.message)
+		})
+	}
+}
+
+func TestLoadTaxRules(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "taxes.yaml")
+	content := "default_region: US\nregions:\n  US-CA:\n    rates: {default: 8.875}\n  ES:\n    inclusive: true\n    rates: {default: \"21\"}\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	// When
+	rules, err := LoadTaxRules(path)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, TaxRules{
+		DefaultRegion: "US",
+		Regions: map[string]RegionTaxRules{
+			"US-CA": {Rates: map[string]string{"default": "8.875"}},
+			"ES":    {Inclusive: true, Rates: map[string]string{"default": "21"}},
+		},
+	}, rules)
+}
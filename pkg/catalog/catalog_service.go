@@ -0,0 +1,121 @@
+package catalog
+
+import (
+	"fmt"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+	"trafilea-tech-challenge/pkg/storage"
+)
+
+// Catalog is the authoritative source of the products on sale and their prices.
+type Catalog interface {
+	AddProduct(product models.Product) (models.Product, error)
+	UpdateProduct(sku string, product models.Product) (models.Product, error)
+	RemoveProduct(sku string) error
+	GetProduct(sku string) (models.Product, error)
+	GetProducts() ([]models.Product, error)
+}
+
+type catalog struct {
+	ProductRepo storage.ProductRepository
+}
+
+func NewCatalog(repo storage.ProductRepository) Catalog {
+	return &catalog{
+		ProductRepo: repo,
+	}
+}
+
+func (c *catalog) AddProduct(product models.Product) (models.Product, error) {
+	if err := validateProduct(product); err != nil {
+		return models.Product{}, err
+	}
+
+	return c.ProductRepo.CreateProduct(product)
+}
+
+// UpdateProduct replaces the name, category and price of the product with the given SKU.
+func (c *catalog) UpdateProduct(sku string, product models.Product) (models.Product, error) {
+	product.SKU = sku
+	if err := validateProduct(product); err != nil {
+		return models.Product{}, err
+	}
+
+	return c.ProductRepo.UpdateProduct(product)
+}
+
+func (c *catalog) RemoveProduct(sku string) error {
+	return c.ProductRepo.DeleteProduct(sku)
+}
+
+func (c *catalog) GetProduct(sku string) (models.Product, error) {
+	return c.ProductRepo.GetProductBySKU(sku)
+}
+
+func (c *catalog) GetProducts() ([]models.Product, error) {
+	return c.ProductRepo.GetProducts()
+}
+
+func validateProduct(product models.Product) error {
+	if product.SKU == "" || product.Name == "" {
+		return invalidProduct("no empty values allowed")
+	}
+
+	if !isValidCategory(product.Category) {
+		return invalidProduct("invalid category")
+	}
+
+	if !product.Price.IsPositive() {
+		return invalidProduct("price must be greater than 0")
+	}
+
+	if product.Price.Currency != money.DefaultCurrency {
+		return invalidProduct(fmt.Sprintf("price must be in %v", money.DefaultCurrency))
+	}
+
+	if product.Weight < 0 {
+		return invalidProduct("weight must not be negative")
+	}
+
+	seen := map[money.Currency]bool{money.DefaultCurrency: true}
+	for _, price := range product.Prices {
+		if !price.IsPositive() {
+			return invalidProduct("price must be greater than 0")
+		}
+		if seen[price.Currency] {
+			return invalidProduct(fmt.Sprintf("there's more than one price in %v", price.Currency))
+		}
+		seen[price.Currency] = true
+	}
+
+	return nil
+}
+
+// PriceIn returns the product priced in the currency of a cart: at the price of its price list for the currency,
+// or else at its catalog price converted with the exchange rate of the cart, which may be nil if the cart is in the
+// catalog currency.
+func PriceIn(product models.Product, currency money.Currency, rate *money.Rate) (models.Product, error) {
+	prices := product.Prices
+	product.Prices = nil
+
+	for _, price := range prices {
+		if price.Currency == currency {
+			product.Price = price
+			return product, nil
+		}
+	}
+
+	switch {
+	case product.Price.Currency == currency:
+		return product, nil
+	case rate != nil && rate.From == product.Price.Currency && rate.To == currency:
+		product.Price = rate.Convert(product.Price, money.HalfUp)
+		return product, nil
+	default:
+		return models.Product{}, fmt.Errorf("%w: product %v has no price in %v", ErrPriceNotFound, product.SKU, currency)
+	}
+}
+
+func isValidCategory(category string) bool {
+	return category == models.CoffeeCategory || category == models.EquipmentCategory || category == models.AccessoriesCategory
+}
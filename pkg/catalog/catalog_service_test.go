@@ -0,0 +1,156 @@
+package catalog
+
+import (
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+	"trafilea-tech-challenge/pkg/storage"
+)
+
+func usd(amount int64) money.Money {
+	return money.FromMajor(amount, money.USD)
+}
+
+func TestAddProduct_Success(t *testing.T) {
+	// Given
+	product := models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}
+	repo := &storage.ProductRepositoryMock{}
+	repo.On("CreateProduct", product).Return(product, nil)
+	catalogService := NewCatalog(repo)
+
+	// When
+	created, err := catalogService.AddProduct(product)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, product, created)
+}
+
+func TestAddProduct_Validation_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		product models.Product
+		message string
+	}{
+		{
+			name:    "no SKU",
+			product: models.Product{Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)},
+			message: "invalid product: no empty values allowed",
+		},
+		{
+			name:    "no name",
+			product: models.Product{SKU: "COF-001", Category: models.CoffeeCategory, Price: usd(10)},
+			message: "invalid product: no empty values allowed",
+		},
+		{
+			name:    "unknown category",
+			product: models.Product{SKU: "TEA-001", Name: "teaA", Category: "tea", Price: usd(15)},
+			message: "invalid product: invalid category",
+		},
+		{
+			name:    "free product",
+			product: models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(0)},
+			message: "invalid product: price must be greater than 0",
+		},
+		{
+			name:    "other currency",
+			product: models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: money.FromMajor(10, money.EUR)},
+			message: "invalid product: price must be in USD",
+		},
+		{
+			name:    "negative weight",
+			product: models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10), Weight: -1},
+			message: "invalid product: weight must not be negative",
+		},
+		{
+			name: "price list with the catalog currency",
+			product: models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10),
+				Prices: []money.Money{usd(11)}},
+			message: "invalid product: there's more than one price in USD",
+		},
+		{
+			name: "free in the price list",
+			product: models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10),
+				Prices: []money.Money{money.Zero(money.EUR)}},
+			message: "invalid product: price must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo := &storage.ProductRepositoryMock{}
+			catalogService := NewCatalog(repo)
+
+			// When
+			_, err := catalogService.AddProduct(tt.product)
+
+			// Then
+			require.ErrorIs(t, err, ErrInvalidProduct)
+			require.EqualError(t, err, tt.message)
+			repo.AssertNotCalled(t, "CreateProduct", mock.Anything)
+		})
+	}
+}
+
+func TestUpdateProduct_Uses_SKU_From_Path(t *testing.T) {
+	// Given
+	expected := models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(12)}
+	repo := &storage.ProductRepositoryMock{}
+	repo.On("UpdateProduct", expected).Return(expected, nil)
+	catalogService := NewCatalog(repo)
+
+	// When
+	updated, err := catalogService.UpdateProduct("COF-001", models.Product{SKU: "other", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(12)})
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expected, updated)
+}
+
+func TestPriceIn(t *testing.T) {
+	toEUR, err := money.ParseRate(money.USD, money.EUR, "0.92")
+	require.NoError(t, err)
+	toGBP, err := money.ParseRate(money.USD, money.GBP, "0.79")
+	require.NoError(t, err)
+	product := models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10),
+		Prices: []money.Money{money.FromMajor(9, money.EUR)}}
+
+	tests := []struct {
+		name     string
+		currency money.Currency
+		rate     *money.Rate
+		expected money.Money
+	}{
+		{name: "catalog currency", currency: money.USD, expected: usd(10)},
+		{name: "price list", currency: money.EUR, rate: &toEUR, expected: money.FromMajor(9, money.EUR)},
+		{name: "converted", currency: money.GBP, rate: &toGBP, expected: money.New(790, money.GBP)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			priced, err := PriceIn(product, tt.currency, tt.rate)
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, priced.Price)
+			require.Nil(t, priced.Prices)
+			require.Equal(t, product.Name, priced.Name)
+		})
+	}
+}
+
+func TestPriceIn_No_Price(t *testing.T) {
+	// Given
+	product := models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}
+
+	// When
+	_, err := PriceIn(product, money.GBP, nil)
+
+	// Then
+	require.ErrorIs(t, err, ErrPriceNotFound)
+	require.EqualError(t, err, "price not found: product COF-001 has no price in GBP")
+}
@@ -0,0 +1,18 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"trafilea-tech-challenge/pkg/storage"
+)
+
+var (
+	ErrProductNotFound = storage.ErrProductNotFound
+	ErrProductExists   = storage.ErrProductExists
+	ErrInvalidProduct  = errors.New("invalid product")
+	ErrPriceNotFound   = errors.New("price not found")
+)
+
+func invalidProduct(message string) error {
+	return fmt.Errorf("%w: %v", ErrInvalidProduct, message)
+}
@@ -0,0 +1,206 @@
+package coupons
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+	"trafilea-tech-challenge/pkg/promotions"
+	"trafilea-tech-challenge/pkg/storage"
+)
+
+// Coupons keeps the coupons of the shop, checks whether carts can be ordered with them and redeems them for the
+// orders placed. Codes are case insensitive.
+type Coupons interface {
+	CreateCoupon(coupon models.Coupon) (models.Coupon, error)
+	GetCoupon(code string) (models.Coupon, error)
+	// Check returns the coupon with the code as long as an order for the cart could redeem it right now, failing
+	// with ErrCouponNotApplicable or ErrCouponExhausted otherwise. Coupons limited per user don't apply to guest carts,
+	// since guests can't be told apart.
+	Check(code string, cart models.Cart) (models.Coupon, error)
+	// Redeem redeems all the coupons for the order of the user, or none of them.
+	Redeem(orderID int, userID string, codes []string) error
+	// Release gives back the coupons redeemed by an order that wasn't placed or was cancelled.
+	Release(orderID int) error
+}
+
+type coupons struct {
+	CouponRepo storage.CouponRepository
+	now        func() time.Time
+}
+
+func NewCoupons(repo storage.CouponRepository) Coupons {
+	return &coupons{
+		CouponRepo: repo,
+		now:        time.Now,
+	}
+}
+
+// CreateCoupon adds a coupon that hasn't been redeemed yet, with its code in upper case.
+func (c *coupons) CreateCoupon(coupon models.Coupon) (models.Coupon, error) {
+	coupon.Code = NormalizeCode(coupon.Code)
+	coupon.Redemptions = 0
+	if err := validateCoupon(coupon); err != nil {
+		return models.Coupon{}, err
+	}
+
+	return c.CouponRepo.CreateCoupon(coupon)
+}
+
+func (c *coupons) GetCoupon(code string) (models.Coupon, error) {
+	return c.CouponRepo.GetCoupon(NormalizeCode(code))
+}
+
+func (c *coupons) Check(code string, cart models.Cart) (models.Coupon, error) {
+	coupon, err := c.CouponRepo.GetCoupon(NormalizeCode(code))
+	if err != nil {
+		return models.Coupon{}, err
+	}
+
+	now := c.now()
+	if coupon.StartsAt != nil && now.Before(*coupon.StartsAt) {
+		return models.Coupon{}, notApplicable(fmt.Sprintf("coupon %v can't be used before %v", coupon.Code,
+			coupon.StartsAt.UTC().Format(time.RFC3339)))
+	}
+	if coupon.EndsAt != nil && !now.Before(*coupon.EndsAt) {
+		return models.Coupon{}, notApplicable(fmt.Sprintf("coupon %v expired at %v", coupon.Code,
+			coupon.EndsAt.UTC().Format(time.RFC3339)))
+	}
+
+	if coupon.MaxRedemptions > 0 && coupon.Redemptions >= coupon.MaxRedemptions {
+		return models.Coupon{}, exhausted(fmt.Sprintf("coupon %v has no redemptions left", coupon.Code))
+	}
+
+	if coupon.MaxRedemptionsPerUser > 0 {
+		if cart.UserID == "" {
+			return models.Coupon{}, notApplicable(fmt.Sprintf("coupon %v is limited per user, log in to use it", coupon.Code))
+		}
+
+		redeemed, err := c.CouponRepo.CountUserRedemptions(coupon.Code, cart.UserID)
+		if err != nil {
+			return models.Coupon{}, err
+		}
+		if redeemed >= coupon.MaxRedemptionsPerUser {
+			return models.Coupon{}, exhausted(fmt.Sprintf("user %v has no redemptions of coupon %v left", cart.UserID, coupon.Code))
+		}
+	}
+
+	if !condition(coupon).Matches(cart) {
+		return models.Coupon{}, notApplicable(conditionMessage(coupon))
+	}
+
+	return coupon, nil
+}
+
+func (c *coupons) Redeem(orderID int, userID string, codes []string) error {
+	if len(codes) == 0 {
+		return nil
+	}
+
+	redemptions := make([]models.Redemption, 0, len(codes))
+	for _, code := range codes {
+		redemptions = append(redemptions, models.Redemption{Code: NormalizeCode(code), UserID: userID, OrderID: orderID})
+	}
+
+	return c.CouponRepo.Redeem(orderID, redemptions)
+}
+
+func (c *coupons) Release(orderID int) error {
+	return c.CouponRepo.ReleaseRedemptions(orderID)
+}
+
+// NormalizeCode returns the code the coupon is stored with, in upper case.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Promotion builds the promotion that applies the coupon to a cart, after the promotions the shop runs. Its
+// adjustments are described by the coupon code.
+func Promotion(coupon models.Coupon) promotions.Promotion {
+	id, description := "coupon-"+coupon.Code, fmt.Sprintf("Coupon %v", coupon.Code)
+	switch coupon.Effect {
+	case promotions.PercentOffEffect:
+		return promotions.PercentOff{PromotionID: id, Description: description, Condition: condition(coupon), Percent: coupon.Percent,
+			Rounding: money.HalfUp}
+	case promotions.FixedOffEffect:
+		return promotions.FixedOff{PromotionID: id, Description: description, Condition: condition(coupon), Amount: coupon.Amount}
+	default:
+		return promotions.FreeShipping{PromotionID: id, Description: description, Condition: condition(coupon)}
+	}
+}
+
+// condition is what a cart needs to be ordered with the coupon: a product of its category, if it has one, and
+// the minimum subtotal.
+func condition(coupon models.Coupon) promotions.Condition {
+	condition := promotions.Condition{Category: coupon.Category, MinSubtotal: coupon.MinSubtotal}
+	if coupon.Category != "" {
+		condition.MinQuantity = 1
+	}
+
+	return condition
+}
+
+func conditionMessage(coupon models.Coupon) string {
+	switch {
+	case coupon.Category == "":
+		return fmt.Sprintf("coupon %v needs a subtotal of at least %v", coupon.Code, coupon.MinSubtotal)
+	case coupon.MinSubtotal.IsZero():
+		return fmt.Sprintf("coupon %v needs %v products in the cart", coupon.Code, coupon.Category)
+	default:
+		return fmt.Sprintf("coupon %v needs at least %v of %v products in the cart", coupon.Code, coupon.MinSubtotal, coupon.Category)
+	}
+}
+
+func validateCoupon(coupon models.Coupon) error {
+	if coupon.Code == "" {
+		return invalidCoupon("code is required")
+	}
+	if strings.Trim(coupon.Code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_") != "" {
+		return invalidCoupon("code must only have letters, digits, dashes and underscores")
+	}
+
+	switch coupon.Effect {
+	case promotions.PercentOffEffect:
+		if coupon.Percent <= 0 || coupon.Percent > 100 {
+			return invalidCoupon("percent must be between 1 and 100")
+		}
+	case promotions.FixedOffEffect:
+		if !coupon.Amount.IsPositive() {
+			return invalidCoupon("amount must be greater than 0")
+		}
+		if coupon.Amount.Currency != money.DefaultCurrency {
+			return invalidCoupon(fmt.Sprintf("amount must be in %v, the catalog currency", money.DefaultCurrency))
+		}
+	case promotions.FreeShippingEffect:
+	case "":
+		return invalidCoupon("effect is required")
+	default:
+		return invalidCoupon(fmt.Sprintf("effect %q is not supported, try one of %v, %v or %v", coupon.Effect,
+			promotions.PercentOffEffect, promotions.FixedOffEffect, promotions.FreeShippingEffect))
+	}
+
+	if coupon.Category != "" && !isValidCategory(coupon.Category) {
+		return invalidCoupon(fmt.Sprintf("category %q is not a valid category", coupon.Category))
+	}
+	if coupon.MinSubtotal.IsNegative() {
+		return invalidCoupon("min_subtotal must not be negative")
+	}
+	if !coupon.MinSubtotal.IsZero() && coupon.MinSubtotal.Currency != money.DefaultCurrency {
+		return invalidCoupon(fmt.Sprintf("min_subtotal must be in %v, the catalog currency", money.DefaultCurrency))
+	}
+
+	if coupon.StartsAt != nil && coupon.EndsAt != nil && !coupon.EndsAt.After(*coupon.StartsAt) {
+		return invalidCoupon("ends_at must be after starts_at")
+	}
+
+	if coupon.MaxRedemptions < 0 || coupon.MaxRedemptionsPerUser < 0 {
+		return invalidCoupon("redemption limits must not be negative")
+	}
+
+	return nil
+}
+
+func isValidCategory(category string) bool {
+	return category == models.CoffeeCategory || category == models.EquipmentCategory || category == models.AccessoriesCategory
+}
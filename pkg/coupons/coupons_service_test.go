@@ -0,0 +1,330 @@
+package coupons
+
+import (
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+	"trafilea-tech-challenge/pkg/promotions"
+	"trafilea-tech-challenge/pkg/storage"
+)
+
+func usd(amount int64) money.Money {
+	return money.FromMajor(amount, money.USD)
+}
+
+var testNow = time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestCoupons(repo storage.CouponRepository) *coupons {
+	service := NewCoupons(repo).(*coupons)
+	service.now = func() time.Time { return testNow }
+	return service
+}
+
+func newTestCart(userID string, items ...models.LineItem) models.Cart {
+	return models.Cart{ID: "cart1", UserID: userID, Items: items, Currency: money.USD}
+}
+
+var coffee = models.LineItem{
+	Product:  models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(30)},
+	Quantity: 2,
+}
+
+var mug = models.LineItem{
+	Product:  models.Product{SKU: "ACC-001", Name: "mug", Category: models.AccessoriesCategory, Price: usd(5)},
+	Quantity: 1,
+}
+
+func TestCreateCoupon(t *testing.T) {
+	// Given
+	service := newTestCoupons(storage.NewCouponRepo())
+
+	// When
+	created, err := service.CreateCoupon(models.Coupon{Code: " tenoff ", Effect: promotions.PercentOffEffect, Percent: 10, Redemptions: 5})
+
+	// Then the code is normalized and nothing is redeemed yet
+	require.NoError(t, err)
+	require.Equal(t, models.Coupon{Code: "TENOFF", Effect: promotions.PercentOffEffect, Percent: 10}, created)
+	stored, err := service.GetCoupon("TenOff")
+	require.NoError(t, err)
+	require.Equal(t, created, stored)
+}
+
+func TestCreateCoupon_Invalid(t *testing.T) {
+	startsAt := testNow.Add(24 * time.Hour)
+	tests := []struct {
+		name    string
+		coupon  models.Coupon
+		message string
+	}{
+		{
+			name:    "no code",
+			coupon:  models.Coupon{Effect: promotions.FreeShippingEffect},
+			message: "invalid coupon: code is required",
+		},
+		{
+			name:    "code with spaces",
+			coupon:  models.Coupon{Code: "TEN OFF", Effect: promotions.FreeShippingEffect},
+			message: "invalid coupon: code must only have letters, digits, dashes and underscores",
+		},
+		{
+			name:    "no effect",
+			coupon:  models.Coupon{Code: "TENOFF"},
+			message: "invalid coupon: effect is required",
+		},
+		{
+			name:    "unknown effect",
+			coupon:  models.Coupon{Code: "TENOFF", Effect: "free_item"},
+			message: `invalid coupon: effect "free_item" is not supported, try one of percent_off, fixed_off or free_shipping`,
+		},
+		{
+			name:    "percent over 100",
+			coupon:  models.Coupon{Code: "TENOFF", Effect: promotions.PercentOffEffect, Percent: 110},
+			message: "invalid coupon: percent must be between 1 and 100",
+		},
+		{
+			name:    "no amount",
+			coupon:  models.Coupon{Code: "TENOFF", Effect: promotions.FixedOffEffect},
+			message: "invalid coupon: amount must be greater than 0",
+		},
+		{
+			name:    "amount in another currency",
+			coupon:  models.Coupon{Code: "TENOFF", Effect: promotions.FixedOffEffect, Amount: money.FromMajor(10, money.EUR)},
+			message: "invalid coupon: amount must be in USD, the catalog currency",
+		},
+		{
+			name:    "unknown category",
+			coupon:  models.Coupon{Code: "TENOFF", Effect: promotions.FreeShippingEffect, Category: "Tea"},
+			message: `invalid coupon: category "Tea" is not a valid category`,
+		},
+		{
+			name:    "negative min subtotal",
+			coupon:  models.Coupon{Code: "TENOFF", Effect: promotions.FreeShippingEffect, MinSubtotal: usd(-1)},
+			message: "invalid coupon: min_subtotal must not be negative",
+		},
+		{
+			name:    "ends before it starts",
+			coupon:  models.Coupon{Code: "TENOFF", Effect: promotions.FreeShippingEffect, StartsAt: &startsAt, EndsAt: &testNow},
+			message: "invalid coupon: ends_at must be after starts_at",
+		},
+		{
+			name:    "negative limit",
+			coupon:  models.Coupon{Code: "TENOFF", Effect: promotions.FreeShippingEffect, MaxRedemptionsPerUser: -1},
+			message: "invalid coupon: redemption limits must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo := &storage.CouponRepositoryMock{}
+			service := newTestCoupons(repo)
+
+			// When
+			_, err := service.CreateCoupon(tt.coupon)
+
+			// Then
+			require.ErrorIs(t, err, ErrInvalidCoupon)
+			require.EqualError(t, err, tt.message)
+			repo.AssertNotCalled(t, "CreateCoupon", mock.Anything)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	before, after := testNow.Add(-time.Hour), testNow.Add(time.Hour)
+	tests := []struct {
+		name        string
+		coupon      models.Coupon
+		cart        models.Cart
+		expectedErr error
+		message     string
+	}{
+		{
+			name:   "applicable",
+			coupon: models.Coupon{Code: "TENOFF", Category: models.CoffeeCategory, MinSubtotal: usd(50), StartsAt: &before, EndsAt: &after},
+			cart:   newTestCart("user2", coffee),
+		},
+		{
+			name:        "not started",
+			coupon:      models.Coupon{Code: "TENOFF", StartsAt: &after},
+			cart:        newTestCart("user2", coffee),
+			expectedErr: ErrCouponNotApplicable,
+			message:     "coupon not applicable: coupon TENOFF can't be used before 2023-05-01T13:00:00Z",
+		},
+		{
+			name:        "expired",
+			coupon:      models.Coupon{Code: "TENOFF", EndsAt: &testNow},
+			cart:        newTestCart("user2", coffee),
+			expectedErr: ErrCouponNotApplicable,
+			message:     "coupon not applicable: coupon TENOFF expired at 2023-05-01T12:00:00Z",
+		},
+		{
+			name:        "no redemptions left",
+			coupon:      models.Coupon{Code: "TENOFF", MaxRedemptions: 1},
+			cart:        newTestCart("user2", coffee),
+			expectedErr: ErrCouponExhausted,
+			message:     "coupon exhausted: coupon TENOFF has no redemptions left",
+		},
+		{
+			name:        "no redemptions left for the user",
+			coupon:      models.Coupon{Code: "TENOFF", MaxRedemptionsPerUser: 1},
+			cart:        newTestCart("user1", coffee),
+			expectedErr: ErrCouponExhausted,
+			message:     "coupon exhausted: user user1 has no redemptions of coupon TENOFF left",
+		},
+		{
+			name:        "subtotal too low",
+			coupon:      models.Coupon{Code: "TENOFF", MinSubtotal: usd(100)},
+			cart:        newTestCart("user2", coffee, mug),
+			expectedErr: ErrCouponNotApplicable,
+			message:     "coupon not applicable: coupon TENOFF needs a subtotal of at least 100.00 USD",
+		},
+		{
+			name:        "no products of the category",
+			coupon:      models.Coupon{Code: "TENOFF", Category: models.CoffeeCategory},
+			cart:        newTestCart("user2", mug),
+			expectedErr: ErrCouponNotApplicable,
+			message:     "coupon not applicable: coupon TENOFF needs coffee products in the cart",
+		},
+		{
+			name:        "category subtotal too low",
+			coupon:      models.Coupon{Code: "TENOFF", Category: models.AccessoriesCategory, MinSubtotal: usd(10)},
+			cart:        newTestCart("user2", coffee, mug),
+			expectedErr: ErrCouponNotApplicable,
+			message:     "coupon not applicable: coupon TENOFF needs at least 10.00 USD of accessories products in the cart",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given a coupon already redeemed once by user1
+			repo := storage.NewCouponRepo()
+			tt.coupon.Effect, tt.coupon.Percent = promotions.PercentOffEffect, 10
+			_, err := repo.CreateCoupon(tt.coupon)
+			require.NoError(t, err)
+			require.NoError(t, repo.Redeem(1, []models.Redemption{{Code: "TENOFF", UserID: "user1", OrderID: 1}}))
+			service := newTestCoupons(repo)
+
+			// When
+			coupon, err := service.Check("tenoff", tt.cart)
+
+			// Then
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				require.EqualError(t, err, tt.message)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "TENOFF", coupon.Code)
+		})
+	}
+}
+
+func TestCheck_Guests(t *testing.T) {
+	// Given a coupon limited per user, never redeemed
+	repo := storage.NewCouponRepo()
+	_, err := repo.CreateCoupon(models.Coupon{Code: "TENOFF", Effect: promotions.PercentOffEffect, Percent: 10, MaxRedemptionsPerUser: 1})
+	require.NoError(t, err)
+	service := newTestCoupons(repo)
+	firstGuest := models.Cart{ID: "guest1", GuestToken: "token1", Items: []models.LineItem{coffee}, Currency: money.USD}
+	secondGuest := models.Cart{ID: "guest2", GuestToken: "token2", Items: []models.LineItem{coffee}, Currency: money.USD}
+
+	// When
+	_, firstErr := service.Check("TENOFF", firstGuest)
+	_, secondErr := service.Check("TENOFF", secondGuest)
+	_, userErr := service.Check("TENOFF", newTestCart("user1", coffee))
+
+	// Then guests don't share the redemptions of a single user, they're told to log in instead
+	for _, err := range []error{firstErr, secondErr} {
+		require.ErrorIs(t, err, ErrCouponNotApplicable)
+		require.EqualError(t, err, "coupon not applicable: coupon TENOFF is limited per user, log in to use it")
+	}
+	require.NoError(t, userErr)
+}
+
+func TestCheck_Not_Found(t *testing.T) {
+	// Given
+	service := newTestCoupons(storage.NewCouponRepo())
+
+	// When
+	_, err := service.Check("NOPE", newTestCart("user1", coffee))
+
+	// Then
+	require.ErrorIs(t, err, ErrCouponNotFound)
+}
+
+func TestRedeem(t *testing.T) {
+	// Given
+	repo := &storage.CouponRepositoryMock{}
+	repo.On("Redeem", 7, []models.Redemption{
+		{Code: "TENOFF", UserID: "user1", OrderID: 7},
+		{Code: "FREESHIP", UserID: "user1", OrderID: 7},
+	}).Return(nil)
+	service := newTestCoupons(repo)
+
+	// When
+	err := service.Redeem(7, "user1", []string{"tenoff", "FREESHIP"})
+	noCouponsErr := service.Redeem(8, "user1", nil)
+
+	// Then
+	require.NoError(t, err)
+	require.NoError(t, noCouponsErr)
+	repo.AssertExpectations(t)
+	repo.AssertNumberOfCalls(t, "Redeem", 1)
+}
+
+func TestPromotion(t *testing.T) {
+	tests := []struct {
+		name             string
+		coupon           models.Coupon
+		cart             models.Cart
+		expectedDiscount money.Money
+		expectedShipping money.Money
+	}{
+		{
+			name:             "percent off",
+			coupon:           models.Coupon{Code: "TENOFF", Effect: promotions.PercentOffEffect, Percent: 10},
+			cart:             newTestCart("user1", coffee, mug),
+			expectedDiscount: usd(65).Percent(10, money.HalfUp),
+			expectedShipping: usd(20),
+		},
+		{
+			name:             "fixed off",
+			coupon:           models.Coupon{Code: "TENOFF", Effect: promotions.FixedOffEffect, Amount: usd(10)},
+			cart:             newTestCart("user1", coffee, mug),
+			expectedDiscount: usd(10),
+			expectedShipping: usd(20),
+		},
+		{
+			name:             "free shipping",
+			coupon:           models.Coupon{Code: "FREESHIP", Effect: promotions.FreeShippingEffect},
+			cart:             newTestCart("user1", coffee, mug),
+			expectedDiscount: usd(0),
+			expectedShipping: usd(0),
+		},
+		{
+			name:             "category the cart doesn't have",
+			coupon:           models.Coupon{Code: "TENOFF", Effect: promotions.FixedOffEffect, Amount: usd(10), Category: models.EquipmentCategory},
+			cart:             newTestCart("user1", coffee, mug),
+			expectedDiscount: usd(0),
+			expectedShipping: usd(20),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			result := &promotions.Result{Subtotal: usd(65), Discount: usd(0), Shipping: usd(20)}
+
+			// When
+			Promotion(tt.coupon).Apply(tt.cart, result)
+
+			// Then
+			require.Equal(t, tt.expectedDiscount, result.Discount)
+			require.Equal(t, tt.expectedShipping, result.Shipping)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package coupons
+
+import (
+	"errors"
+	"fmt"
+	"trafilea-tech-challenge/pkg/storage"
+)
+
+var (
+	ErrCouponNotFound      = storage.ErrCouponNotFound
+	ErrCouponExists        = storage.ErrCouponExists
+	ErrCouponExhausted     = storage.ErrCouponExhausted
+	ErrRedemptionExists    = storage.ErrRedemptionExists
+	ErrInvalidCoupon       = errors.New("invalid coupon")
+	ErrCouponNotApplicable = errors.New("coupon not applicable")
+)
+
+func invalidCoupon(message string) error {
+	return fmt.Errorf("%w: %v", ErrInvalidCoupon, message)
+}
+
+func notApplicable(message string) error {
+	return fmt.Errorf("%w: %v", ErrCouponNotApplicable, message)
+}
+
+func exhausted(message string) error {
+	return fmt.Errorf("%w: %v", ErrCouponExhausted, message)
+}
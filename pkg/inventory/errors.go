@@ -0,0 +1,22 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"trafilea-tech-challenge/pkg/storage"
+)
+
+var (
+	ErrInsufficientStock   = storage.ErrInsufficientStock
+	ErrReservationNotFound = storage.ErrReservationNotFound
+	ErrReservationExists   = storage.ErrReservationExists
+	ErrInvalidStock        = errors.New("invalid stock")
+)
+
+func insufficientStock(sku string, available int) error {
+	return fmt.Errorf("%w: only %v units of %v are available", ErrInsufficientStock, available, sku)
+}
+
+func invalidStock(message string) error {
+	return fmt.Errorf("%w: %v", ErrInvalidStock, message)
+}
@@ -0,0 +1,98 @@
+package inventory
+
+import (
+	"time"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/storage"
+)
+
+// Inventory keeps track of the units of every product, by SKU, and holds them for the orders placed until
+// they're paid. Line items without a SKU, such as the products given away by promotions, aren't tracked.
+type Inventory interface {
+	GetStock(sku string) (models.Stock, error)
+	SetStock(sku string, onHand int) (models.Stock, error)
+	// CheckAvailability fails with ErrInsufficientStock when fewer units of the product than quantity are available.
+	CheckAvailability(sku string, quantity int) error
+	// Reserve holds the units of all the items for the order, or none of them, until the reservation expires.
+	Reserve(orderID int, items []models.LineItem) (models.Reservation, error)
+	Commit(orderID int) error
+	Release(orderID int) error
+	GetReservation(orderID int) (models.Reservation, error)
+	ExpiredReservations() ([]models.Reservation, error)
+}
+
+type inventory struct {
+	InventoryRepo storage.InventoryRepository
+	ttl           time.Duration
+	now           func() time.Time
+}
+
+// NewInventory returns an Inventory whose reservations expire ttl after they're made.
+func NewInventory(repo storage.InventoryRepository, ttl time.Duration) Inventory {
+	return &inventory{
+		InventoryRepo: repo,
+		ttl:           ttl,
+		now:           time.Now,
+	}
+}
+
+func (i *inventory) GetStock(sku string) (models.Stock, error) {
+	return i.InventoryRepo.GetStock(sku)
+}
+
+func (i *inventory) SetStock(sku string, onHand int) (models.Stock, error) {
+	if onHand < 0 {
+		return models.Stock{}, invalidStock("on hand units can't be negative")
+	}
+
+	return i.InventoryRepo.SetStock(sku, onHand)
+}
+
+func (i *inventory) CheckAvailability(sku string, quantity int) error {
+	stock, err := i.InventoryRepo.GetStock(sku)
+	if err != nil {
+		return err
+	}
+
+	if stock.Available() < quantity {
+		return insufficientStock(sku, stock.Available())
+	}
+
+	return nil
+}
+
+func (i *inventory) Reserve(orderID int, items []models.LineItem) (models.Reservation, error) {
+	reservation := models.Reservation{
+		OrderID:   orderID,
+		Items:     make(map[string]int),
+		ExpiresAt: i.now().Add(i.ttl).UTC(),
+	}
+
+	for _, item := range items {
+		if item.Product.SKU != "" {
+			reservation.Items[item.Product.SKU] += item.Quantity
+		}
+	}
+
+	if err := i.InventoryRepo.CreateReservation(reservation); err != nil {
+		return models.Reservation{}, err
+	}
+
+	return reservation, nil
+}
+
+func (i *inventory) Commit(orderID int) error {
+	return i.InventoryRepo.CommitReservation(orderID)
+}
+
+func (i *inventory) Release(orderID int) error {
+	return i.InventoryRepo.ReleaseReservation(orderID)
+}
+
+func (i *inventory) GetReservation(orderID int) (models.Reservation, error) {
+	return i.InventoryRepo.GetReservation(orderID)
+}
+
+func (i *inventory) ExpiredReservations() ([]models.Reservation, error) {
+	return i.InventoryRepo.GetReservationsExpiredAt(i.now())
+}
@@ -0,0 +1,116 @@
+package inventory
+
+import (
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+	"trafilea-tech-challenge/pkg/storage"
+)
+
+func usd(amount int64) money.Money {
+	return money.FromMajor(amount, money.USD)
+}
+
+var testNow = time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestInventory(repo storage.InventoryRepository) *inventory {
+	service := NewInventory(repo, 15*time.Minute).(*inventory)
+	service.now = func() time.Time { return testNow }
+	return service
+}
+
+func TestSetStock_Negative(t *testing.T) {
+	// Given
+	repo := &storage.InventoryRepositoryMock{}
+	inventoryService := newTestInventory(repo)
+
+	// When
+	_, err := inventoryService.SetStock("COF-001", -1)
+
+	// Then
+	require.ErrorIs(t, err, ErrInvalidStock)
+	require.EqualError(t, err, "invalid stock: on hand units can't be negative")
+	repo.AssertNotCalled(t, "SetStock", mock.Anything, mock.Anything)
+}
+
+func TestCheckAvailability(t *testing.T) {
+	// Given
+	repo := &storage.InventoryRepositoryMock{}
+	repo.On("GetStock", "COF-001").Return(models.Stock{SKU: "COF-001", OnHand: 5, Reserved: 2}, nil)
+	inventoryService := newTestInventory(repo)
+
+	// When
+	availableErr := inventoryService.CheckAvailability("COF-001", 3)
+	unavailableErr := inventoryService.CheckAvailability("COF-001", 4)
+
+	// Then
+	require.NoError(t, availableErr)
+	require.ErrorIs(t, unavailableErr, ErrInsufficientStock)
+	require.EqualError(t, unavailableErr, "insufficient stock: only 3 units of COF-001 are available")
+}
+
+func TestReserve(t *testing.T) {
+	// Given
+	expected := models.Reservation{
+		OrderID:   1,
+		Items:     map[string]int{"COF-001": 3, "ACC-001": 1},
+		ExpiresAt: testNow.Add(15 * time.Minute),
+	}
+	repo := &storage.InventoryRepositoryMock{}
+	repo.On("CreateReservation", expected).Return(nil)
+	inventoryService := newTestInventory(repo)
+
+	// When
+	reservation, err := inventoryService.Reserve(1, []models.LineItem{
+		{Product: models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 3},
+		{Product: models.Product{SKU: "ACC-001", Name: "mug", Category: models.AccessoriesCategory, Price: usd(5)}, Quantity: 1},
+		{Product: models.Product{Name: "freeCoffee", Category: models.CoffeeCategory}, Quantity: 1},
+	})
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expected, reservation)
+	repo.AssertExpectations(t)
+}
+
+func TestReserve_Insufficient_Stock(t *testing.T) {
+	// Given
+	inventoryService := newTestInventory(storage.NewInventoryRepo())
+	_, err := inventoryService.SetStock("COF-001", 2)
+	require.NoError(t, err)
+
+	// When
+	_, err = inventoryService.Reserve(1, []models.LineItem{
+		{Product: models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 3},
+	})
+
+	// Then
+	require.ErrorIs(t, err, ErrInsufficientStock)
+	stock, _ := inventoryService.GetStock("COF-001")
+	require.Equal(t, 2, stock.Available())
+}
+
+func TestExpiredReservations(t *testing.T) {
+	// Given
+	inventoryService := newTestInventory(storage.NewInventoryRepo())
+	_, err := inventoryService.SetStock("COF-001", 10)
+	require.NoError(t, err)
+	items := []models.LineItem{{Product: models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 1}}
+	_, err = inventoryService.Reserve(1, items)
+	require.NoError(t, err)
+
+	// When
+	beforeExpiry, beforeErr := inventoryService.ExpiredReservations()
+	inventoryService.now = func() time.Time { return testNow.Add(15 * time.Minute) }
+	afterExpiry, afterErr := inventoryService.ExpiredReservations()
+
+	// Then
+	require.NoError(t, beforeErr)
+	require.Empty(t, beforeExpiry)
+	require.NoError(t, afterErr)
+	require.Len(t, afterExpiry, 1)
+	require.Equal(t, 1, afterExpiry[0].OrderID)
+}
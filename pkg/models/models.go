@@ -1,32 +1,225 @@
 package models
 
+import (
+	"time"
+	"trafilea-tech-challenge/pkg/money"
+)
+
 const (
 	CoffeeCategory      = "coffee"
 	EquipmentCategory   = "equipment"
 	AccessoriesCategory = "accessories"
 )
 
+// Product is an item of the catalog, identified by its SKU. Products given away by promotions have no SKU.
+//
+// Price is in the catalog currency. Prices is the price list of the product in other currencies, for the ones whose
+// price isn't just converted with the exchange rate; products in carts only have the price in the cart currency.
+// Weight is in grams, for the shipping methods that charge by weight.
 type Product struct {
-	Name     string `json:"name"`
-	Category string `json:"category"`
-	Price    int    `json:"price"`
+	SKU      string        `json:"sku,omitempty"`
+	Name     string        `json:"name"`
+	Category string        `json:"category"`
+	Price    money.Money   `json:"price"`
+	Prices   []money.Money `json:"prices,omitempty"`
+	Weight   int           `json:"weight,omitempty"`
 }
 
+// LineItem is a product of a cart or order along with how many units of it are bought. Only the line items of
+// taxed orders have a Tax.
+//
+// Free line items are given away by the promotion with PromotionID. They are derived from the rest of the cart
+// every time it changes, so users can't change them themselves.
+type LineItem struct {
+	Product     Product `json:"product"`
+	Quantity    int     `json:"quantity"`
+	Tax         *Tax    `json:"tax,omitempty"`
+	Free        bool    `json:"free,omitempty"`
+	PromotionID string  `json:"promotion_id,omitempty"`
+}
+
+// Tax is the tax charged on a line item, Rate percent of what is paid for it.
+type Tax struct {
+	Rate   string      `json:"rate"`
+	Amount money.Money `json:"amount"`
+}
+
+// Cart holds the products a user is buying, priced in the currency chosen when it was created. Carts in another
+// currency than the catalog one keep the ExchangeRate they were created with, so their prices don't change while
+// the user shops. Coupons are the codes of the coupons applied to the cart.
+//
+// Users can have several open carts at once, told apart by their Name, such as "office" or "home".
+//
+// Guest carts belong to shoppers who haven't logged in. They have no UserID and are identified by their GuestToken
+// until they're merged into the cart of the user who logs in.
 type Cart struct {
-	ID       string    `json:"id"`
-	UserID   string    `json:"user_id"`
-	Products []Product `json:"products"`
+	ID           string         `json:"id"`
+	UserID       string         `json:"user_id"`
+	GuestToken   string         `json:"guest_token,omitempty"`
+	Name         string         `json:"name,omitempty"`
+	Items        []LineItem     `json:"items"`
+	CheckedOut   bool           `json:"checked_out"`
+	Currency     money.Currency `json:"currency"`
+	ExchangeRate *money.Rate    `json:"exchange_rate,omitempty"`
+	Checkout     Checkout       `json:"checkout"`
+	Coupons      []string       `json:"coupons,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+// Checkout is what a cart needs before its order can be placed: the Email to contact its user, where to ship and
+// bill the order and the shipping method to deliver it with.
+type Checkout struct {
+	Email           string   `json:"email,omitempty"`
+	ShippingAddress *Address `json:"shipping_address,omitempty"`
+	BillingAddress  *Address `json:"billing_address,omitempty"`
+	DeliveryMethod  string   `json:"delivery_method,omitempty"`
+}
+
+// Address is where an order is shipped or billed to. Country is an ISO 3166-1 alpha-2 code, e.g. US, and Region the
+// code of the subdivision of the country, if it has any, e.g. NY.
+type Address struct {
+	Name       string `json:"name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
 }
 
+// Destination is the region the address is in as shipping methods and taxes know it, e.g. US-NY, or just its
+// country if it has no Region.
+func (a Address) Destination() string {
+	if a.Region == "" {
+		return a.Country
+	}
+
+	return a.Country + "-" + a.Region
+}
+
+// CartDetails is a cart along with a preview of what an order for it would cost.
+type CartDetails struct {
+	Cart
+	Pricing Pricing `json:"pricing"`
+}
+
+// Pricing is previewed with the delivery method of the cart, or else the default one, and has no ShippingMethod when
+// the cart can't be shipped with it yet, e.g. because it has no shipping address. Adjustments itemize the Discounts
+// and the shipping waived.
+type Pricing struct {
+	Products       int          `json:"products"`
+	Subtotal       money.Money  `json:"subtotal"`
+	Discounts      money.Money  `json:"discounts"`
+	Shipping       money.Money  `json:"shipping"`
+	ShippingMethod string       `json:"shipping_method,omitempty"`
+	Price          money.Money  `json:"price"`
+	Adjustments    []Adjustment `json:"adjustments,omitempty"`
+}
+
+// Adjustment is what a promotion or coupon, identified by ID, changed in the price of an order: Amount off its
+// products or, if Shipping is set, off its shipping. Items are the names of the line items it applies to, e.g. the
+// free item it gave away, which is adjusted by 0 since it's already free. The adjustments off the products add up to
+// the discounts of the order, and the ones off shipping to what its shipping rate was waived.
+type Adjustment struct {
+	ID          string      `json:"id"`
+	Description string      `json:"description"`
+	Items       []string    `json:"items,omitempty"`
+	Amount      money.Money `json:"amount"`
+	Shipping    bool        `json:"shipping,omitempty"`
+}
+
+// Order is a cart that was checked out, along with the Checkout of the cart and the Coupons redeemed by it. Its totals
+// are in the cart currency, and ExchangeRate is the rate its prices were converted with, if the cart wasn't in the
+// catalog currency.
 type Order struct {
-	CartID string `json:"cart_id"`
-	Totals Total  `json:"totals"`
+	CartID       string         `json:"cart_id"`
+	UserID       string         `json:"user_id"`
+	Items        []LineItem     `json:"items"`
+	Totals       Total          `json:"totals"`
+	Status       OrderStatus    `json:"status"`
+	History      []StatusChange `json:"history"`
+	CreatedAt    time.Time      `json:"created_at"`
+	ExchangeRate *money.Rate    `json:"exchange_rate,omitempty"`
+	TaxRegion    string         `json:"tax_region,omitempty"`
+	Checkout     Checkout       `json:"checkout"`
+	Coupons      []string       `json:"coupons,omitempty"`
 }
 
+type OrderStatus string
+
+const (
+	OrderPending   OrderStatus = "pending"
+	OrderPaid      OrderStatus = "paid"
+	OrderFulfilled OrderStatus = "fulfilled"
+	OrderShipped   OrderStatus = "shipped"
+	OrderDelivered OrderStatus = "delivered"
+	OrderCancelled OrderStatus = "cancelled"
+	OrderRefunded  OrderStatus = "refunded"
+)
+
+// StatusChange records when an order moved to a status.
+type StatusChange struct {
+	Status OrderStatus `json:"status"`
+	At     time.Time   `json:"at"`
+}
+
+// Total sums up an order. ShippingRate is what the ShippingMethod charges for the order, and Shipping what is paid
+// once the promotions are applied. Tax is added to the Price unless TaxInclusive, in which case the prices already
+// had it. Adjustments itemize the Discounts and the shipping waived.
 type Total struct {
-	Products  int `json:"products"`
-	Discounts int `json:"discounts"`
-	Shipping  int `json:"shipping"`
-	Order     int `json:"order"`
-	Price     int `json:"price"`
+	Products       int          `json:"products"`
+	Discounts      money.Money  `json:"discounts"`
+	Shipping       money.Money  `json:"shipping"`
+	ShippingMethod string       `json:"shipping_method,omitempty"`
+	ShippingRate   money.Money  `json:"shipping_rate"`
+	Tax            money.Money  `json:"tax"`
+	TaxInclusive   bool         `json:"tax_inclusive"`
+	Order          int          `json:"order"`
+	Price          money.Money  `json:"price"`
+	Adjustments    []Adjustment `json:"adjustments,omitempty"`
+}
+
+// Stock is how many units of a product the shop has and how many of them are held for orders not paid yet.
+type Stock struct {
+	SKU      string `json:"sku"`
+	OnHand   int    `json:"on_hand"`
+	Reserved int    `json:"reserved"`
+}
+
+// Available is how many units can still be ordered.
+func (s Stock) Available() int {
+	return s.OnHand - s.Reserved
+}
+
+// Reservation holds the units of every product of an order until it's paid or it expires.
+type Reservation struct {
+	OrderID   int            `json:"order_id"`
+	Items     map[string]int `json:"items"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+// Coupon is a code users apply to their carts for a percent off, a fixed amount off or free shipping, as long as the
+// cart has products of Category, if any, adding up to at least MinSubtotal. Amounts are in the catalog currency.
+//
+// Coupons can only be redeemed from StartsAt and until EndsAt, when they're set, and at most MaxRedemptions times
+// in total and MaxRedemptionsPerUser times by every user, 0 meaning no limit. Redemptions is how many times it was.
+type Coupon struct {
+	Code                  string      `json:"code"`
+	Effect                string      `json:"effect"`
+	Percent               int         `json:"percent,omitempty"`
+	Amount                money.Money `json:"amount,omitempty"`
+	Category              string      `json:"category,omitempty"`
+	MinSubtotal           money.Money `json:"min_subtotal,omitempty"`
+	StartsAt              *time.Time  `json:"starts_at,omitempty"`
+	EndsAt                *time.Time  `json:"ends_at,omitempty"`
+	MaxRedemptions        int         `json:"max_redemptions,omitempty"`
+	MaxRedemptionsPerUser int         `json:"max_redemptions_per_user,omitempty"`
+	Redemptions           int         `json:"redemptions"`
+}
+
+// Redemption is a coupon used by an order of a user.
+type Redemption struct {
+	Code    string `json:"code"`
+	UserID  string `json:"user_id"`
+	OrderID int    `json:"order_id"`
 }
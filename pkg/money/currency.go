@@ -0,0 +1,50 @@
+package money
+
+import (
+	"fmt"
+)
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+const (
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	GBP Currency = "GBP"
+	JPY Currency = "JPY"
+)
+
+// DefaultCurrency is the currency of the amounts that don't say theirs, such as the prices stored as bare numbers.
+const DefaultCurrency = USD
+
+// minorUnits holds how many decimal places every supported currency has.
+var minorUnits = map[Currency]int{
+	USD: 2,
+	EUR: 2,
+	GBP: 2,
+	JPY: 0,
+}
+
+// Valid reports whether the currency is supported.
+func (c Currency) Valid() bool {
+	_, ok := minorUnits[c]
+	return ok
+}
+
+// MinorUnits returns how many decimal places the currency has, e.g. 2 for USD, whose minor unit is the cent.
+func (c Currency) MinorUnits() int {
+	return minorUnits[c]
+}
+
+func (c Currency) scale() int64 {
+	return pow10(c.MinorUnits())
+}
+
+func ParseCurrency(code string) (Currency, error) {
+	currency := Currency(code)
+	if !currency.Valid() {
+		return "", fmt.Errorf("%w: %q", ErrUnknownCurrency, code)
+	}
+
+	return currency, nil
+}
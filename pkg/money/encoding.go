@@ -0,0 +1,109 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+// Parse reads a decimal amount in whole units of the currency, such as "15.50", allowing as many decimal places
+// as the currency has.
+func Parse(amount string, currency Currency) (Money, error) {
+	if !currency.Valid() {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+
+	digits, negative := strings.CutPrefix(amount, "-")
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(fraction) || strings.HasSuffix(digits, ".") {
+		return Money{}, fmt.Errorf("%w: %q is not a decimal number", ErrInvalidAmount, amount)
+	}
+
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > currency.MinorUnits() {
+		return Money{}, fmt.Errorf("%w: %v only has %d decimal places", ErrInvalidAmount, currency, currency.MinorUnits())
+	}
+
+	var minor int64
+	for _, digit := range whole + fraction + strings.Repeat("0", currency.MinorUnits()-len(fraction)) {
+		minor = minor*10 + int64(digit-'0')
+		if minor < 0 {
+			return Money{}, fmt.Errorf("%w: %q is too large", ErrInvalidAmount, amount)
+		}
+	}
+
+	if negative {
+		minor = -minor
+	}
+
+	return New(minor, currency), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// jsonMoney is how amounts are serialized: a decimal string in whole units, so clients don't have to know how many
+// decimal places the currency has nor lose precision to floats, along with the currency, e.g.
+// {"amount": "15.00", "currency": "USD"}.
+type jsonMoney struct {
+	Amount   string   `json:"amount" yaml:"amount"`
+	Currency Currency `json:"currency" yaml:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	// Only zero amounts lack a currency, such as the discounts of a cart without promotions
+	if m.Currency == "" && m.IsZero() {
+		m.Currency = DefaultCurrency
+	}
+
+	return json.Marshal(jsonMoney{Amount: m.decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON reads amounts serialized by MarshalJSON. A bare number is read as whole units of the default
+// currency, which is how amounts were written before they had a currency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && (data[0] == '-' || (data[0] >= '0' && data[0] <= '9')) {
+		return m.parse(jsonMoney{Amount: string(data), Currency: DefaultCurrency})
+	}
+
+	var value jsonMoney
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+	}
+
+	return m.parse(value)
+}
+
+// UnmarshalYAML reads amounts from config files the same way UnmarshalJSON does.
+func (m *Money) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return m.parse(jsonMoney{Amount: node.Value, Currency: DefaultCurrency})
+	}
+
+	var value jsonMoney
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+
+	return m.parse(value)
+}
+
+func (m *Money) parse(value jsonMoney) error {
+	parsed, err := Parse(value.Amount, value.Currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,129 @@
+package money
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency Currency
+		expected Money
+	}{
+		{name: "whole units", amount: "15", currency: USD, expected: New(1500, USD)},
+		{name: "minor units", amount: "15.5", currency: USD, expected: New(1550, USD)},
+		{name: "trailing zeros", amount: "15.500", currency: USD, expected: New(1550, USD)},
+		{name: "negative", amount: "-0.05", currency: EUR, expected: New(-5, EUR)},
+		{name: "no minor unit", amount: "1500", currency: JPY, expected: New(1500, JPY)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			parsed, err := Parse(tt.amount, tt.currency)
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, parsed)
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency Currency
+		expected error
+	}{
+		{name: "too many decimal places", amount: "15.001", currency: USD, expected: ErrInvalidAmount},
+		{name: "decimal places of a currency without them", amount: "15.5", currency: JPY, expected: ErrInvalidAmount},
+		{name: "not a number", amount: "fifteen", currency: USD, expected: ErrInvalidAmount},
+		{name: "exponent", amount: "1e3", currency: USD, expected: ErrInvalidAmount},
+		{name: "no digits after the point", amount: "15.", currency: USD, expected: ErrInvalidAmount},
+		{name: "unknown currency", amount: "15", currency: "XXX", expected: ErrUnknownCurrency},
+		{name: "no currency", amount: "15", currency: "", expected: ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			_, err := Parse(tt.amount, tt.currency)
+
+			// Then
+			require.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	// When
+	price, priceErr := json.Marshal(New(1550, USD))
+	zero, zeroErr := json.Marshal(Money{})
+
+	// Then
+	require.NoError(t, priceErr)
+	require.JSONEq(t, `{"amount": "15.50", "currency": "USD"}`, string(price))
+	require.NoError(t, zeroErr)
+	require.JSONEq(t, `{"amount": "0.00", "currency": "USD"}`, string(zero))
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected Money
+	}{
+		{name: "amount and currency", json: `{"amount": "15.50", "currency": "EUR"}`, expected: New(1550, EUR)},
+		{name: "bare number in whole units of the default currency", json: `15`, expected: New(1500, DefaultCurrency)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			var parsed Money
+
+			// When
+			err := json.Unmarshal([]byte(tt.json), &parsed)
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, parsed)
+		})
+	}
+}
+
+func TestUnmarshalJSON_Errors(t *testing.T) {
+	for _, data := range []string{`{"amount": "15.50"}`, `{"amount": 15.5, "currency": "USD"}`, `"15.50"`, `15.505`} {
+		t.Run(data, func(t *testing.T) {
+			// Given
+			var parsed Money
+
+			// When
+			err := json.Unmarshal([]byte(data), &parsed)
+
+			// Then
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestUnmarshalYAML(t *testing.T) {
+	// Given
+	var config struct {
+		Bare    Money `yaml:"bare"`
+		Mapping Money `yaml:"mapping"`
+	}
+
+	// When
+	err := yaml.Unmarshal([]byte("bare: 70.5\nmapping:\n  amount: \"20\"\n  currency: GBP\n"), &config)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, New(7050, DefaultCurrency), config.Bare)
+	require.Equal(t, New(2000, GBP), config.Mapping)
+}
@@ -0,0 +1,12 @@
+package money
+
+import (
+	"errors"
+)
+
+var (
+	ErrUnknownCurrency     = errors.New("unknown currency")
+	ErrInvalidAmount       = errors.New("invalid amount")
+	ErrUnknownRoundingMode = errors.New("unknown rounding mode")
+	ErrInvalidRate         = errors.New("invalid exchange rate")
+)
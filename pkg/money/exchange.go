@@ -0,0 +1,241 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxRateDecimals bounds the decimal places of a rate, which are more than any exchange quotes
+	maxRateDecimals = 6
+	// maxRateUnits bounds the digits of a rate, so it can be scaled to the minor unit of any currency
+	maxRateUnits = 1_000_000_000_000
+)
+
+// Rate is the exchange rate between two currencies: a whole unit of From is worth the rate in whole units of To.
+// The rate is kept exactly as it was written, e.g. "0.92", so converting an amount only rounds once.
+type Rate struct {
+	From Currency
+	To   Currency
+	// The rate is units / 10^decimals
+	units    int64
+	decimals int
+}
+
+// ParseRate reads a decimal exchange rate, such as "0.92", with up to 6 decimal places.
+func ParseRate(from, to Currency, value string) (Rate, error) {
+	if !from.Valid() {
+		return Rate{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, from)
+	}
+	if !to.Valid() {
+		return Rate{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, to)
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(fraction) || strings.HasSuffix(value, ".") {
+		return Rate{}, fmt.Errorf("%w: %q is not a decimal number", ErrInvalidRate, value)
+	}
+
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > maxRateDecimals {
+		return Rate{}, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidRate, value, maxRateDecimals)
+	}
+
+	var units int64
+	for _, digit := range strings.TrimLeft(whole, "0") + fraction {
+		units = units*10 + int64(digit-'0')
+		if units > maxRateUnits {
+			return Rate{}, fmt.Errorf("%w: %q is too large", ErrInvalidRate, value)
+		}
+	}
+
+	if units == 0 {
+		return Rate{}, fmt.Errorf("%w: %q must be greater than 0", ErrInvalidRate, value)
+	}
+
+	return Rate{From: from, To: to, units: units, decimals: len(fraction)}, nil
+}
+
+// Value formats the rate as a decimal number, e.g. "0.92".
+func (r Rate) Value() string {
+	scale := pow10(r.decimals)
+	if r.decimals == 0 {
+		return fmt.Sprintf("%d", r.units)
+	}
+
+	return fmt.Sprintf("%d.%0*d", r.units/scale, r.decimals, r.units%scale)
+}
+
+// String formats the rate along with its currencies, e.g. "1 USD = 0.92 EUR".
+func (r Rate) String() string {
+	return fmt.Sprintf("1 %v = %v %v", r.From, r.Value(), r.To)
+}
+
+// Convert returns the amount of From in To, rounded to the minor unit of To with the given mode.
+// Converting an amount of another currency is a bug and panics, except for zero amounts.
+func (r Rate) Convert(m Money, mode RoundingMode) Money {
+	if m.IsZero() {
+		return Zero(r.To)
+	}
+	if m.Currency != r.From {
+		panic(fmt.Sprintf("money: can't convert %v with %v", m, r))
+	}
+
+	// The amount times the rate may not fit in an int64 even if the converted amount does
+	numerator := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(r.units*r.To.scale()))
+	denominator := pow10(r.decimals) * r.From.scale()
+	quotient, remainder := new(big.Int).QuoRem(numerator, big.NewInt(denominator), new(big.Int))
+	if !quotient.IsInt64() {
+		panic(fmt.Sprintf("money: %v is too large to convert with %v", m, r))
+	}
+
+	return Money{Amount: round(quotient.Int64(), remainder.Int64(), denominator, mode), Currency: r.To}
+}
+
+// jsonRate is how rates are serialized, e.g. {"from": "USD", "to": "EUR", "rate": "0.92"}.
+type jsonRate struct {
+	From Currency `json:"from"`
+	To   Currency `json:"to"`
+	Rate string   `json:"rate"`
+}
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonRate{From: r.From, To: r.To, Rate: r.Value()})
+}
+
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	var value jsonRate
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRate, err)
+	}
+
+	parsed, err := ParseRate(value.From, value.To, value.Rate)
+	if err != nil {
+		return err
+	}
+
+	*r = parsed
+	return nil
+}
+
+// Rates are the exchange rates from the Base currency, the one the catalog is priced in, to the other currencies
+// the shop sells in.
+type Rates struct {
+	Base  Currency
+	rates map[Currency]Rate
+}
+
+// NewRates checks that every rate converts from the base currency, and that there's a single one for each currency.
+func NewRates(base Currency, rates ...Rate) (Rates, error) {
+	if !base.Valid() {
+		return Rates{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, base)
+	}
+
+	table := Rates{Base: base, rates: make(map[Currency]Rate, len(rates))}
+	for _, rate := range rates {
+		if rate.From != base {
+			return Rates{}, fmt.Errorf("%w: %v doesn't convert from %v", ErrInvalidRate, rate, base)
+		}
+		if rate.To == base {
+			return Rates{}, fmt.Errorf("%w: %v converts %v into itself", ErrInvalidRate, rate, base)
+		}
+		if _, ok := table.rates[rate.To]; ok {
+			return Rates{}, fmt.Errorf("%w: there's more than one rate for %v", ErrInvalidRate, rate.To)
+		}
+		table.rates[rate.To] = rate
+	}
+
+	return table, nil
+}
+
+// Rate returns the rate that converts amounts of the base currency into the given one.
+// There's no rate for the base currency itself, nor for the currencies the shop doesn't sell in.
+func (r Rates) Rate(to Currency) (Rate, bool) {
+	rate, ok := r.rates[to]
+	return rate, ok
+}
+
+// Supports reports whether the shop sells in the currency, which is either the base one or one with a rate.
+func (r Rates) Supports(currency Currency) bool {
+	_, ok := r.rates[currency]
+	return currency == r.Base || ok
+}
+
+// Currencies returns the currencies the shop sells in, the base one first and then the rest sorted by code.
+func (r Rates) Currencies() []Currency {
+	currencies := make([]Currency, 0, len(r.rates))
+	for currency := range r.rates {
+		currencies = append(currencies, currency)
+	}
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i] < currencies[j] })
+
+	return append([]Currency{r.Base}, currencies...)
+}
+
+// ratesFile is the layout of the rates file, the base currency along with the rate of every other currency, e.g.
+//
+//	base: USD
+//	rates:
+//	  EUR: "0.92"
+type ratesFile struct {
+	Base  Currency            `json:"base" yaml:"base"`
+	Rates map[Currency]string `json:"rates" yaml:"rates"`
+}
+
+// LoadRates reads the exchange rates from a JSON or YAML file, depending on its extension.
+func LoadRates(path string) (Rates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Rates{}, err
+	}
+
+	var file ratesFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&file)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&file)
+	default:
+		return Rates{}, errors.New(fmt.Sprintf("unsupported rates file format %v", filepath.Ext(path)))
+	}
+	if err != nil {
+		return Rates{}, errors.New(fmt.Sprintf("parsing %v: %v", path, err))
+	}
+
+	rates := make([]Rate, 0, len(file.Rates))
+	for currency, value := range file.Rates {
+		rate, err := ParseRate(file.Base, currency, value)
+		if err != nil {
+			return Rates{}, errors.New(fmt.Sprintf("%v: %v", path, err))
+		}
+		rates = append(rates, rate)
+	}
+
+	table, err := NewRates(file.Base, rates...)
+	if err != nil {
+		return Rates{}, errors.New(fmt.Sprintf("%v: %v", path, err))
+	}
+
+	return table, nil
+}
+
+func pow10(exponent int) int64 {
+	power := int64(1)
+	for i := 0; i < exponent; i++ {
+		power *= 10
+	}
+
+	return power
+}
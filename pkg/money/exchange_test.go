@@ -0,0 +1,147 @@
+package money
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRate(t *testing.T) {
+	// When
+	rate, err := ParseRate(USD, EUR, "0.920")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "0.92", rate.Value())
+	require.Equal(t, "1 USD = 0.92 EUR", rate.String())
+}
+
+func TestParseRate_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		from  Currency
+		value string
+		err   error
+	}{
+		{name: "unknown currency", from: "XYZ", value: "1", err: ErrUnknownCurrency},
+		{name: "not a number", from: USD, value: "abc", err: ErrInvalidRate},
+		{name: "negative", from: USD, value: "-0.92", err: ErrInvalidRate},
+		{name: "zero", from: USD, value: "0.00", err: ErrInvalidRate},
+		{name: "too many decimal places", from: USD, value: "0.1234567", err: ErrInvalidRate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			_, err := ParseRate(tt.from, EUR, tt.value)
+
+			// Then
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestRate_Convert(t *testing.T) {
+	tests := []struct {
+		name     string
+		to       Currency
+		rate     string
+		amount   Money
+		mode     RoundingMode
+		expected Money
+	}{
+		{name: "exact", to: EUR, rate: "0.92", amount: FromMajor(15, USD), expected: New(1380, EUR)},
+		{name: "half up", to: EUR, rate: "0.925", amount: New(1, USD), mode: HalfUp, expected: New(1, EUR)},
+		{name: "half even", to: EUR, rate: "0.25", amount: New(10, USD), mode: HalfEven, expected: New(2, EUR)},
+		{name: "down", to: EUR, rate: "0.925", amount: New(150, USD), mode: Down, expected: New(138, EUR)},
+		{name: "to a currency without minor units", to: JPY, rate: "151.35", amount: New(1050, USD), expected: New(1589, JPY)},
+		{name: "zero", to: EUR, rate: "0.92", amount: Money{}, expected: Zero(EUR)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			rate, err := ParseRate(USD, tt.to, tt.rate)
+			require.NoError(t, err)
+
+			// When
+			converted := rate.Convert(tt.amount, tt.mode)
+
+			// Then
+			require.Equal(t, tt.expected, converted)
+		})
+	}
+}
+
+func TestRate_Convert_Other_Currency_Panics(t *testing.T) {
+	rate, err := ParseRate(USD, EUR, "0.92")
+	require.NoError(t, err)
+
+	require.Panics(t, func() {
+		rate.Convert(New(100, GBP), HalfUp)
+	})
+}
+
+func TestRate_JSON(t *testing.T) {
+	// Given
+	rate, err := ParseRate(USD, EUR, "0.92")
+	require.NoError(t, err)
+
+	// When
+	data, marshalErr := json.Marshal(rate)
+	var decoded Rate
+	unmarshalErr := json.Unmarshal(data, &decoded)
+
+	// Then
+	require.NoError(t, marshalErr)
+	require.JSONEq(t, `{"from": "USD", "to": "EUR", "rate": "0.92"}`, string(data))
+	require.NoError(t, unmarshalErr)
+	require.Equal(t, rate, decoded)
+}
+
+func TestNewRates_Errors(t *testing.T) {
+	toEUR, err := ParseRate(USD, EUR, "0.92")
+	require.NoError(t, err)
+	fromGBP, err := ParseRate(GBP, EUR, "1.17")
+	require.NoError(t, err)
+
+	_, duplicatedErr := NewRates(USD, toEUR, toEUR)
+	_, otherBaseErr := NewRates(USD, fromGBP)
+
+	require.ErrorIs(t, duplicatedErr, ErrInvalidRate)
+	require.ErrorIs(t, otherBaseErr, ErrInvalidRate)
+}
+
+func TestLoadRates(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "rates.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("base: USD\nrates:\n  GBP: 0.79\n  EUR: \"0.92\"\n"), 0o644))
+
+	// When
+	rates, err := LoadRates(path)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []Currency{USD, EUR, GBP}, rates.Currencies())
+	require.True(t, rates.Supports(USD))
+	require.False(t, rates.Supports(JPY))
+	rate, ok := rates.Rate(GBP)
+	require.True(t, ok)
+	require.Equal(t, "1 USD = 0.79 GBP", rate.String())
+	_, ok = rates.Rate(USD)
+	require.False(t, ok)
+}
+
+func TestLoadRates_Invalid(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "rates.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"base": "USD", "rates": {"EUR": "-1"}}`), 0o644))
+
+	// When
+	_, err := LoadRates(path)
+
+	// Then
+	require.ErrorContains(t, err, "invalid exchange rate")
+}
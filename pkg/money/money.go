@@ -0,0 +1,124 @@
+package money
+
+import (
+	"fmt"
+)
+
+// Money is an amount of a currency, counted in its minor unit, e.g. cents for USD, so it's never rounded implicitly.
+//
+// Amounts are only combined with amounts of the same currency; mixing currencies is a bug and panics. The zero
+// value is a zero amount without currency, which can be combined with any currency.
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+// New returns the amount of minor units of the currency.
+func New(amount int64, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// FromMajor returns the amount of whole units of the currency, e.g. dollars for USD.
+func FromMajor(amount int64, currency Currency) Money {
+	return Money{Amount: amount * currency.scale(), Currency: currency}
+}
+
+func Zero(currency Currency) Money {
+	return Money{Currency: currency}
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount + other.Amount, Currency: m.currencyWith(other)}
+}
+
+func (m Money) Sub(other Money) Money {
+	return Money{Amount: m.Amount - other.Amount, Currency: m.currencyWith(other)}
+}
+
+// Mul returns the amount times quantity, e.g. the price of several units of a product.
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// Percent returns the given percentage of the amount, rounded to the minor unit with the given mode.
+func (m Money) Percent(percent int, mode RoundingMode) Money {
+	return m.MulRatio(int64(percent), 100, mode)
+}
+
+// MulRatio returns the amount times numerator / denominator, rounded to the minor unit with the given mode.
+// The denominator must be positive.
+func (m Money) MulRatio(numerator, denominator int64, mode RoundingMode) Money {
+	return Money{Amount: divide(m.Amount*numerator, denominator, mode), Currency: m.Currency}
+}
+
+// Cmp returns -1, 0 or +1 depending on whether the amount is less than, equal to or greater than the other one.
+func (m Money) Cmp(other Money) int {
+	m.currencyWith(other)
+	switch {
+	case m.Amount < other.Amount:
+		return -1
+	case m.Amount > other.Amount:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Min returns the smallest of both amounts.
+func Min(a, b Money) Money {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+
+	return b
+}
+
+// String formats the amount in whole units followed by its currency, e.g. "15.00 USD".
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.decimal()
+	}
+
+	return fmt.Sprintf("%v %v", m.decimal(), m.Currency)
+}
+
+// decimal formats the amount in whole units with as many decimal places as its currency has, e.g. "-3.50".
+func (m Money) decimal() string {
+	scale := m.Currency.scale()
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	if scale == 1 {
+		return fmt.Sprintf("%v%d", sign, amount)
+	}
+
+	return fmt.Sprintf("%v%d.%0*d", sign, amount/scale, m.Currency.MinorUnits(), amount%scale)
+}
+
+// currencyWith returns the currency of the result of combining both amounts.
+func (m Money) currencyWith(other Money) Currency {
+	switch {
+	case m.Currency == other.Currency:
+		return m.Currency
+	case m.Currency == "" && m.Amount == 0:
+		return other.Currency
+	case other.Currency == "" && other.Amount == 0:
+		return m.Currency
+	default:
+		panic(fmt.Sprintf("money: can't combine %v with %v", m, other))
+	}
+}
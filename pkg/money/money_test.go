@@ -0,0 +1,98 @@
+package money
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFromMajor(t *testing.T) {
+	require.Equal(t, New(1500, USD), FromMajor(15, USD))
+	require.Equal(t, New(1500, JPY), FromMajor(1500, JPY))
+}
+
+func TestAdd_And_Sub(t *testing.T) {
+	// Given
+	price := New(1050, USD)
+
+	// When
+	sum := price.Add(New(250, USD))
+	difference := price.Sub(New(2000, USD))
+
+	// Then
+	require.Equal(t, New(1300, USD), sum)
+	require.Equal(t, New(-950, USD), difference)
+	require.True(t, difference.IsNegative())
+}
+
+func TestAdd_Zero_Value_Takes_The_Currency(t *testing.T) {
+	// When
+	sum := Money{}.Add(New(250, EUR))
+
+	// Then
+	require.Equal(t, New(250, EUR), sum)
+}
+
+func TestAdd_Mixed_Currencies_Panics(t *testing.T) {
+	require.Panics(t, func() {
+		New(100, USD).Add(New(100, EUR))
+	})
+}
+
+func TestMul(t *testing.T) {
+	require.Equal(t, New(4500, USD), New(1500, USD).Mul(3))
+}
+
+func TestPercent_Rounding(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   int64
+		mode     RoundingMode
+		expected int64
+	}{
+		{name: "half up rounds halves away from zero", amount: 125, mode: HalfUp, expected: 13},
+		{name: "half up rounds below half down", amount: 124, mode: HalfUp, expected: 12},
+		{name: "half even rounds halves to even", amount: 125, mode: HalfEven, expected: 12},
+		{name: "half even rounds odd halves up", amount: 135, mode: HalfEven, expected: 14},
+		{name: "half even rounds above half up", amount: 126, mode: HalfEven, expected: 13},
+		{name: "down truncates", amount: 129, mode: Down, expected: 12},
+		{name: "up rounds any fraction up", amount: 121, mode: Up, expected: 13},
+		{name: "exact amounts aren't rounded", amount: 8500, mode: Up, expected: 850},
+		{name: "negative halves go away from zero", amount: -125, mode: HalfUp, expected: -13},
+		{name: "negative amounts truncate towards zero", amount: -129, mode: Down, expected: -12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			result := New(tt.amount, USD).Percent(10, tt.mode)
+
+			// Then
+			require.Equal(t, New(tt.expected, USD), result)
+		})
+	}
+}
+
+func TestMin(t *testing.T) {
+	require.Equal(t, New(100, USD), Min(New(100, USD), New(200, USD)))
+	require.Equal(t, New(100, USD), Min(New(200, USD), New(100, USD)))
+}
+
+func TestString(t *testing.T) {
+	require.Equal(t, "15.00 USD", FromMajor(15, USD).String())
+	require.Equal(t, "-0.05 EUR", New(-5, EUR).String())
+	require.Equal(t, "1500 JPY", New(1500, JPY).String())
+}
+
+func TestRoundingMode_UnmarshalText(t *testing.T) {
+	// Given
+	var mode RoundingMode
+
+	// When
+	err := mode.UnmarshalText([]byte("half_even"))
+	unknownErr := mode.UnmarshalText([]byte("nearest"))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, HalfEven, mode)
+	require.ErrorIs(t, unknownErr, ErrUnknownRoundingMode)
+}
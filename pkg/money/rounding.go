@@ -0,0 +1,91 @@
+package money
+
+import (
+	"fmt"
+)
+
+// RoundingMode tells how an amount that falls between two minor units, such as a percentage of a price, is rounded.
+// The zero value is HalfUp.
+type RoundingMode int
+
+const (
+	// HalfUp rounds to the nearest minor unit, and halves away from zero.
+	HalfUp RoundingMode = iota
+	// HalfEven rounds to the nearest minor unit, and halves to the even one.
+	HalfEven
+	// Down rounds towards zero, truncating the amount.
+	Down
+	// Up rounds away from zero.
+	Up
+)
+
+var roundingModeNames = map[RoundingMode]string{
+	HalfUp:   "half_up",
+	HalfEven: "half_even",
+	Down:     "down",
+	Up:       "up",
+}
+
+func (m RoundingMode) String() string {
+	if name, ok := roundingModeNames[m]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("RoundingMode(%d)", int(m))
+}
+
+func (m RoundingMode) MarshalText() ([]byte, error) {
+	if _, ok := roundingModeNames[m]; !ok {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownRoundingMode, m)
+	}
+
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText reads the rounding modes by their name, such as "half_even", so they can be set in config files.
+func (m *RoundingMode) UnmarshalText(text []byte) error {
+	for mode, name := range roundingModeNames {
+		if name == string(text) {
+			*m = mode
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %q", ErrUnknownRoundingMode, text)
+}
+
+// divide returns numerator / denominator rounded to an integer with the given mode. The denominator must be positive.
+func divide(numerator, denominator int64, mode RoundingMode) int64 {
+	return round(numerator/denominator, numerator%denominator, denominator, mode)
+}
+
+// round rounds the quotient of a division, truncated towards zero as Go does, with the given mode. The remainder has
+// the sign of the numerator and the denominator must be positive.
+func round(quotient, remainder, denominator int64, mode RoundingMode) int64 {
+	if remainder == 0 {
+		return quotient
+	}
+
+	// The quotient moves one unit away from zero when rounding up
+	away := int64(1)
+	if remainder < 0 {
+		away, remainder = -1, -remainder
+	}
+
+	switch mode {
+	case Down:
+		return quotient
+	case Up:
+		return quotient + away
+	case HalfEven:
+		if 2*remainder > denominator || (2*remainder == denominator && quotient%2 != 0) {
+			return quotient + away
+		}
+		return quotient
+	default:
+		if 2*remainder >= denominator {
+			return quotient + away
+		}
+		return quotient
+	}
+}
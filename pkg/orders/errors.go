@@ -0,0 +1,23 @@
+package orders
+
+import (
+	"errors"
+	"fmt"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/storage"
+)
+
+var (
+	ErrOrderNotFound       = storage.ErrOrderNotFound
+	ErrReservationNotFound = storage.ErrReservationNotFound
+	ErrIllegalTransition   = errors.New("illegal order transition")
+	ErrReservationExpired  = errors.New("reservation expired")
+)
+
+func illegalTransition(orderID int, from, to models.OrderStatus) error {
+	return fmt.Errorf("%w: order %v can't go from %v to %v", ErrIllegalTransition, orderID, from, to)
+}
+
+func reservationExpired(orderID int) error {
+	return fmt.Errorf("%w: the stock held for order %v was given back", ErrReservationExpired, orderID)
+}
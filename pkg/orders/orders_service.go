@@ -0,0 +1,145 @@
+package orders
+
+import (
+	"errors"
+	"time"
+	"trafilea-tech-challenge/pkg/coupons"
+	"trafilea-tech-challenge/pkg/inventory"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/storage"
+)
+
+type Orders interface {
+	Transition(orderID int, to models.OrderStatus) (models.Order, error)
+	// ExpireReservations cancels the pending orders whose stock reservation expired, so their units and the coupons
+	// they redeemed are available again, and returns how many orders it cancelled.
+	ExpireReservations() (int, error)
+}
+
+type orders struct {
+	OrderRepo storage.OrderRepository
+	Inventory inventory.Inventory
+	Coupons   coupons.Coupons
+	now       func() time.Time
+}
+
+func NewOrders(repo storage.OrderRepository, inventory inventory.Inventory, coupons coupons.Coupons) Orders {
+	return &orders{
+		OrderRepo: repo,
+		Inventory: inventory,
+		Coupons:   coupons,
+		now:       time.Now,
+	}
+}
+
+// Transition moves the order to the given status, recording when it happened, if the state machine allows it.
+// Paying an order takes its reserved units out of the stock, which can't be done once the reservation expired,
+// while cancelling it makes them available again, along with the coupons it redeemed. The order only moves once its
+// stock was updated.
+func (o *orders) Transition(orderID int, to models.OrderStatus) (models.Order, error) {
+	for {
+		order, err := o.OrderRepo.GetOrderByID(orderID)
+		if err != nil {
+			return models.Order{}, err
+		}
+
+		if !CanTransition(order.Status, to) {
+			return models.Order{}, illegalTransition(orderID, order.Status, to)
+		}
+
+		if to == models.OrderPaid {
+			if err := o.checkReservation(orderID); err != nil {
+				return models.Order{}, err
+			}
+		}
+
+		if to == models.OrderPaid || to == models.OrderCancelled {
+			if err := o.settleReservation(orderID, to); err != nil {
+				return models.Order{}, err
+			}
+		}
+
+		updated, err := o.OrderRepo.UpdateOrderStatus(orderID, order.Status, models.StatusChange{Status: to, At: o.now().UTC()})
+		if errors.Is(err, storage.ErrOrderStatusStale) {
+			// Another request moved the order in the meantime, so the transition is checked again from its new
+			// status. Transitions never lead back to a previous status, so this ends.
+			continue
+		}
+		if err != nil {
+			return models.Order{}, err
+		}
+
+		return updated, nil
+	}
+}
+
+func (o *orders) ExpireReservations() (int, error) {
+	expired, err := o.Inventory.ExpiredReservations()
+	if err != nil {
+		return 0, err
+	}
+
+	cancelled := 0
+	for _, reservation := range expired {
+		order, err := o.OrderRepo.GetOrderByID(reservation.OrderID)
+		switch {
+		case errors.Is(err, ErrOrderNotFound):
+			// The order couldn't be stored after reserving its stock
+			err = o.Inventory.Release(reservation.OrderID)
+		case err != nil:
+		case order.Status == models.OrderPending:
+			if _, err = o.Transition(reservation.OrderID, models.OrderCancelled); err == nil {
+				cancelled++
+			}
+		default:
+			// The order was paid or cancelled since the reservation was listed
+			err = o.settleReservation(reservation.OrderID, order.Status)
+		}
+
+		// Orders paid while they were being cancelled are settled by the request paying them
+		if err != nil && !errors.Is(err, ErrIllegalTransition) && !errors.Is(err, ErrReservationNotFound) {
+			return cancelled, err
+		}
+	}
+
+	return cancelled, nil
+}
+
+// checkReservation fails when the units reserved for the order are about to be given back. Orders placed before
+// stock was tracked have no reservation.
+func (o *orders) checkReservation(orderID int) error {
+	reservation, err := o.Inventory.GetReservation(orderID)
+	if errors.Is(err, ErrReservationNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !reservation.ExpiresAt.After(o.now()) {
+		return reservationExpired(orderID)
+	}
+
+	return nil
+}
+
+// settleReservation sells the reserved units of the order once it's paid, or gives them back once it's cancelled
+// along with its coupons. Later statuses leave the stock alone: refunded units aren't restocked.
+func (o *orders) settleReservation(orderID int, status models.OrderStatus) error {
+	var err error
+	switch status {
+	case models.OrderCancelled:
+		if err = o.Inventory.Release(orderID); err == nil || errors.Is(err, ErrReservationNotFound) {
+			err = o.Coupons.Release(orderID)
+		}
+	case models.OrderPending, models.OrderRefunded:
+	default:
+		err = o.Inventory.Commit(orderID)
+	}
+
+	if errors.Is(err, ErrReservationNotFound) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,253 @@
+package orders
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"trafilea-tech-challenge/pkg/coupons"
+	"trafilea-tech-challenge/pkg/inventory"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/storage"
+)
+
+func newTestOrders(repo storage.OrderRepository, stock inventory.Inventory, redeemed coupons.Coupons, now time.Time) Orders {
+	return &orders{
+		OrderRepo: repo,
+		Inventory: stock,
+		Coupons:   redeemed,
+		now:       func() time.Time { return now },
+	}
+}
+
+// newTestInventory holds stock for order 1 until the given time.
+func newTestInventory(expiresAt time.Time) *inventory.InventoryMock {
+	stock := &inventory.InventoryMock{}
+	stock.On("GetReservation", 1).Return(models.Reservation{OrderID: 1, Items: map[string]int{"COF-001": 1}, ExpiresAt: expiresAt}, nil)
+	stock.On("Commit", 1).Return(nil)
+	stock.On("Release", 1).Return(nil)
+	return stock
+}
+
+// newTestCoupons gives back the coupons of any order.
+func newTestCoupons() *coupons.CouponsMock {
+	redeemed := &coupons.CouponsMock{}
+	redeemed.On("Release", mock.Anything).Return(nil)
+	return redeemed
+}
+
+func TestTransition_Success(t *testing.T) {
+	// Given
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	pending := models.Order{Totals: models.Total{Order: 1}, Status: models.OrderPending}
+	paid := models.Order{Totals: models.Total{Order: 1}, Status: models.OrderPaid}
+	repo := &storage.OrderRepositoryMock{}
+	repo.On("GetOrderByID", 1).Return(pending, nil)
+	repo.On("UpdateOrderStatus", 1, models.OrderPending, models.StatusChange{Status: models.OrderPaid, At: now}).Return(paid, nil)
+	service := newTestOrders(repo, newTestInventory(time.Now().Add(time.Hour)), newTestCoupons(), now)
+
+	// When
+	order, err := service.Transition(1, models.OrderPaid)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, models.OrderPaid, order.Status)
+	repo.AssertExpectations(t)
+}
+
+func TestTransition_Illegal(t *testing.T) {
+	// Given
+	repo := &storage.OrderRepositoryMock{}
+	repo.On("GetOrderByID", 1).Return(models.Order{Totals: models.Total{Order: 1}, Status: models.OrderShipped}, nil)
+	service := newTestOrders(repo, newTestInventory(time.Now().Add(time.Hour)), newTestCoupons(), time.Now())
+
+	// When
+	_, err := service.Transition(1, models.OrderCancelled)
+
+	// Then
+	require.ErrorIs(t, err, ErrIllegalTransition)
+	require.EqualError(t, err, "illegal order transition: order 1 can't go from shipped to cancelled")
+	repo.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransition_Order_Changed_Concurrently(t *testing.T) {
+	// Given an order that gets paid by another request right before it is cancelled
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	repo := &storage.OrderRepositoryMock{}
+	repo.On("GetOrderByID", 1).Return(models.Order{Totals: models.Total{Order: 1}, Status: models.OrderPending}, nil).Once()
+	repo.On("UpdateOrderStatus", 1, models.OrderPending, models.StatusChange{Status: models.OrderCancelled, At: now}).
+		Return(models.Order{}, fmt.Errorf("%w: order 1 is no longer pending", storage.ErrOrderStatusStale)).Once()
+	repo.On("GetOrderByID", 1).Return(models.Order{Totals: models.Total{Order: 1}, Status: models.OrderPaid}, nil).Once()
+	service := newTestOrders(repo, newTestInventory(time.Now().Add(time.Hour)), newTestCoupons(), now)
+
+	// When
+	_, err := service.Transition(1, models.OrderCancelled)
+
+	// Then
+	require.ErrorIs(t, err, ErrIllegalTransition)
+	repo.AssertExpectations(t)
+}
+
+func TestTransition_Order_Not_Found(t *testing.T) {
+	// Given
+	repo := &storage.OrderRepositoryMock{}
+	repo.On("GetOrderByID", 1).Return(models.Order{}, fmt.Errorf("%w: order 1 doesn't exist", storage.ErrOrderNotFound))
+	service := newTestOrders(repo, newTestInventory(time.Now().Add(time.Hour)), newTestCoupons(), time.Now())
+
+	// When
+	_, err := service.Transition(1, models.OrderPaid)
+
+	// Then
+	require.ErrorIs(t, err, ErrOrderNotFound)
+}
+
+func TestTransition_Pay_Commits_Reservation(t *testing.T) {
+	// Given
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	repo := &storage.OrderRepositoryMock{}
+	repo.On("GetOrderByID", 1).Return(models.Order{Totals: models.Total{Order: 1}, Status: models.OrderPending}, nil)
+	repo.On("UpdateOrderStatus", 1, models.OrderPending, models.StatusChange{Status: models.OrderPaid, At: now}).
+		Return(models.Order{Totals: models.Total{Order: 1}, Status: models.OrderPaid}, nil)
+	stock := newTestInventory(now.Add(time.Minute))
+	service := newTestOrders(repo, stock, newTestCoupons(), now)
+
+	// When
+	_, err := service.Transition(1, models.OrderPaid)
+
+	// Then
+	require.NoError(t, err)
+	stock.AssertCalled(t, "Commit", 1)
+	stock.AssertNotCalled(t, "Release", mock.Anything)
+}
+
+func TestTransition_Pay_Commit_Fails(t *testing.T) {
+	// Given
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	placedAt := now.Add(-time.Minute)
+	repo := storage.NewOrderRepo()
+	_, err := repo.CreateOrder(models.Order{Totals: models.Total{Order: 1}, Status: models.OrderPending,
+		History: []models.StatusChange{{Status: models.OrderPending, At: placedAt}}})
+	require.NoError(t, err)
+	stock := &inventory.InventoryMock{}
+	stock.On("GetReservation", 1).Return(models.Reservation{OrderID: 1, Items: map[string]int{"COF-001": 1}, ExpiresAt: now.Add(time.Minute)}, nil)
+	stock.On("Commit", 1).Return(errors.New("disk full"))
+	service := newTestOrders(repo, stock, newTestCoupons(), now)
+
+	// When
+	_, err = service.Transition(1, models.OrderPaid)
+
+	// Then the order is still pending, without a trace of the payment that failed
+	require.EqualError(t, err, "disk full")
+	order, err := repo.GetOrderByID(1)
+	require.NoError(t, err)
+	require.Equal(t, models.OrderPending, order.Status)
+	require.Equal(t, []models.StatusChange{{Status: models.OrderPending, At: placedAt}}, order.History)
+}
+
+func TestTransition_Pay_Reservation_Expired(t *testing.T) {
+	// Given
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	repo := &storage.OrderRepositoryMock{}
+	repo.On("GetOrderByID", 1).Return(models.Order{Totals: models.Total{Order: 1}, Status: models.OrderPending}, nil)
+	stock := newTestInventory(now)
+	service := newTestOrders(repo, stock, newTestCoupons(), now)
+
+	// When
+	_, err := service.Transition(1, models.OrderPaid)
+
+	// Then
+	require.ErrorIs(t, err, ErrReservationExpired)
+	require.EqualError(t, err, "reservation expired: the stock held for order 1 was given back")
+	repo.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything, mock.Anything)
+	stock.AssertNotCalled(t, "Commit", mock.Anything)
+}
+
+func TestTransition_Cancel_Releases_Reservation(t *testing.T) {
+	// Given
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	repo := &storage.OrderRepositoryMock{}
+	repo.On("GetOrderByID", 1).Return(models.Order{Totals: models.Total{Order: 1}, Status: models.OrderPending}, nil)
+	repo.On("UpdateOrderStatus", 1, models.OrderPending, models.StatusChange{Status: models.OrderCancelled, At: now}).
+		Return(models.Order{Totals: models.Total{Order: 1}, Status: models.OrderCancelled}, nil)
+	stock := newTestInventory(now.Add(time.Minute))
+	service := newTestOrders(repo, stock, newTestCoupons(), now)
+
+	// When
+	_, err := service.Transition(1, models.OrderCancelled)
+
+	// Then
+	require.NoError(t, err)
+	stock.AssertCalled(t, "Release", 1)
+}
+
+func TestTransition_Cancel_Releases_Coupons(t *testing.T) {
+	// Given an order whose coupons were redeemed and one placed before stock was tracked
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	repo := &storage.OrderRepositoryMock{}
+	for _, orderID := range []int{1, 2} {
+		repo.On("GetOrderByID", orderID).Return(models.Order{Totals: models.Total{Order: orderID}, Status: models.OrderPending}, nil)
+		repo.On("UpdateOrderStatus", orderID, models.OrderPending, models.StatusChange{Status: models.OrderCancelled, At: now}).
+			Return(models.Order{Totals: models.Total{Order: orderID}, Status: models.OrderCancelled}, nil)
+	}
+	stock := newTestInventory(now.Add(time.Minute))
+	stock.On("Release", 2).Return(fmt.Errorf("%w: order 2 has no reservation", storage.ErrReservationNotFound))
+	redeemed := newTestCoupons()
+	service := newTestOrders(repo, stock, redeemed, now)
+
+	// When
+	_, err := service.Transition(1, models.OrderCancelled)
+	_, untrackedErr := service.Transition(2, models.OrderCancelled)
+
+	// Then
+	require.NoError(t, err)
+	require.NoError(t, untrackedErr)
+	redeemed.AssertCalled(t, "Release", 1)
+	redeemed.AssertCalled(t, "Release", 2)
+}
+
+func TestTransition_Cancel_Release_Coupons_Fails(t *testing.T) {
+	// Given
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	repo := &storage.OrderRepositoryMock{}
+	repo.On("GetOrderByID", 1).Return(models.Order{Totals: models.Total{Order: 1}, Status: models.OrderPending}, nil)
+	redeemed := &coupons.CouponsMock{}
+	redeemed.On("Release", 1).Return(errors.New("disk full"))
+	service := newTestOrders(repo, newTestInventory(now.Add(time.Minute)), redeemed, now)
+
+	// When
+	_, err := service.Transition(1, models.OrderCancelled)
+
+	// Then the order stays pending
+	require.EqualError(t, err, "disk full")
+	repo.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExpireReservations(t *testing.T) {
+	// Given a pending order, a paid one whose stock wasn't committed yet and a reservation without order
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	repo := &storage.OrderRepositoryMock{}
+	repo.On("GetOrderByID", 1).Return(models.Order{Totals: models.Total{Order: 1}, Status: models.OrderPending}, nil)
+	repo.On("UpdateOrderStatus", 1, models.OrderPending, models.StatusChange{Status: models.OrderCancelled, At: now}).
+		Return(models.Order{Totals: models.Total{Order: 1}, Status: models.OrderCancelled}, nil)
+	repo.On("GetOrderByID", 2).Return(models.Order{Totals: models.Total{Order: 2}, Status: models.OrderPaid}, nil)
+	repo.On("GetOrderByID", 3).Return(models.Order{}, fmt.Errorf("%w: order 3 doesn't exist", storage.ErrOrderNotFound))
+	stock := &inventory.InventoryMock{}
+	stock.On("ExpiredReservations").Return([]models.Reservation{{OrderID: 1}, {OrderID: 2}, {OrderID: 3}}, nil)
+	stock.On("Release", 1).Return(nil)
+	stock.On("Commit", 2).Return(nil)
+	stock.On("Release", 3).Return(nil)
+	redeemed := newTestCoupons()
+	service := newTestOrders(repo, stock, redeemed, now)
+
+	// When
+	cancelled, err := service.ExpireReservations()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 1, cancelled)
+	repo.AssertExpectations(t)
+	stock.AssertExpectations(t)
+	redeemed.AssertCalled(t, "Release", 1)
+}
@@ -0,0 +1,26 @@
+package orders
+
+import (
+	"trafilea-tech-challenge/pkg/models"
+)
+
+// transitions lists the statuses an order can move to from each status. Orders can be cancelled until they are
+// paid and refunded once paid, except while they are on their way. Cancelled and refunded orders are final.
+var transitions = map[models.OrderStatus][]models.OrderStatus{
+	models.OrderPending:   {models.OrderPaid, models.OrderCancelled},
+	models.OrderPaid:      {models.OrderFulfilled, models.OrderRefunded},
+	models.OrderFulfilled: {models.OrderShipped, models.OrderRefunded},
+	models.OrderShipped:   {models.OrderDelivered},
+	models.OrderDelivered: {models.OrderRefunded},
+}
+
+// CanTransition tells whether an order in the from status can move to the to status.
+func CanTransition(from, to models.OrderStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
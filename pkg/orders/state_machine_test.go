@@ -0,0 +1,39 @@
+package orders
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from     models.OrderStatus
+		to       models.OrderStatus
+		expected bool
+	}{
+		{from: models.OrderPending, to: models.OrderPaid, expected: true},
+		{from: models.OrderPending, to: models.OrderCancelled, expected: true},
+		{from: models.OrderPending, to: models.OrderFulfilled, expected: false},
+		{from: models.OrderPending, to: models.OrderRefunded, expected: false},
+		{from: models.OrderPaid, to: models.OrderFulfilled, expected: true},
+		{from: models.OrderPaid, to: models.OrderRefunded, expected: true},
+		{from: models.OrderPaid, to: models.OrderCancelled, expected: false},
+		{from: models.OrderPaid, to: models.OrderPending, expected: false},
+		{from: models.OrderFulfilled, to: models.OrderShipped, expected: true},
+		{from: models.OrderFulfilled, to: models.OrderRefunded, expected: true},
+		{from: models.OrderShipped, to: models.OrderDelivered, expected: true},
+		{from: models.OrderShipped, to: models.OrderRefunded, expected: false},
+		{from: models.OrderDelivered, to: models.OrderRefunded, expected: true},
+		{from: models.OrderDelivered, to: models.OrderShipped, expected: false},
+		{from: models.OrderCancelled, to: models.OrderPaid, expected: false},
+		{from: models.OrderRefunded, to: models.OrderPaid, expected: false},
+		{from: models.OrderPaid, to: models.OrderPaid, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+string(tt.to), func(t *testing.T) {
+			require.Equal(t, tt.expected, CanTransition(tt.from, tt.to))
+		})
+	}
+}
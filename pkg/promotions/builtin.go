@@ -0,0 +1,226 @@
+package promotions
+
+import (
+	"fmt"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+)
+
+const (
+	ExtraCoffeeID         = "extra-coffee"
+	AccessoriesDiscountID = "accessories-discount"
+	EquipmentShippingID   = "equipment-free-shipping"
+)
+
+// Condition restricts a promotion to carts holding at least MinQuantity products of Category
+// that add up to at least MinSubtotal. An empty Category matches every product in the cart.
+// Carts in another currency get MinSubtotal converted with their exchange rate.
+type Condition struct {
+	Category    string      `json:"category,omitempty" yaml:"category,omitempty"`
+	MinQuantity int         `json:"min_quantity,omitempty" yaml:"min_quantity,omitempty"`
+	MinSubtotal money.Money `json:"min_subtotal,omitempty" yaml:"min_subtotal,omitempty"`
+}
+
+func (c Condition) Matches(cart models.Cart) bool {
+	minSubtotal := inCartCurrency(cart, c.MinSubtotal)
+	return countByCategory(cart, c.Category) >= c.MinQuantity && subtotalByCategory(cart, c.Category).Cmp(minSubtotal) >= 0
+}
+
+// describe tells what a cart needs to meet the condition, e.g. "at least 2 coffee products", with MinSubtotal in the
+// currency of the cart. It's empty for the conditions every cart meets.
+func (c Condition) describe(cart models.Cart) string {
+	products := "products"
+	if c.Category != "" {
+		products = c.Category + " products"
+	}
+
+	minSubtotal := inCartCurrency(cart, c.MinSubtotal)
+	switch {
+	case c.MinQuantity > 0 && minSubtotal.IsPositive():
+		return fmt.Sprintf("at least %v %v worth %v", c.MinQuantity, products, minSubtotal)
+	case c.MinQuantity > 0:
+		return fmt.Sprintf("at least %v %v", c.MinQuantity, products)
+	case minSubtotal.IsPositive():
+		return fmt.Sprintf("at least %v of %v", minSubtotal, products)
+	default:
+		return ""
+	}
+}
+
+// describe returns the description of the adjustments of a promotion: the one it was given, or else its effect along
+// with what the cart needed for it, e.g. "10% off for at least 70.01 USD of accessories products".
+func describe(description, effect string, condition Condition, cart models.Cart) string {
+	if description != "" {
+		return description
+	}
+
+	if needs := condition.describe(cart); needs != "" {
+		return effect + " for " + needs
+	}
+
+	return effect
+}
+
+// FreeItem gives Item away once the condition is met. The item is a free line item of the cart, derived from the
+// rest of it, so it's taken back as soon as the cart no longer meets the condition.
+// Free line items never count towards the condition.
+type FreeItem struct {
+	PromotionID string
+	Description string
+	Condition   Condition
+	Item        models.Product
+}
+
+func (p FreeItem) ID() string {
+	return p.PromotionID
+}
+
+func (p FreeItem) Apply(cart models.Cart, result *Result) {
+	if !p.Condition.Matches(cart) {
+		return
+	}
+
+	// Free items are given away in any currency
+	item := p.Item
+	item.Price = money.Zero(result.Discount.Currency)
+	result.FreeItems = append(result.FreeItems, models.LineItem{Product: item, Quantity: 1, Free: true, PromotionID: p.PromotionID})
+
+	// The item costs nothing, so it only needs to show up among the adjustments once the cart has it
+	if hasFreeItem(cart, p.PromotionID) {
+		result.Adjustments = append(result.Adjustments, models.Adjustment{
+			ID:          p.PromotionID,
+			Description: describe(p.Description, fmt.Sprintf("Free %v", p.Item.Name), p.Condition, cart),
+			Items:       []string{p.Item.Name},
+			Amount:      money.Zero(result.Discount.Currency),
+		})
+	}
+}
+
+// hasFreeItem reports whether the cart has the free line item given away by the promotion.
+func hasFreeItem(cart models.Cart, promotionID string) bool {
+	for _, item := range cart.Items {
+		if item.Free && item.PromotionID == promotionID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PercentOff discounts Percent of the cart subtotal left after the previous promotions once the condition is met,
+// rounded to the minor unit of the currency with Rounding.
+type PercentOff struct {
+	PromotionID string
+	Description string
+	Condition   Condition
+	Percent     int
+	Rounding    money.RoundingMode
+}
+
+func (p PercentOff) ID() string {
+	return p.PromotionID
+}
+
+func (p PercentOff) Apply(cart models.Cart, result *Result) {
+	if !p.Condition.Matches(cart) {
+		return
+	}
+
+	discount := result.Subtotal.Sub(result.Discount).Percent(p.Percent, p.Rounding)
+	result.Discount = result.Discount.Add(discount)
+	if discount.IsPositive() {
+		result.Adjustments = append(result.Adjustments, models.Adjustment{
+			ID:          p.PromotionID,
+			Description: describe(p.Description, fmt.Sprintf("%v%% off", p.Percent), p.Condition, cart),
+			Items:       paidItems(cart),
+			Amount:      discount,
+		})
+	}
+}
+
+// FixedOff discounts Amount from the cart subtotal once the condition is met, never going below 0.
+// Carts in another currency get Amount converted with their exchange rate.
+type FixedOff struct {
+	PromotionID string
+	Description string
+	Condition   Condition
+	Amount      money.Money
+}
+
+func (p FixedOff) ID() string {
+	return p.PromotionID
+}
+
+func (p FixedOff) Apply(cart models.Cart, result *Result) {
+	if !p.Condition.Matches(cart) {
+		return
+	}
+
+	amount := inCartCurrency(cart, p.Amount)
+	discount := money.Min(amount, result.Subtotal.Sub(result.Discount))
+	result.Discount = result.Discount.Add(discount)
+	if discount.IsPositive() {
+		result.Adjustments = append(result.Adjustments, models.Adjustment{
+			ID:          p.PromotionID,
+			Description: describe(p.Description, fmt.Sprintf("%v off", amount), p.Condition, cart),
+			Items:       paidItems(cart),
+			Amount:      discount,
+		})
+	}
+}
+
+// FreeShipping waives the shipping cost once the condition is met.
+type FreeShipping struct {
+	PromotionID string
+	Description string
+	Condition   Condition
+}
+
+func (p FreeShipping) ID() string {
+	return p.PromotionID
+}
+
+func (p FreeShipping) Apply(cart models.Cart, result *Result) {
+	if !p.Condition.Matches(cart) {
+		return
+	}
+
+	waived := result.Shipping
+	result.Shipping = money.Zero(result.Shipping.Currency)
+	if waived.IsPositive() {
+		result.Adjustments = append(result.Adjustments, models.Adjustment{
+			ID:          p.PromotionID,
+			Description: describe(p.Description, "Free shipping", p.Condition, cart),
+			Amount:      waived,
+			Shipping:    true,
+		})
+	}
+}
+
+// Defaults returns the promotions the shop runs out of the box:
+//   - buying 2 coffees gives an extra coffee for free
+//   - spending more than 70 in accessories gives a 10% discount on the whole cart
+//   - buying more than 3 equipment products gives free shipping
+func Defaults() []Promotion {
+	return []Promotion{
+		FreeItem{
+			PromotionID: ExtraCoffeeID,
+			Condition:   Condition{Category: models.CoffeeCategory, MinQuantity: 2},
+			Item: models.Product{
+				Name:     "extraCoffee",
+				Category: models.CoffeeCategory,
+				Price:    money.Zero(money.DefaultCurrency),
+			},
+		},
+		PercentOff{
+			PromotionID: AccessoriesDiscountID,
+			Condition:   Condition{Category: models.AccessoriesCategory, MinSubtotal: money.New(7001, money.DefaultCurrency)},
+			Percent:     10,
+			Rounding:    money.HalfUp,
+		},
+		FreeShipping{
+			PromotionID: EquipmentShippingID,
+			Condition:   Condition{Category: models.EquipmentCategory, MinQuantity: 4},
+		},
+	}
+}
@@ -0,0 +1,276 @@
+package promotions
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+)
+
+func usd(amount int64) money.Money {
+	return money.FromMajor(amount, money.USD)
+}
+
+func lineItems(category string, prices ...int64) []models.LineItem {
+	var items []models.LineItem
+	for i, price := range prices {
+		items = append(items, models.LineItem{
+			Product: models.Product{
+				Name:     category + string(rune('A'+i)),
+				Category: category,
+				Price:    usd(price),
+			},
+			Quantity: 1,
+		})
+	}
+
+	return items
+}
+
+var extraCoffee = models.LineItem{
+	Product:     models.Product{Name: "extraCoffee", Category: models.CoffeeCategory, Price: usd(0)},
+	Quantity:    1,
+	Free:        true,
+	PromotionID: ExtraCoffeeID,
+}
+
+func TestDefaults(t *testing.T) {
+	tests := []struct {
+		name             string
+		items            []models.LineItem
+		expectedFree     int
+		expectedDiscount int
+		expectedShipping int
+	}{
+		{
+			name:             "empty cart",
+			items:            nil,
+			expectedShipping: 20,
+		},
+		{
+			name:             "one coffee gets no extra coffee",
+			items:            lineItems(models.CoffeeCategory, 10),
+			expectedShipping: 20,
+		},
+		{
+			name:             "two coffees get an extra coffee",
+			items:            lineItems(models.CoffeeCategory, 10, 20),
+			expectedFree:     1,
+			expectedShipping: 20,
+		},
+		{
+			name:             "two units of the same coffee get an extra coffee",
+			items:            []models.LineItem{{Product: models.Product{Name: "coffee", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 2}},
+			expectedFree:     1,
+			expectedShipping: 20,
+		},
+		{
+			name:             "coffee samples priced at 0 count as coffees",
+			items:            lineItems(models.CoffeeCategory, 10, 0),
+			expectedFree:     1,
+			expectedShipping: 20,
+		},
+		{
+			name:             "extra coffee is kept while there are two coffees",
+			items:            append(lineItems(models.CoffeeCategory, 10, 20), extraCoffee),
+			expectedFree:     1,
+			expectedShipping: 20,
+		},
+		{
+			name:             "extra coffee doesn't count towards two coffees",
+			items:            append(lineItems(models.CoffeeCategory, 10), extraCoffee),
+			expectedShipping: 20,
+		},
+		{
+			name:             "accessories at 70 get no discount",
+			items:            lineItems(models.AccessoriesCategory, 30, 40),
+			expectedShipping: 20,
+		},
+		{
+			name:             "accessories at 70.00 get no discount",
+			items:            []models.LineItem{{Product: models.Product{Name: "mug", Category: models.AccessoriesCategory, Price: money.New(7000, money.USD)}, Quantity: 1}},
+			expectedShipping: 20,
+		},
+		{
+			name:             "accessories at 70.01 get 10% off",
+			items:            []models.LineItem{{Product: models.Product{Name: "mug", Category: models.AccessoriesCategory, Price: money.New(7001, money.USD)}, Quantity: 1}},
+			expectedDiscount: 7,
+			expectedShipping: 20,
+		},
+		{
+			name:             "accessories over 70 get 10% off the whole cart",
+			items:            append(lineItems(models.AccessoriesCategory, 80), lineItems(models.EquipmentCategory, 20)...),
+			expectedDiscount: 10,
+			expectedShipping: 20,
+		},
+		{
+			name:             "accessories subtotal counts every unit",
+			items:            []models.LineItem{{Product: models.Product{Name: "mug", Category: models.AccessoriesCategory, Price: usd(25)}, Quantity: 4}},
+			expectedDiscount: 10,
+			expectedShipping: 20,
+		},
+		{
+			name:             "three equipment pay shipping",
+			items:            lineItems(models.EquipmentCategory, 10, 10, 10),
+			expectedShipping: 20,
+		},
+		{
+			name:             "more than three equipment get free shipping",
+			items:            lineItems(models.EquipmentCategory, 10, 10, 10, 10),
+			expectedShipping: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			registry, err := NewRegistry(Defaults()...)
+			require.NoError(t, err)
+
+			// When
+			result := registry.Evaluate(models.Cart{Items: tt.items}, usd(20))
+
+			// Then
+			require.Equal(t, tt.expectedFree, len(result.FreeItems))
+			require.Equal(t, usd(int64(tt.expectedDiscount)), result.Discount)
+			require.Equal(t, usd(int64(tt.expectedShipping)), result.Shipping)
+		})
+	}
+}
+
+func TestDefaults_Cart_In_Another_Currency(t *testing.T) {
+	// Given a cart in EUR, where the 70.01 USD the accessories must add up to are 64.41 EUR
+	rate, err := money.ParseRate(money.USD, money.EUR, "0.92")
+	require.NoError(t, err)
+	registry, err := NewRegistry(Defaults()...)
+	require.NoError(t, err)
+	eur := func(amount int64) money.Money { return money.FromMajor(amount, money.EUR) }
+	freeCoffee := models.LineItem{
+		Product:     models.Product{Name: "extraCoffee", Category: models.CoffeeCategory, Price: money.Zero(money.EUR)},
+		Quantity:    1,
+		Free:        true,
+		PromotionID: ExtraCoffeeID,
+	}
+	cart := func(accessories int64) models.Cart {
+		return models.Cart{Currency: money.EUR, ExchangeRate: &rate, Items: []models.LineItem{
+			{Product: models.Product{Name: "mug", Category: models.AccessoriesCategory, Price: eur(accessories)}, Quantity: 1},
+			{Product: models.Product{Name: "coffee", Category: models.CoffeeCategory, Price: eur(10)}, Quantity: 2},
+			freeCoffee,
+		}}
+	}
+
+	// When
+	below := registry.Evaluate(cart(64), eur(18))
+	above := registry.Evaluate(cart(65), eur(18))
+
+	// Then
+	require.Equal(t, money.Zero(money.EUR), below.Discount)
+	require.Equal(t, money.New(850, money.EUR), above.Discount)
+	require.Equal(t, eur(18), above.Shipping)
+	require.Equal(t, []models.LineItem{freeCoffee}, above.FreeItems, "the free coffee is priced in EUR")
+}
+
+func TestDefaults_Adjustments(t *testing.T) {
+	// Given a cart that gets every default promotion
+	registry, err := NewRegistry(Defaults()...)
+	require.NoError(t, err)
+	items := append(lineItems(models.CoffeeCategory, 10, 20), extraCoffee)
+	items = append(items, lineItems(models.AccessoriesCategory, 80)...)
+	items = append(items, lineItems(models.EquipmentCategory, 10, 10, 10, 10)...)
+	paid := []string{"coffeeA", "coffeeB", "accessoriesA", "equipmentA", "equipmentB", "equipmentC", "equipmentD"}
+
+	// When
+	result := registry.Evaluate(models.Cart{Items: items}, usd(20))
+
+	// Then
+	require.Equal(t, []models.Adjustment{
+		{ID: ExtraCoffeeID, Description: "Free extraCoffee for at least 2 coffee products", Items: []string{"extraCoffee"}, Amount: usd(0)},
+		{ID: AccessoriesDiscountID, Description: "10% off for at least 70.01 USD of accessories products", Items: paid, Amount: usd(15)},
+		{ID: EquipmentShippingID, Description: "Free shipping for at least 4 equipment products", Amount: usd(20), Shipping: true},
+	}, result.Adjustments)
+	require.Equal(t, usd(15), result.Discount)
+	require.Equal(t, usd(0), result.Shipping)
+}
+
+func TestFixedOff_Adjustment(t *testing.T) {
+	tests := []struct {
+		name        string
+		promotion   FixedOff
+		expected    []models.Adjustment
+		expectedOff money.Money
+	}{
+		{
+			name:      "described by its effect",
+			promotion: FixedOff{PromotionID: "five-off", Condition: Condition{MinQuantity: 2}, Amount: usd(5)},
+			expected: []models.Adjustment{
+				{ID: "five-off", Description: "5.00 USD off for at least 2 products", Items: []string{"coffeeA", "coffeeB"}, Amount: usd(5)},
+			},
+			expectedOff: usd(5),
+		},
+		{
+			name:      "with its own description",
+			promotion: FixedOff{PromotionID: "five-off", Description: "Spring sale", Amount: usd(5)},
+			expected: []models.Adjustment{
+				{ID: "five-off", Description: "Spring sale", Items: []string{"coffeeA", "coffeeB"}, Amount: usd(5)},
+			},
+			expectedOff: usd(5),
+		},
+		{
+			name:        "not met",
+			promotion:   FixedOff{PromotionID: "five-off", Condition: Condition{MinQuantity: 4}, Amount: usd(5)},
+			expectedOff: usd(0),
+		},
+		{
+			name:      "never below 0",
+			promotion: FixedOff{PromotionID: "fifty-off", Amount: usd(50)},
+			expected: []models.Adjustment{
+				{ID: "fifty-off", Description: "50.00 USD off", Items: []string{"coffeeA", "coffeeB"}, Amount: usd(30)},
+			},
+			expectedOff: usd(30),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			cart := models.Cart{Items: append(lineItems(models.CoffeeCategory, 10, 20), extraCoffee)}
+			result := newResult(cart, usd(20))
+
+			// When
+			tt.promotion.Apply(cart, &result)
+
+			// Then
+			require.Equal(t, tt.expected, result.Adjustments)
+			require.Equal(t, tt.expectedOff, result.Discount)
+		})
+	}
+}
+
+func TestPercentOff_Rounding(t *testing.T) {
+	tests := []struct {
+		name     string
+		rounding money.RoundingMode
+		expected money.Money
+	}{
+		{name: "half up", rounding: money.HalfUp, expected: money.New(13, money.USD)},
+		{name: "half even", rounding: money.HalfEven, expected: money.New(12, money.USD)},
+		{name: "down", rounding: money.Down, expected: money.New(12, money.USD)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given a subtotal of 1.25, whose 10% is half a cent
+			cart := models.Cart{Items: []models.LineItem{
+				{Product: models.Product{Name: "mug", Category: models.AccessoriesCategory, Price: money.New(125, money.USD)}, Quantity: 1},
+			}}
+			promotion := PercentOff{PromotionID: "ten-off", Percent: 10, Rounding: tt.rounding}
+			result := newResult(cart, usd(20))
+
+			// When
+			promotion.Apply(cart, &result)
+
+			// Then
+			require.Equal(t, tt.expected, result.Discount)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package promotions
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+)
+
+const (
+	PercentOffEffect   = "percent_off"
+	FixedOffEffect     = "fixed_off"
+	FreeItemEffect     = "free_item"
+	FreeShippingEffect = "free_shipping"
+)
+
+// Definition describes a promotion in a config file so it can be created without code changes.
+type Definition struct {
+	ID        string    `json:"id" yaml:"id"`
+	Disabled  bool      `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	Condition Condition `json:"condition" yaml:"condition"`
+	Effect    Effect    `json:"effect" yaml:"effect"`
+}
+
+// Effect is what a promotion does. Percentages are rounded with Rounding, half_up by default.
+type Effect struct {
+	Type     string             `json:"type" yaml:"type"`
+	Percent  int                `json:"percent,omitempty" yaml:"percent,omitempty"`
+	Rounding money.RoundingMode `json:"rounding,omitempty" yaml:"rounding,omitempty"`
+	Amount   money.Money        `json:"amount,omitempty" yaml:"amount,omitempty"`
+	Item     *models.Product    `json:"item,omitempty" yaml:"item,omitempty"`
+}
+
+type definitionsFile struct {
+	Promotions []Definition `json:"promotions" yaml:"promotions"`
+}
+
+// LoadDefinitions reads and validates the promotions described in a JSON or YAML file.
+// The format is picked from the file extension.
+func LoadDefinitions(path string) ([]Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file definitionsFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&file)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&file)
+	default:
+		return nil, errors.New(fmt.Sprintf("unsupported promotions file format %v", filepath.Ext(path)))
+	}
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("parsing %v: %v", path, err))
+	}
+
+	if err := ValidateDefinitions(file.Promotions); err != nil {
+		return nil, errors.New(fmt.Sprintf("%v: %v", path, err))
+	}
+
+	return file.Promotions, nil
+}
+
+// ValidateDefinitions checks every definition, returning an error that points to the first invalid one.
+func ValidateDefinitions(definitions []Definition) error {
+	seen := make(map[string]bool)
+	for i, definition := range definitions {
+		if err := definition.Validate(); err != nil {
+			return errors.New(fmt.Sprintf("promotion #%d (id %q): %v", i+1, definition.ID, err))
+		}
+		if seen[definition.ID] {
+			return errors.New(fmt.Sprintf("promotion #%d (id %q): id is already used by another promotion", i+1, definition.ID))
+		}
+		seen[definition.ID] = true
+	}
+
+	return nil
+}
+
+func (d Definition) Validate() error {
+	if d.ID == "" {
+		return errors.New("id is required")
+	}
+	if d.Condition.Category != "" && !isValidCategory(d.Condition.Category) {
+		return errors.New(fmt.Sprintf("condition.category %q is not a valid category", d.Condition.Category))
+	}
+	if d.Condition.MinQuantity < 0 {
+		return errors.New("condition.min_quantity must not be negative")
+	}
+	if d.Condition.MinSubtotal.IsNegative() {
+		return errors.New("condition.min_subtotal must not be negative")
+	}
+	// Carts are evaluated in the catalog currency, amounts in any other one can't be compared with their totals
+	if d.Condition.MinSubtotal.Currency != "" && d.Condition.MinSubtotal.Currency != money.DefaultCurrency {
+		return errors.New(fmt.Sprintf("condition.min_subtotal must be in %v, the catalog currency", money.DefaultCurrency))
+	}
+
+	switch d.Effect.Type {
+	case PercentOffEffect:
+		if d.Effect.Percent <= 0 || d.Effect.Percent > 100 {
+			return errors.New("effect.percent must be between 1 and 100")
+		}
+	case FixedOffEffect:
+		if !d.Effect.Amount.IsPositive() {
+			return errors.New("effect.amount must be greater than 0")
+		}
+		if d.Effect.Amount.Currency != money.DefaultCurrency {
+			return errors.New(fmt.Sprintf("effect.amount must be in %v, the catalog currency", money.DefaultCurrency))
+		}
+	case FreeItemEffect:
+		if d.Effect.Item == nil {
+			return errors.New("effect.item is required")
+		}
+		if d.Effect.Item.Name == "" {
+			return errors.New("effect.item.name is required")
+		}
+		if !isValidCategory(d.Effect.Item.Category) {
+			return errors.New(fmt.Sprintf("effect.item.category %q is not a valid category", d.Effect.Item.Category))
+		}
+		if !d.Effect.Item.Price.IsZero() {
+			return errors.New("effect.item.price must be 0")
+		}
+	case FreeShippingEffect:
+	case "":
+		return errors.New("effect.type is required")
+	default:
+		return errors.New(fmt.Sprintf("effect.type %q is not supported", d.Effect.Type))
+	}
+
+	return nil
+}
+
+// Promotion builds the promotion described by the definition. The definition must be valid.
+func (d Definition) Promotion() Promotion {
+	switch d.Effect.Type {
+	case PercentOffEffect:
+		return PercentOff{PromotionID: d.ID, Condition: d.Condition, Percent: d.Effect.Percent, Rounding: d.Effect.Rounding}
+	case FixedOffEffect:
+		return FixedOff{PromotionID: d.ID, Condition: d.Condition, Amount: d.Effect.Amount}
+	case FreeItemEffect:
+		return FreeItem{PromotionID: d.ID, Condition: d.Condition, Item: *d.Effect.Item}
+	default:
+		return FreeShipping{PromotionID: d.ID, Condition: d.Condition}
+	}
+}
+
+func isValidCategory(category string) bool {
+	return category == models.CoffeeCategory || category == models.EquipmentCategory || category == models.AccessoriesCategory
+}
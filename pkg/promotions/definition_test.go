@@ -0,0 +1,155 @@
+package promotions
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+)
+
+func TestLoadDefinitions_Sample_Config(t *testing.T) {
+	// Given
+	path := filepath.Join("..", "..", "config", "promotions.yaml")
+
+	// When
+	definitions, err := LoadDefinitions(path)
+
+	// Then
+	require.NoError(t, err)
+	promos := make([]Promotion, 0, len(definitions))
+	for _, definition := range definitions {
+		promos = append(promos, definition.Promotion())
+	}
+	require.Equal(t, Defaults(), promos)
+}
+
+func TestLoadDefinitions_JSON(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "promotions.json")
+	content := `{"promotions": [{"id": "five-off", "condition": {"min_subtotal": 50}, "effect": {"type": "fixed_off", "amount": 5}}]}`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	// When
+	definitions, err := LoadDefinitions(path)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, FixedOff{PromotionID: "five-off", Condition: Condition{MinSubtotal: usd(50)}, Amount: usd(5)}, definitions[0].Promotion())
+}
+
+func TestLoadDefinitions_Money_And_Rounding(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "promotions.yaml")
+	content := "promotions:\n  - id: eight-off\n    condition:\n      min_subtotal:\n        amount: \"70.50\"\n        currency: USD\n" +
+		"    effect:\n      type: percent_off\n      percent: 8\n      rounding: half_even\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	// When
+	definitions, err := LoadDefinitions(path)
+
+	// Then
+	require.NoError(t, err)
+	expected := PercentOff{
+		PromotionID: "eight-off",
+		Condition:   Condition{MinSubtotal: money.New(7050, money.USD)},
+		Percent:     8,
+		Rounding:    money.HalfEven,
+	}
+	require.Equal(t, expected, definitions[0].Promotion())
+}
+
+func TestLoadDefinitions_Unknown_Field(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "promotions.yaml")
+	content := "promotions:\n  - id: typo\n    efect:\n      type: free_shipping\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	// When
+	_, err := LoadDefinitions(path)
+
+	// Then
+	require.Error(t, err)
+}
+
+func TestValidateDefinitions(t *testing.T) {
+	freeItem := &models.Product{Name: "gift", Category: models.AccessoriesCategory}
+
+	tests := []struct {
+		name          string
+		definitions   []Definition
+		expectedError string
+	}{
+		{
+			name: "valid definitions",
+			definitions: []Definition{
+				{ID: "a", Effect: Effect{Type: FreeShippingEffect}},
+				{ID: "b", Effect: Effect{Type: FreeItemEffect, Item: freeItem}},
+			},
+		},
+		{
+			name:          "missing id",
+			definitions:   []Definition{{Effect: Effect{Type: FreeShippingEffect}}},
+			expectedError: `promotion #1 (id ""): id is required`,
+		},
+		{
+			name: "repeated id",
+			definitions: []Definition{
+				{ID: "a", Effect: Effect{Type: FreeShippingEffect}},
+				{ID: "a", Effect: Effect{Type: FreeShippingEffect}},
+			},
+			expectedError: `promotion #2 (id "a"): id is already used by another promotion`,
+		},
+		{
+			name:          "invalid category",
+			definitions:   []Definition{{ID: "a", Condition: Condition{Category: "tea"}, Effect: Effect{Type: FreeShippingEffect}}},
+			expectedError: `promotion #1 (id "a"): condition.category "tea" is not a valid category`,
+		},
+		{
+			name:          "percent out of range",
+			definitions:   []Definition{{ID: "a", Effect: Effect{Type: PercentOffEffect, Percent: 150}}},
+			expectedError: `promotion #1 (id "a"): effect.percent must be between 1 and 100`,
+		},
+		{
+			name:          "fixed amount missing",
+			definitions:   []Definition{{ID: "a", Effect: Effect{Type: FixedOffEffect}}},
+			expectedError: `promotion #1 (id "a"): effect.amount must be greater than 0`,
+		},
+		{
+			name: "min subtotal in another currency",
+			definitions: []Definition{{ID: "a", Condition: Condition{MinSubtotal: money.FromMajor(10, money.EUR)},
+				Effect: Effect{Type: FreeShippingEffect}}},
+			expectedError: `promotion #1 (id "a"): condition.min_subtotal must be in USD, the catalog currency`,
+		},
+		{
+			name:          "fixed amount in another currency",
+			definitions:   []Definition{{ID: "a", Effect: Effect{Type: FixedOffEffect, Amount: money.FromMajor(5, money.EUR)}}},
+			expectedError: `promotion #1 (id "a"): effect.amount must be in USD, the catalog currency`,
+		},
+		{
+			name:          "free item missing",
+			definitions:   []Definition{{ID: "a", Effect: Effect{Type: FreeItemEffect}}},
+			expectedError: `promotion #1 (id "a"): effect.item is required`,
+		},
+		{
+			name:          "unknown effect",
+			definitions:   []Definition{{ID: "a", Effect: Effect{Type: "buy_one_get_two"}}},
+			expectedError: `promotion #1 (id "a"): effect.type "buy_one_get_two" is not supported`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			err := ValidateDefinitions(tt.definitions)
+
+			// Then
+			if tt.expectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.expectedError)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package promotions
+
+import (
+	"fmt"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+)
+
+// Promotion is a single rule evaluated against a cart. Promotions are applied in the
+// order they are registered, so a promotion can rely on the changes made by the previous ones.
+type Promotion interface {
+	ID() string
+	Apply(cart models.Cart, result *Result)
+}
+
+// Result holds the outcome of evaluating the enabled promotions against a cart.
+// FreeItems are the free line items the cart qualifies for, which replace the ones it has.
+// Adjustments itemize what every promotion took off the Discount and the Shipping.
+type Result struct {
+	Subtotal    money.Money
+	Discount    money.Money
+	Shipping    money.Money
+	FreeItems   []models.LineItem
+	Adjustments []models.Adjustment
+}
+
+// Apply applies the promotion to the result of the cart. Whatever the promotion took off without recording an
+// adjustment for it is recorded here, so the adjustments of a result always add up to its discount and to the
+// shipping it waived.
+func Apply(promotion Promotion, cart models.Cart, result *Result) {
+	discount, shipping, recorded := result.Discount, result.Shipping, len(result.Adjustments)
+	promotion.Apply(cart, result)
+
+	for _, adjustment := range result.Adjustments[recorded:] {
+		if adjustment.Shipping {
+			shipping = shipping.Sub(adjustment.Amount)
+		} else {
+			discount = discount.Add(adjustment.Amount)
+		}
+	}
+
+	description := fmt.Sprintf("Promotion %v", promotion.ID())
+	if unrecorded := result.Discount.Sub(discount); !unrecorded.IsZero() {
+		result.Adjustments = append(result.Adjustments, models.Adjustment{
+			ID: promotion.ID(), Description: description, Items: paidItems(cart), Amount: unrecorded,
+		})
+	}
+	if unrecorded := shipping.Sub(result.Shipping); !unrecorded.IsZero() {
+		result.Adjustments = append(result.Adjustments, models.Adjustment{
+			ID: promotion.ID(), Description: description, Amount: unrecorded, Shipping: true,
+		})
+	}
+}
+
+func newResult(cart models.Cart, shipping money.Money) Result {
+	result := Result{Subtotal: money.Zero(shipping.Currency), Discount: money.Zero(shipping.Currency), Shipping: shipping}
+	for _, item := range cart.Items {
+		result.Subtotal = result.Subtotal.Add(item.Product.Price.Mul(item.Quantity))
+	}
+
+	return result
+}
+
+func countByCategory(cart models.Cart, category string) int {
+	count := 0
+	for _, item := range cart.Items {
+		if !item.Free && (category == "" || item.Product.Category == category) {
+			count += item.Quantity
+		}
+	}
+
+	return count
+}
+
+func subtotalByCategory(cart models.Cart, category string) money.Money {
+	subtotal := money.Money{}
+	for _, item := range cart.Items {
+		if !item.Free && (category == "" || item.Product.Category == category) {
+			subtotal = subtotal.Add(item.Product.Price.Mul(item.Quantity))
+		}
+	}
+
+	return subtotal
+}
+
+// paidItems returns the names of the items of the cart that aren't free, which a discount on the cart is split among.
+func paidItems(cart models.Cart) []string {
+	var names []string
+	for _, item := range cart.Items {
+		if !item.Free && item.Product.Price.IsPositive() {
+			names = append(names, item.Product.Name)
+		}
+	}
+
+	return names
+}
+
+// inCartCurrency converts an amount of the catalog currency, such as a minimum subtotal, into the currency of the cart
+// with the exchange rate the cart was created with. Amounts of the cart currency are returned as they are.
+func inCartCurrency(cart models.Cart, amount money.Money) money.Money {
+	if cart.ExchangeRate == nil || amount.Currency != cart.ExchangeRate.From {
+		return amount
+	}
+
+	return cart.ExchangeRate.Convert(amount, money.HalfUp)
+}
@@ -0,0 +1,155 @@
+package promotions
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+)
+
+// Registry keeps an ordered list of promotions that can be enabled, disabled and reordered at runtime.
+type Registry interface {
+	Register(promotion Promotion) error
+	Enable(id string) error
+	Disable(id string) error
+	Reorder(ids []string) error
+	Replace(definitions []Definition) error
+	Promotions() []Promotion
+	Evaluate(cart models.Cart, shipping money.Money) Result
+}
+
+type entry struct {
+	promotion Promotion
+	enabled   bool
+}
+
+type registry struct {
+	mu      sync.RWMutex
+	entries []entry
+}
+
+func NewRegistry(promotions ...Promotion) (Registry, error) {
+	r := &registry{}
+	for _, promotion := range promotions {
+		if err := r.Register(promotion); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+func (r *registry) Register(promotion Promotion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.indexOf(promotion.ID()) >= 0 {
+		return errors.New(fmt.Sprintf("promotion %v is already registered", promotion.ID()))
+	}
+
+	r.entries = append(r.entries, entry{promotion: promotion, enabled: true})
+	return nil
+}
+
+func (r *registry) Enable(id string) error {
+	return r.setEnabled(id, true)
+}
+
+func (r *registry) Disable(id string) error {
+	return r.setEnabled(id, false)
+}
+
+func (r *registry) Reorder(ids []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(ids) != len(r.entries) {
+		return errors.New("reorder must include every registered promotion")
+	}
+
+	reordered := make([]entry, 0, len(ids))
+	for _, id := range ids {
+		i := r.indexOf(id)
+		if i < 0 {
+			return errors.New(fmt.Sprintf("promotion %v is not registered", id))
+		}
+		for _, e := range reordered {
+			if e.promotion.ID() == id {
+				return errors.New(fmt.Sprintf("promotion %v is listed more than once", id))
+			}
+		}
+		reordered = append(reordered, r.entries[i])
+	}
+
+	r.entries = reordered
+	return nil
+}
+
+// Replace swaps every registered promotion by the ones described in the definitions, keeping their order.
+// Nothing is changed if any definition is invalid.
+func (r *registry) Replace(definitions []Definition) error {
+	if err := ValidateDefinitions(definitions); err != nil {
+		return err
+	}
+
+	entries := make([]entry, 0, len(definitions))
+	for _, definition := range definitions {
+		entries = append(entries, entry{promotion: definition.Promotion(), enabled: !definition.Disabled})
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = entries
+	return nil
+}
+
+func (r *registry) Promotions() []Promotion {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	promotions := make([]Promotion, 0, len(r.entries))
+	for _, e := range r.entries {
+		promotions = append(promotions, e.promotion)
+	}
+
+	return promotions
+}
+
+func (r *registry) Evaluate(cart models.Cart, shipping money.Money) Result {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := newResult(cart, shipping)
+	for _, e := range r.entries {
+		if e.enabled {
+			Apply(e.promotion, cart, &result)
+		}
+	}
+
+	return result
+}
+
+func (r *registry) setEnabled(id string, enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(id)
+	if i < 0 {
+		return errors.New(fmt.Sprintf("promotion %v is not registered", id))
+	}
+
+	r.entries[i].enabled = enabled
+	return nil
+}
+
+func (r *registry) indexOf(id string) int {
+	for i, e := range r.entries {
+		if e.promotion.ID() == id {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,143 @@
+package promotions
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+)
+
+// halfOff takes half the subtotal and the shipping off without recording any adjustment, as promotions written
+// before adjustments did.
+type halfOff struct{}
+
+func (halfOff) ID() string {
+	return "half-off"
+}
+
+func (halfOff) Apply(_ models.Cart, result *Result) {
+	result.Discount = result.Discount.Add(result.Subtotal.Percent(50, money.HalfUp))
+	result.Shipping = result.Shipping.Percent(50, money.HalfUp)
+}
+
+func TestRegistry_Register_Duplicated(t *testing.T) {
+	// Given
+	registry, err := NewRegistry(Defaults()...)
+	require.NoError(t, err)
+
+	// When
+	err = registry.Register(FreeShipping{PromotionID: EquipmentShippingID})
+
+	// Then
+	require.Error(t, err)
+	require.Equal(t, 3, len(registry.Promotions()))
+}
+
+func TestRegistry_Disable_And_Enable(t *testing.T) {
+	// Given
+	registry, err := NewRegistry(Defaults()...)
+	require.NoError(t, err)
+	cart := models.Cart{Items: lineItems(models.EquipmentCategory, 10, 10, 10, 10)}
+
+	// When
+	require.NoError(t, registry.Disable(EquipmentShippingID))
+	disabled := registry.Evaluate(cart, usd(20))
+	require.NoError(t, registry.Enable(EquipmentShippingID))
+	enabled := registry.Evaluate(cart, usd(20))
+
+	// Then
+	require.Equal(t, usd(20), disabled.Shipping)
+	require.Equal(t, usd(0), enabled.Shipping)
+	require.Error(t, registry.Disable("unknown"))
+}
+
+func TestRegistry_Reorder(t *testing.T) {
+	// Given
+	registry, err := NewRegistry(
+		PercentOff{PromotionID: "ten", Percent: 10},
+		PercentOff{PromotionID: "fifty", Percent: 50},
+	)
+	require.NoError(t, err)
+
+	// When
+	err = registry.Reorder([]string{"fifty", "ten"})
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "fifty", registry.Promotions()[0].ID())
+	require.Equal(t, "ten", registry.Promotions()[1].ID())
+}
+
+func TestRegistry_Reorder_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		ids  []string
+	}{
+		{name: "missing promotion", ids: []string{ExtraCoffeeID, AccessoriesDiscountID}},
+		{name: "unknown promotion", ids: []string{ExtraCoffeeID, AccessoriesDiscountID, "unknown"}},
+		{name: "repeated promotion", ids: []string{ExtraCoffeeID, ExtraCoffeeID, EquipmentShippingID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			registry, err := NewRegistry(Defaults()...)
+			require.NoError(t, err)
+
+			// When
+			err = registry.Reorder(tt.ids)
+
+			// Then
+			require.Error(t, err)
+			require.Equal(t, ExtraCoffeeID, registry.Promotions()[0].ID())
+		})
+	}
+}
+
+func TestRegistry_Replace(t *testing.T) {
+	// Given
+	registry, err := NewRegistry(Defaults()...)
+	require.NoError(t, err)
+
+	// When
+	err = registry.Replace([]Definition{
+		{ID: "free-shipping", Effect: Effect{Type: FreeShippingEffect}},
+		{ID: "five-off", Disabled: true, Effect: Effect{Type: FixedOffEffect, Amount: usd(5)}},
+	})
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 2, len(registry.Promotions()))
+	result := registry.Evaluate(models.Cart{Items: lineItems(models.CoffeeCategory, 10)}, usd(20))
+	require.Equal(t, usd(0), result.Shipping)
+	require.Equal(t, usd(0), result.Discount)
+}
+
+func TestRegistry_Replace_Invalid_Keeps_Promotions(t *testing.T) {
+	// Given
+	registry, err := NewRegistry(Defaults()...)
+	require.NoError(t, err)
+
+	// When
+	err = registry.Replace([]Definition{{ID: "broken"}})
+
+	// Then
+	require.Error(t, err)
+	require.Equal(t, Defaults(), registry.Promotions())
+}
+
+func TestRegistry_Evaluate_Records_Adjustments_Promotions_Left_Out(t *testing.T) {
+	// Given
+	registry, err := NewRegistry(halfOff{})
+	require.NoError(t, err)
+	cart := models.Cart{Items: append(lineItems(models.CoffeeCategory, 10), extraCoffee)}
+
+	// When
+	result := registry.Evaluate(cart, usd(20))
+
+	// Then
+	require.Equal(t, []models.Adjustment{
+		{ID: "half-off", Description: "Promotion half-off", Items: []string{"coffeeA"}, Amount: usd(5)},
+		{ID: "half-off", Description: "Promotion half-off", Amount: usd(10), Shipping: true},
+	}, result.Adjustments)
+}
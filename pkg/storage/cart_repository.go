@@ -2,101 +2,557 @@ package storage
 
 import (
 	"errors"
-	"fmt"
+	"sort"
+	"sync"
 	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
 )
 
 type CartRepository interface {
-	CreateCart(userID string, cart models.Cart) models.Cart
-	AddProduct(cartID string, product models.Product) (models.Cart, error)
+	// CreateCart stores a new cart, next to the other open carts its user may have.
+	CreateCart(userID string, cart models.Cart) (models.Cart, error)
+	AddProduct(cartID string, product models.Product, quantity int) (models.Cart, error)
 	UpdateProductQuantity(cartID, product string, quantity int) (models.Cart, error)
+	RemoveProduct(cartID, product string) (models.Cart, error)
+	// SetFreeItems replaces the free line items of an open cart, which come after the products the user added.
+	SetFreeItems(cartID string, items []models.LineItem) (models.Cart, error)
+	// UpdateCheckout sets the fields of the checkout of an open cart that aren't empty, leaving the rest as they are.
+	UpdateCheckout(cartID string, checkout models.Checkout) (models.Cart, error)
+	// AddCoupon applies the coupon with the code to an open cart, unless it already was.
+	AddCoupon(cartID, code string) (models.Cart, error)
+	RemoveCoupon(cartID, code string) (models.Cart, error)
+	CheckoutCart(cartID string) (models.Cart, error)
+	// ReopenCart undoes the checkout of a cart whose order couldn't be placed.
+	ReopenCart(cartID string) (models.Cart, error)
+	// RenameCart changes the name of an open cart.
+	RenameCart(cartID, name string) (models.Cart, error)
+	// DeleteCart deletes an open cart. Checked out carts are kept along with their orders.
+	DeleteCart(cartID string) error
 	GetCartByID(cartID string) (models.Cart, error)
+	// GetCartByUserID returns the open cart the user created last.
+	GetCartByUserID(userID string) (models.Cart, error)
+	// GetCartsByUserID returns the open carts of the user, in the order they were created.
+	GetCartsByUserID(userID string) ([]models.Cart, error)
+	// GetCartByGuestToken returns the open guest cart with the token.
+	GetCartByGuestToken(token string) (models.Cart, error)
+	// MergeCart merges the open guest cart into the open cart the user created last with merge, stores the merged
+	// cart and deletes the guest cart. Both carts are read, merged and stored as one change, so changes made to them
+	// meanwhile aren't lost. The merged cart may be the guest cart itself, handed over to its user.
+	MergeCart(guestCartID, userID string, merge MergeFunc) (models.Cart, error)
 }
 
+// MergeFunc returns the cart of the user with the guest cart merged into it. userCart is empty when the user doesn't
+// have an open cart.
+type MergeFunc func(userCart, guestCart models.Cart) (models.Cart, error)
+
+// cartRepo is safe for concurrent use. mu guards the maps, while changes to a cart are serialized by a lock per
+// cart so concurrent requests on the same cart don't lose each other's products.
+//
+// Carts are stored by ID, users maps every user ID to the IDs of their open carts, in the order they were created,
+// and guests every guest token to the ID of its cart, so carts can be looked up by any of them without going
+// through every cart.
 type cartRepo struct {
-	repo map[string]models.Cart
+	mu        sync.RWMutex
+	repo      map[string]models.Cart
+	users     map[string][]string
+	guests    map[string]string
+	cartLocks sync.Map
 }
 
+// NewCartRepo returns a repository holding the given carts, whatever they're keyed by.
 func NewCartRepo(repo map[string]models.Cart) CartRepository {
-	return &cartRepo{
+	return newCartRepo(repo)
+}
+
+func newCartRepo(repo map[string]models.Cart) *cartRepo {
+	c := &cartRepo{
 		repo: repo,
 	}
+	c.reindex()
+
+	return c
 }
 
 func (c *cartRepo) GetCartByID(cartID string) (models.Cart, error) {
-	var cartToReturn models.Cart
-	var found bool
-	for _, cart := range c.repo {
-		if cart.ID == cartID {
-			cartToReturn = cart
-			found = true
-		}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.findCart(cartID)
+}
+
+func (c *cartRepo) GetCartByUserID(userID string) (models.Cart, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cartIDs := c.users[userID]
+	if len(cartIDs) == 0 {
+		return models.Cart{}, userCartNotFound(userID)
+	}
+
+	return cloneCart(c.repo[cartIDs[len(cartIDs)-1]]), nil
+}
+
+func (c *cartRepo) GetCartsByUserID(userID string) ([]models.Cart, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	carts := make([]models.Cart, 0, len(c.users[userID]))
+	for _, cartID := range c.users[userID] {
+		carts = append(carts, cloneCart(c.repo[cartID]))
 	}
 
-	if !found {
-		return models.Cart{}, errors.New(fmt.Sprintf("cart with ID %v doesn't exist", cartID))
+	return carts, nil
+}
+
+func (c *cartRepo) GetCartByGuestToken(token string) (models.Cart, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cartID, ok := c.guests[token]
+	if !ok {
+		return models.Cart{}, guestCartNotFound()
 	}
 
-	return cartToReturn, nil
+	return cloneCart(c.repo[cartID]), nil
 }
 
 func (c *cartRepo) UpdateProductQuantity(cartID, product string, quantity int) (models.Cart, error) {
-	existingCart, err := c.GetCartByID(cartID)
+	unlock := c.lockCart(cartID)
+	defer unlock()
+
+	existingCart, err := c.getOpenCart(cartID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	i := findProductInCart(existingCart, product)
+	if i < 0 {
+		return models.Cart{}, productNotInCart(product)
+	}
+
+	if quantity == 0 {
+		existingCart.Items = append(existingCart.Items[:i], existingCart.Items[i+1:]...)
+	} else {
+		existingCart.Items[i].Quantity = quantity
+	}
+
+	return c.save(existingCart), nil
+}
+
+func (c *cartRepo) CreateCart(userID string, cartToCreate models.Cart) (models.Cart, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.put(cloneCart(cartToCreate))
+	return cloneCart(cartToCreate), nil
+}
+
+func (c *cartRepo) AddProduct(cartID string, product models.Product, quantity int) (models.Cart, error) {
+	unlock := c.lockCart(cartID)
+	defer unlock()
+
+	userCart, err := c.getOpenCart(cartID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	if i := findProductInCart(userCart, product.Name); i >= 0 {
+		userCart.Items[i].Quantity += quantity
+	} else {
+		// Free line items stay after the products the user added
+		free := freeItems(userCart)
+		userCart.Items = append(userCart.Items, models.LineItem{Product: product, Quantity: quantity})
+		userCart = withFreeItems(userCart, free)
+	}
+
+	return c.save(userCart), nil
+}
+
+func (c *cartRepo) RemoveProduct(cartID, product string) (models.Cart, error) {
+	unlock := c.lockCart(cartID)
+	defer unlock()
+
+	userCart, err := c.getOpenCart(cartID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	i := findProductInCart(userCart, product)
+	if i < 0 {
+		return models.Cart{}, productNotInCart(product)
+	}
+
+	userCart.Items = append(userCart.Items[:i], userCart.Items[i+1:]...)
+	return c.save(userCart), nil
+}
+
+func (c *cartRepo) SetFreeItems(cartID string, items []models.LineItem) (models.Cart, error) {
+	unlock := c.lockCart(cartID)
+	defer unlock()
+
+	userCart, err := c.getOpenCart(cartID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	return c.save(withFreeItems(userCart, items)), nil
+}
+
+func (c *cartRepo) UpdateCheckout(cartID string, checkout models.Checkout) (models.Cart, error) {
+	unlock := c.lockCart(cartID)
+	defer unlock()
+
+	userCart, err := c.getOpenCart(cartID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	userCart.Checkout = mergeCheckout(userCart.Checkout, checkout)
+	return c.save(userCart), nil
+}
+
+func (c *cartRepo) AddCoupon(cartID, code string) (models.Cart, error) {
+	unlock := c.lockCart(cartID)
+	defer unlock()
+
+	userCart, err := c.getOpenCart(cartID)
 	if err != nil {
-		return models.Cart{}, errors.New(err.Error())
+		return models.Cart{}, err
 	}
 
-	productInCart := findProductInCart(existingCart, product)
-	if productInCart == nil {
-		return models.Cart{}, errors.New(fmt.Sprintf("product %v does not exist in cart", product))
+	if findCoupon(userCart, code) < 0 {
+		userCart.Coupons = append(userCart.Coupons, code)
+	}
+
+	return c.save(userCart), nil
+}
+
+func (c *cartRepo) RemoveCoupon(cartID, code string) (models.Cart, error) {
+	unlock := c.lockCart(cartID)
+	defer unlock()
+
+	userCart, err := c.getOpenCart(cartID)
+	if err != nil {
+		return models.Cart{}, err
 	}
 
-	for i := 1; i < quantity; i++ {
-		_, err := c.AddProduct(cartID, *productInCart)
-		if err != nil {
+	i := findCoupon(userCart, code)
+	if i < 0 {
+		return models.Cart{}, couponNotInCart(code)
+	}
+
+	userCart.Coupons = append(userCart.Coupons[:i], userCart.Coupons[i+1:]...)
+	if len(userCart.Coupons) == 0 {
+		userCart.Coupons = nil
+	}
+
+	return c.save(userCart), nil
+}
+
+func (c *cartRepo) CheckoutCart(cartID string) (models.Cart, error) {
+	unlock := c.lockCart(cartID)
+	defer unlock()
+
+	userCart, err := c.getOpenCart(cartID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	userCart.CheckedOut = true
+	return c.save(userCart), nil
+}
+
+func (c *cartRepo) ReopenCart(cartID string) (models.Cart, error) {
+	unlock := c.lockCart(cartID)
+	defer unlock()
+
+	userCart, err := c.GetCartByID(cartID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	userCart.CheckedOut = false
+	return c.save(userCart), nil
+}
+
+func (c *cartRepo) RenameCart(cartID, name string) (models.Cart, error) {
+	unlock := c.lockCart(cartID)
+	defer unlock()
+
+	userCart, err := c.getOpenCart(cartID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	userCart.Name = name
+	return c.save(userCart), nil
+}
+
+func (c *cartRepo) DeleteCart(cartID string) error {
+	unlock := c.lockCart(cartID)
+	defer unlock()
+
+	if _, err := c.getOpenCart(cartID); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.remove(cartID)
+	c.cartLocks.Delete(cartID)
+	return nil
+}
+
+func (c *cartRepo) MergeCart(guestCartID, userID string, merge MergeFunc) (models.Cart, error) {
+	unlock := c.lockCart(guestCartID)
+	defer unlock()
+
+	guestCart, err := c.getOpenCart(guestCartID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	userCart, err := c.GetCartByUserID(userID)
+	if err == nil {
+		unlockUserCart := c.lockCart(userCart.ID)
+		defer unlockUserCart()
+
+		// Read it again now that it's locked, it may have changed meanwhile
+		if userCart, err = c.getOpenCart(userCart.ID); err != nil {
 			return models.Cart{}, err
 		}
+	} else if !errors.Is(err, ErrCartNotFound) {
+		return models.Cart{}, err
 	}
 
-	return c.repo[existingCart.UserID], nil
+	merged, err := merge(userCart, guestCart)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.remove(guestCartID)
+	if merged.ID != guestCartID {
+		c.cartLocks.Delete(guestCartID)
+	}
+	c.put(cloneCart(merged))
+	return cloneCart(merged), nil
 }
 
-func (c *cartRepo) CreateCart(userID string, cartToCreate models.Cart) models.Cart {
-	existingCart, ok := c.repo[userID]
-	if ok {
-		return existingCart
+// getOpenCart returns the cart as long as it can still be changed.
+func (c *cartRepo) getOpenCart(cartID string) (models.Cart, error) {
+	userCart, err := c.GetCartByID(cartID)
+	if err != nil {
+		return models.Cart{}, err
 	}
 
-	c.repo[userID] = cartToCreate
-	return c.repo[userID]
+	if userCart.CheckedOut {
+		return models.Cart{}, cartCheckedOut(cartID)
+	}
+
+	return userCart, nil
+}
+
+// lockCart acquires the lock of the cart and returns the function that releases it. The lock is dropped along with
+// the cart, whoever was waiting for it then finds the cart gone.
+func (c *cartRepo) lockCart(cartID string) func() {
+	lock, _ := c.cartLocks.LoadOrStore(cartID, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	return lock.(*sync.Mutex).Unlock
 }
 
-func (c *cartRepo) AddProduct(cartID string, product models.Product) (models.Cart, error) {
-	var userCart models.Cart
-	var found bool
-	for _, cart := range c.repo {
-		if cart.ID == cartID {
-			userCart = cart
-			found = true
+// findCart returns a copy of the cart, so callers can change it without affecting the stored one.
+// The caller must hold mu.
+func (c *cartRepo) findCart(cartID string) (models.Cart, error) {
+	cart, ok := c.repo[cartID]
+	if !ok {
+		return models.Cart{}, cartNotFound(cartID)
+	}
+
+	return cloneCart(cart), nil
+}
+
+// save replaces a stored cart.
+func (c *cartRepo) save(cart models.Cart) models.Cart {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.put(cart)
+	return cloneCart(cart)
+}
+
+// put stores the cart, replacing the previous version of it, and indexes it by its user or guest token while it's
+// open. The caller must hold mu.
+func (c *cartRepo) put(cart models.Cart) {
+	if previous, ok := c.repo[cart.ID]; ok {
+		c.unindex(previous)
+	}
+
+	c.repo[cart.ID] = cart
+	switch {
+	case cart.CheckedOut:
+	case cart.UserID != "":
+		c.users[cart.UserID] = c.sortCarts(append(c.users[cart.UserID], cart.ID))
+	case cart.GuestToken != "":
+		c.guests[cart.GuestToken] = cart.ID
+	}
+}
+
+// remove deletes the cart along with its index entries. The caller must hold mu.
+func (c *cartRepo) remove(cartID string) {
+	if cart, ok := c.repo[cartID]; ok {
+		c.unindex(cart)
+		delete(c.repo, cartID)
+	}
+}
+
+// unindex drops the index entries pointing to the cart. The caller must hold mu.
+func (c *cartRepo) unindex(cart models.Cart) {
+	cartIDs := c.users[cart.UserID]
+	for i, cartID := range cartIDs {
+		if cartID == cart.ID {
+			cartIDs = append(cartIDs[:i], cartIDs[i+1:]...)
+			break
 		}
 	}
+	if len(cartIDs) == 0 {
+		delete(c.users, cart.UserID)
+	} else {
+		c.users[cart.UserID] = cartIDs
+	}
+	if c.guests[cart.GuestToken] == cart.ID {
+		delete(c.guests, cart.GuestToken)
+	}
+}
 
-	if !found {
-		return models.Cart{}, errors.New(fmt.Sprintf("cart with ID %v doesn't exist", cartID))
+// reindex keys the stored carts by their ID, since older snapshots keyed them by user ID, and rebuilds the indexes
+// of the open carts. The caller must hold mu or own the repository.
+func (c *cartRepo) reindex() {
+	carts := c.repo
+	c.repo = make(map[string]models.Cart, len(carts))
+	c.users = make(map[string][]string, len(carts))
+	c.guests = make(map[string]string)
+	for _, cart := range carts {
+		c.put(cart)
 	}
+}
+
+// sortCarts sorts the IDs of stored carts in the order the carts were created. The caller must hold mu.
+func (c *cartRepo) sortCarts(cartIDs []string) []string {
+	sort.Slice(cartIDs, func(i, j int) bool {
+		first, second := c.repo[cartIDs[i]], c.repo[cartIDs[j]]
+		if first.CreatedAt.Equal(second.CreatedAt) {
+			return cartIDs[i] < cartIDs[j]
+		}
+		return first.CreatedAt.Before(second.CreatedAt)
+	})
+
+	return cartIDs
+}
+
+func cloneCart(cart models.Cart) models.Cart {
+	if cart.Items != nil {
+		cart.Items = append([]models.LineItem{}, cart.Items...)
+	}
+
+	cart.ExchangeRate = cloneRate(cart.ExchangeRate)
+	cart.Checkout = cloneCheckout(cart.Checkout)
+	if cart.Coupons != nil {
+		cart.Coupons = append([]string{}, cart.Coupons...)
+	}
+
+	return cart
+}
+
+func cloneCheckout(checkout models.Checkout) models.Checkout {
+	checkout.ShippingAddress = cloneAddress(checkout.ShippingAddress)
+	checkout.BillingAddress = cloneAddress(checkout.BillingAddress)
+	return checkout
+}
+
+func cloneAddress(address *models.Address) *models.Address {
+	if address == nil {
+		return nil
+	}
+
+	clone := *address
+	return &clone
+}
+
+// mergeCheckout returns the checkout with the fields of the update that aren't empty.
+func mergeCheckout(checkout, update models.Checkout) models.Checkout {
+	if update.Email != "" {
+		checkout.Email = update.Email
+	}
+	if update.ShippingAddress != nil {
+		checkout.ShippingAddress = update.ShippingAddress
+	}
+	if update.BillingAddress != nil {
+		checkout.BillingAddress = update.BillingAddress
+	}
+	if update.DeliveryMethod != "" {
+		checkout.DeliveryMethod = update.DeliveryMethod
+	}
+
+	return cloneCheckout(checkout)
+}
 
-	userCart.Products = append(userCart.Products, product)
-	c.repo[userCart.UserID] = userCart
-	return c.repo[userCart.UserID], nil
+func cloneRate(rate *money.Rate) *money.Rate {
+	if rate == nil {
+		return nil
+	}
+
+	clone := *rate
+	return &clone
 }
 
-func findProductInCart(cart models.Cart, productToFind string) *models.Product {
-	productsInCart := cart.Products
-	for _, prod := range productsInCart {
-		if prod.Name == productToFind {
-			return &prod
+// withFreeItems returns the cart with its free line items replaced by the given ones.
+func withFreeItems(cart models.Cart, items []models.LineItem) models.Cart {
+	kept := make([]models.LineItem, 0, len(cart.Items)+len(items))
+	for _, item := range cart.Items {
+		if !item.Free {
+			kept = append(kept, item)
 		}
 	}
 
-	return nil
+	cart.Items = append(kept, items...)
+	return cart
+}
+
+// freeItems returns the free line items of the cart.
+func freeItems(cart models.Cart) []models.LineItem {
+	var items []models.LineItem
+	for _, item := range cart.Items {
+		if item.Free {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// findProductInCart returns the index of the line item of the product the user added, or -1 if it isn't in the
+// cart. Free line items are left out, since only promotions change them.
+func findProductInCart(cart models.Cart, productToFind string) int {
+	for i, item := range cart.Items {
+		if !item.Free && item.Product.Name == productToFind {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// findCoupon returns the index of the code among the coupons of the cart, or -1 if it isn't applied to the cart.
+func findCoupon(cart models.Cart, code string) int {
+	for i, applied := range cart.Coupons {
+		if applied == code {
+			return i
+		}
+	}
+
+	return -1
 }
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+var benchmarkSizes = []int{1_000, 100_000, 1_000_000}
+
+func newBenchmarkRepo(carts int) CartRepository {
+	repo := make(map[string]models.Cart, carts)
+	for i := 0; i < carts; i++ {
+		userID := fmt.Sprintf("user%d", i)
+		repo[userID] = models.Cart{ID: fmt.Sprintf("cart%d", i), UserID: userID}
+	}
+
+	return NewCartRepo(repo)
+}
+
+func BenchmarkCartRepo_GetCartByID(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		repo := newBenchmarkRepo(size)
+		cartID := fmt.Sprintf("cart%d", size-1)
+
+		b.Run(fmt.Sprintf("carts=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := repo.GetCartByID(cartID); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCartRepo_AddProduct(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		repo := newBenchmarkRepo(size)
+
+		b.Run(fmt.Sprintf("carts=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				// Spread the products among carts so the cart size doesn't grow with b.N
+				cartID := fmt.Sprintf("cart%d", i%size)
+				if _, err := repo.AddProduct(cartID, testCoffee, 1); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+const (
+	parallelClients   = 50
+	requestsPerClient = 10
+)
+
+func TestCartRepo_Concurrent_AddProduct_Same_Cart(t *testing.T) {
+	carts := map[string]models.Cart{
+		"user1": {ID: "cart1", UserID: "user1"},
+	}
+
+	forEachRepo(t, carts, func(t *testing.T, repo CartRepository) {
+		// When
+		var wg sync.WaitGroup
+		errs := make(chan error, parallelClients)
+		for client := 0; client < parallelClients; client++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < requestsPerClient; i++ {
+					if _, err := repo.AddProduct("cart1", testCoffee, 1); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		// Then
+		for err := range errs {
+			require.NoError(t, err)
+		}
+		cart, err := repo.GetCartByID("cart1")
+		require.NoError(t, err)
+		require.Equal(t, parallelClients*requestsPerClient, cart.Items[0].Quantity)
+	})
+}
+
+func TestCartRepo_Concurrent_Mixed_Operations(t *testing.T) {
+	forEachRepo(t, nil, func(t *testing.T, repo CartRepository) {
+		// Given a cart for every user, shared by several clients
+		for user := 0; user < 10; user++ {
+			userID := fmt.Sprintf("user%d", user)
+			_, err := repo.CreateCart(userID, models.Cart{ID: "cart-" + userID, UserID: userID})
+			require.NoError(t, err)
+		}
+
+		// When
+		var wg sync.WaitGroup
+		errs := make(chan error, parallelClients)
+		for client := 0; client < parallelClients; client++ {
+			wg.Add(1)
+			go func(client int) {
+				defer wg.Done()
+				errs <- mixedOperations(repo, client)
+			}(client)
+		}
+		wg.Wait()
+		close(errs)
+
+		// Then
+		for err := range errs {
+			require.NoError(t, err)
+		}
+		for user := 0; user < 10; user++ {
+			cart, err := repo.GetCartByID(fmt.Sprintf("cart-user%d", user))
+			require.NoError(t, err)
+			require.Equal(t, parallelClients/10, len(cart.Items))
+			for _, item := range cart.Items {
+				require.Equal(t, requestsPerClient+1, item.Quantity)
+			}
+		}
+	})
+}
+
+// mixedOperations adds a product of the client to the cart of one of the users, changes its quantity and reads the
+// cart back, requestsPerClient times, stopping at the first error.
+func mixedOperations(repo CartRepository, client int) error {
+	userID := fmt.Sprintf("user%d", client%10)
+	cart, err := repo.GetCartByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	product := models.Product{Name: fmt.Sprintf("coffee%d", client), Category: models.CoffeeCategory, Price: usd(10)}
+	for i := 0; i < requestsPerClient; i++ {
+		if _, err := repo.AddProduct(cart.ID, product, 1); err != nil {
+			return err
+		}
+		if _, err := repo.UpdateProductQuantity(cart.ID, product.Name, i+2); err != nil {
+			return err
+		}
+		if _, err := repo.GetCartByID(cart.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestCartRepo_AddProduct_During_MergeCart(t *testing.T) {
+	mug := models.Product{Name: "mug", Category: models.AccessoriesCategory, Price: usd(5)}
+	forEachRepo(t, nil, func(t *testing.T, repo CartRepository) {
+		// Given
+		_, err := repo.CreateCart("", models.Cart{ID: "guest1", GuestToken: "token1"})
+		require.NoError(t, err)
+		_, err = repo.CreateCart("user1", models.Cart{ID: "cart1", UserID: "user1"})
+		require.NoError(t, err)
+
+		// When the user adds a product to their cart while the guest cart is merged into it
+		added := make(chan error, 1)
+		merged, mergeErr := repo.MergeCart("guest1", "user1", func(userCart, _ models.Cart) (models.Cart, error) {
+			go func() {
+				_, err := repo.AddProduct("cart1", mug, 1)
+				added <- err
+			}()
+
+			// The product can't be added before the merged cart is stored, give it the chance to anyway
+			select {
+			case err := <-added:
+				added <- err
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			userCart.Items = append(userCart.Items, models.LineItem{Product: testCoffee, Quantity: 1})
+			return userCart, nil
+		})
+		addErr := <-added
+		userCart, err := repo.GetCartByID("cart1")
+
+		// Then neither change is lost
+		require.NoError(t, mergeErr)
+		require.Equal(t, []models.LineItem{{Product: testCoffee, Quantity: 1}}, merged.Items)
+		require.NoError(t, addErr)
+		require.NoError(t, err)
+		require.Equal(t, []models.LineItem{{Product: testCoffee, Quantity: 1}, {Product: mug, Quantity: 1}}, userCart.Items)
+	})
+}
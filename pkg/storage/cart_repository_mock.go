@@ -13,23 +13,71 @@ type CartRepositoryMock struct {
 	mock.Mock
 }
 
-// AddProduct provides a mock function with given fields: cartID, product
-func (_m *CartRepositoryMock) AddProduct(cartID string, product models.Product) (models.Cart, error) {
-	ret := _m.Called(cartID, product)
+// AddCoupon provides a mock function with given fields: cartID, code
+func (_m *CartRepositoryMock) AddCoupon(cartID string, code string) (models.Cart, error) {
+	ret := _m.Called(cartID, code)
 
 	var r0 models.Cart
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, models.Product) (models.Cart, error)); ok {
-		return rf(cartID, product)
+	if rf, ok := ret.Get(0).(func(string, string) (models.Cart, error)); ok {
+		return rf(cartID, code)
 	}
-	if rf, ok := ret.Get(0).(func(string, models.Product) models.Cart); ok {
-		r0 = rf(cartID, product)
+	if rf, ok := ret.Get(0).(func(string, string) models.Cart); ok {
+		r0 = rf(cartID, code)
 	} else {
 		r0 = ret.Get(0).(models.Cart)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, models.Product) error); ok {
-		r1 = rf(cartID, product)
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(cartID, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AddProduct provides a mock function with given fields: cartID, product, quantity
+func (_m *CartRepositoryMock) AddProduct(cartID string, product models.Product, quantity int) (models.Cart, error) {
+	ret := _m.Called(cartID, product, quantity)
+
+	var r0 models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, models.Product, int) (models.Cart, error)); ok {
+		return rf(cartID, product, quantity)
+	}
+	if rf, ok := ret.Get(0).(func(string, models.Product, int) models.Cart); ok {
+		r0 = rf(cartID, product, quantity)
+	} else {
+		r0 = ret.Get(0).(models.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, models.Product, int) error); ok {
+		r1 = rf(cartID, product, quantity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CheckoutCart provides a mock function with given fields: cartID
+func (_m *CartRepositoryMock) CheckoutCart(cartID string) (models.Cart, error) {
+	ret := _m.Called(cartID)
+
+	var r0 models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.Cart, error)); ok {
+		return rf(cartID)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.Cart); ok {
+		r0 = rf(cartID)
+	} else {
+		r0 = ret.Get(0).(models.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(cartID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -38,19 +86,67 @@ func (_m *CartRepositoryMock) AddProduct(cartID string, product models.Product)
 }
 
 // CreateCart provides a mock function with given fields: userID, cart
-func (_m *CartRepositoryMock) CreateCart(userID string, cart models.Cart) models.Cart {
+func (_m *CartRepositoryMock) CreateCart(userID string, cart models.Cart) (models.Cart, error) {
 	ret := _m.Called(userID, cart)
 
 	var r0 models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, models.Cart) (models.Cart, error)); ok {
+		return rf(userID, cart)
+	}
 	if rf, ok := ret.Get(0).(func(string, models.Cart) models.Cart); ok {
 		r0 = rf(userID, cart)
 	} else {
 		r0 = ret.Get(0).(models.Cart)
 	}
 
+	if rf, ok := ret.Get(1).(func(string, models.Cart) error); ok {
+		r1 = rf(userID, cart)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteCart provides a mock function with given fields: cartID
+func (_m *CartRepositoryMock) DeleteCart(cartID string) error {
+	ret := _m.Called(cartID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(cartID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
 	return r0
 }
 
+// GetCartByGuestToken provides a mock function with given fields: token
+func (_m *CartRepositoryMock) GetCartByGuestToken(token string) (models.Cart, error) {
+	ret := _m.Called(token)
+
+	var r0 models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.Cart, error)); ok {
+		return rf(token)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.Cart); ok {
+		r0 = rf(token)
+	} else {
+		r0 = ret.Get(0).(models.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetCartByID provides a mock function with given fields: cartID
 func (_m *CartRepositoryMock) GetCartByID(cartID string) (models.Cart, error) {
 	ret := _m.Called(cartID)
@@ -75,6 +171,224 @@ func (_m *CartRepositoryMock) GetCartByID(cartID string) (models.Cart, error) {
 	return r0, r1
 }
 
+// GetCartByUserID provides a mock function with given fields: userID
+func (_m *CartRepositoryMock) GetCartByUserID(userID string) (models.Cart, error) {
+	ret := _m.Called(userID)
+
+	var r0 models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.Cart, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.Cart); ok {
+		r0 = rf(userID)
+	} else {
+		r0 = ret.Get(0).(models.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCartsByUserID provides a mock function with given fields: userID
+func (_m *CartRepositoryMock) GetCartsByUserID(userID string) ([]models.Cart, error) {
+	ret := _m.Called(userID)
+
+	var r0 []models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]models.Cart, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []models.Cart); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Cart)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MergeCart provides a mock function with given fields: guestCartID, userID, merge
+func (_m *CartRepositoryMock) MergeCart(guestCartID string, userID string, merge MergeFunc) (models.Cart, error) {
+	ret := _m.Called(guestCartID, userID, merge)
+
+	var r0 models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, MergeFunc) (models.Cart, error)); ok {
+		return rf(guestCartID, userID, merge)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, MergeFunc) models.Cart); ok {
+		r0 = rf(guestCartID, userID, merge)
+	} else {
+		r0 = ret.Get(0).(models.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, MergeFunc) error); ok {
+		r1 = rf(guestCartID, userID, merge)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveCoupon provides a mock function with given fields: cartID, code
+func (_m *CartRepositoryMock) RemoveCoupon(cartID string, code string) (models.Cart, error) {
+	ret := _m.Called(cartID, code)
+
+	var r0 models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (models.Cart, error)); ok {
+		return rf(cartID, code)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) models.Cart); ok {
+		r0 = rf(cartID, code)
+	} else {
+		r0 = ret.Get(0).(models.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(cartID, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveProduct provides a mock function with given fields: cartID, product
+func (_m *CartRepositoryMock) RemoveProduct(cartID string, product string) (models.Cart, error) {
+	ret := _m.Called(cartID, product)
+
+	var r0 models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (models.Cart, error)); ok {
+		return rf(cartID, product)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) models.Cart); ok {
+		r0 = rf(cartID, product)
+	} else {
+		r0 = ret.Get(0).(models.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(cartID, product)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RenameCart provides a mock function with given fields: cartID, name
+func (_m *CartRepositoryMock) RenameCart(cartID string, name string) (models.Cart, error) {
+	ret := _m.Called(cartID, name)
+
+	var r0 models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (models.Cart, error)); ok {
+		return rf(cartID, name)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) models.Cart); ok {
+		r0 = rf(cartID, name)
+	} else {
+		r0 = ret.Get(0).(models.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(cartID, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReopenCart provides a mock function with given fields: cartID
+func (_m *CartRepositoryMock) ReopenCart(cartID string) (models.Cart, error) {
+	ret := _m.Called(cartID)
+
+	var r0 models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.Cart, error)); ok {
+		return rf(cartID)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.Cart); ok {
+		r0 = rf(cartID)
+	} else {
+		r0 = ret.Get(0).(models.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(cartID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetFreeItems provides a mock function with given fields: cartID, items
+func (_m *CartRepositoryMock) SetFreeItems(cartID string, items []models.LineItem) (models.Cart, error) {
+	ret := _m.Called(cartID, items)
+
+	var r0 models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, []models.LineItem) (models.Cart, error)); ok {
+		return rf(cartID, items)
+	}
+	if rf, ok := ret.Get(0).(func(string, []models.LineItem) models.Cart); ok {
+		r0 = rf(cartID, items)
+	} else {
+		r0 = ret.Get(0).(models.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, []models.LineItem) error); ok {
+		r1 = rf(cartID, items)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateCheckout provides a mock function with given fields: cartID, checkout
+func (_m *CartRepositoryMock) UpdateCheckout(cartID string, checkout models.Checkout) (models.Cart, error) {
+	ret := _m.Called(cartID, checkout)
+
+	var r0 models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, models.Checkout) (models.Cart, error)); ok {
+		return rf(cartID, checkout)
+	}
+	if rf, ok := ret.Get(0).(func(string, models.Checkout) models.Cart); ok {
+		r0 = rf(cartID, checkout)
+	} else {
+		r0 = ret.Get(0).(models.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, models.Checkout) error); ok {
+		r1 = rf(cartID, checkout)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateProductQuantity provides a mock function with given fields: cartID, product, quantity
 func (_m *CartRepositoryMock) UpdateProductQuantity(cartID string, product string, quantity int) (models.Cart, error) {
 	ret := _m.Called(cartID, product, quantity)
@@ -3,67 +3,653 @@ package storage
 import (
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
 )
 
+func usd(amount int64) money.Money {
+	return money.FromMajor(amount, money.USD)
+}
+
+// forEachRepo runs the test against every CartRepository implementation, seeded with the given carts.
+func forEachRepo(t *testing.T, carts map[string]models.Cart, test func(t *testing.T, repo CartRepository)) {
+	t.Run("memory", func(t *testing.T) {
+		seed := make(map[string]models.Cart)
+		for userID, cart := range carts {
+			seed[userID] = cart
+		}
+		test(t, NewCartRepo(seed))
+	})
+
+	t.Run("file", func(t *testing.T) {
+		repo, err := NewFileCartRepo(t.TempDir(), 0)
+		require.NoError(t, err)
+		t.Cleanup(func() { repo.Close() })
+		for userID, cart := range carts {
+			_, err := repo.CreateCart(userID, cart)
+			require.NoError(t, err)
+		}
+		test(t, repo)
+	})
+
+	t.Run("sql", func(t *testing.T) {
+		repo := NewSQLCartRepo(newTestDB(t))
+		for userID, cart := range carts {
+			_, err := repo.CreateCart(userID, cart)
+			require.NoError(t, err)
+		}
+		test(t, repo)
+	})
+}
+
 func TestCartRepo_GetCartByID(t *testing.T) {
-	// Given
-	repo := NewCartRepo(map[string]models.Cart{
+	carts := map[string]models.Cart{
 		"12345": {
 			ID:     "testCartID",
 			UserID: "12345",
-			Products: []models.Product{
-				{Name: "product1", Category: models.CoffeeCategory, Price: 10},
+			Items: []models.LineItem{
+				{Product: models.Product{Name: "product1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 1},
 			},
 		},
+	}
+
+	forEachRepo(t, carts, func(t *testing.T, repo CartRepository) {
+		// When
+		cart, err := repo.GetCartByID("testCartID")
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, "12345", cart.UserID)
 	})
+}
 
-	// When
-	cart, err := repo.GetCartByID("testCartID")
+func TestCartRepo_GetCartByUserID(t *testing.T) {
+	carts := map[string]models.Cart{
+		"12345": {ID: "testCartID", UserID: "12345"},
+	}
 
-	// Then
-	require.NoError(t, err)
-	require.Equal(t, "12345", cart.UserID)
+	forEachRepo(t, carts, func(t *testing.T, repo CartRepository) {
+		// When
+		cart, err := repo.GetCartByUserID("12345")
+		_, missingErr := repo.GetCartByUserID("67890")
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, "testCartID", cart.ID)
+		require.EqualError(t, missingErr, "user 67890 doesn't have a cart")
+		require.ErrorIs(t, missingErr, ErrCartNotFound)
+	})
+}
+
+func TestCartRepo_Missing_Cart(t *testing.T) {
+	forEachRepo(t, nil, func(t *testing.T, repo CartRepository) {
+		// When
+		_, getErr := repo.GetCartByID("unknown")
+		_, addErr := repo.AddProduct("unknown", testCoffee, 1)
+		_, updateErr := repo.UpdateProductQuantity("unknown", testCoffee.Name, 2)
+		_, removeErr := repo.RemoveProduct("unknown", testCoffee.Name)
+
+		// Then
+		for _, err := range []error{getErr, addErr, updateErr, removeErr} {
+			require.EqualError(t, err, "cart with ID unknown doesn't exist")
+			require.ErrorIs(t, err, ErrCartNotFound)
+		}
+	})
 }
 
 func TestCartRepo_UpdateProductQuantity(t *testing.T) {
-	// Given
-	repo := NewCartRepo(map[string]models.Cart{
+	carts := map[string]models.Cart{
 		"12345": {
 			ID:     "testCartID",
 			UserID: "testUserID",
-			Products: []models.Product{
-				{Name: "product1", Category: models.CoffeeCategory, Price: 10},
+			Items: []models.LineItem{
+				{Product: models.Product{Name: "product1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 1},
 			},
 		},
+	}
+
+	forEachRepo(t, carts, func(t *testing.T, repo CartRepository) {
+		// When
+		updatedCart, err := repo.UpdateProductQuantity("testCartID", "product1", 3)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, 1, len(updatedCart.Items))
+		require.Equal(t, 3, updatedCart.Items[0].Quantity)
 	})
+}
 
-	// When
-	updatedCart, err := repo.UpdateProductQuantity("testCartID", "product1", 3)
+func TestCartRepo_UpdateProductQuantity_Decrease_And_Remove(t *testing.T) {
+	carts := map[string]models.Cart{
+		"12345": {
+			ID:     "testCartID",
+			UserID: "12345",
+			Items: []models.LineItem{
+				{Product: models.Product{Name: "product1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 5},
+				{Product: models.Product{Name: "product2", Category: models.EquipmentCategory, Price: usd(20)}, Quantity: 1},
+			},
+		},
+	}
 
-	// Then
-	require.NoError(t, err)
-	require.Equal(t, 3, len(updatedCart.Products))
+	forEachRepo(t, carts, func(t *testing.T, repo CartRepository) {
+		// When
+		decreasedCart, decreaseErr := repo.UpdateProductQuantity("testCartID", "product1", 2)
+		removedCart, removeErr := repo.UpdateProductQuantity("testCartID", "product1", 0)
+		_, missingErr := repo.UpdateProductQuantity("testCartID", "product1", 1)
+
+		// Then
+		require.NoError(t, decreaseErr)
+		require.Equal(t, 2, decreasedCart.Items[0].Quantity)
+		require.NoError(t, removeErr)
+		require.Equal(t, 1, len(removedCart.Items))
+		require.Equal(t, "product2", removedCart.Items[0].Product.Name)
+		require.EqualError(t, missingErr, "product product1 does not exist in cart")
+	})
 }
 
 func TestCartRepo_CreateCart_And_Add_Product(t *testing.T) {
-	// Given
-	repo := NewCartRepo(make(map[string]models.Cart))
-	newCart := models.Cart{
-		UserID: "testUserID",
+	forEachRepo(t, nil, func(t *testing.T, repo CartRepository) {
+		// Given
+		newCart := models.Cart{
+			UserID: "testUserID",
+		}
+
+		createdCart, err := repo.CreateCart("testUserID", newCart)
+		require.NoError(t, err)
+		require.Equal(t, "testUserID", createdCart.UserID)
+
+		// When
+		res, err := repo.AddProduct(createdCart.ID, models.Product{
+			Name:     "coffeeTest",
+			Category: models.CoffeeCategory,
+			Price:    usd(15),
+		}, 1)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, 1, len(res.Items))
+		require.Equal(t, 1, res.Items[0].Quantity)
+	})
+}
+
+func TestCartRepo_Keeps_Currency_And_Exchange_Rate(t *testing.T) {
+	forEachRepo(t, nil, func(t *testing.T, repo CartRepository) {
+		// Given
+		rate, err := money.ParseRate(money.USD, money.EUR, "0.92")
+		require.NoError(t, err)
+		_, err = repo.CreateCart("user1", models.Cart{ID: "cart1", UserID: "user1", Currency: money.EUR, ExchangeRate: &rate})
+		require.NoError(t, err)
+
+		// When
+		_, err = repo.AddProduct("cart1", models.Product{Name: "coffeeTest", Category: models.CoffeeCategory,
+			Price: money.New(1380, money.EUR)}, 1)
+		require.NoError(t, err)
+		userCart, cartErr := repo.GetCartByID("cart1")
+
+		// Then
+		require.NoError(t, cartErr)
+		require.Equal(t, money.EUR, userCart.Currency)
+		require.Equal(t, &rate, userCart.ExchangeRate)
+		require.Equal(t, money.New(1380, money.EUR), userCart.Items[0].Product.Price)
+	})
+}
+
+func TestCartRepo_UpdateCheckout(t *testing.T) {
+	forEachRepo(t, nil, func(t *testing.T, repo CartRepository) {
+		// Given
+		_, err := repo.CreateCart("user1", models.Cart{ID: "cart1", UserID: "user1"})
+		require.NoError(t, err)
+		home := models.Address{Name: "Ada Lovelace", Line1: "350 5th Ave", City: "New York", Region: "NY",
+			PostalCode: "10118", Country: "US"}
+		office := models.Address{Name: "Ada Lovelace", Line1: "1 Main St", Line2: "Floor 2", City: "Boston", Region: "MA",
+			PostalCode: "02108", Country: "US"}
+		_, err = repo.UpdateCheckout("cart1", models.Checkout{Email: "ada@example.com", ShippingAddress: &home})
+		require.NoError(t, err)
+
+		// When
+		updatedCart, err := repo.UpdateCheckout("cart1", models.Checkout{BillingAddress: &home, DeliveryMethod: "express"})
+		require.NoError(t, err)
+		_, err = repo.UpdateCheckout("cart1", models.Checkout{ShippingAddress: &office})
+		require.NoError(t, err)
+		storedCart, storedErr := repo.GetCartByID("cart1")
+
+		// Then
+		require.Equal(t, models.Checkout{Email: "ada@example.com", ShippingAddress: &home, BillingAddress: &home,
+			DeliveryMethod: "express"}, updatedCart.Checkout)
+		require.NoError(t, storedErr)
+		require.Equal(t, models.Checkout{Email: "ada@example.com", ShippingAddress: &office, BillingAddress: &home,
+			DeliveryMethod: "express"}, storedCart.Checkout)
+	})
+}
+
+func TestCartRepo_AddCoupon_And_RemoveCoupon(t *testing.T) {
+	forEachRepo(t, nil, func(t *testing.T, repo CartRepository) {
+		// Given
+		_, err := repo.CreateCart("user1", models.Cart{ID: "cart1", UserID: "user1"})
+		require.NoError(t, err)
+		_, err = repo.AddCoupon("cart1", "WELCOME10")
+		require.NoError(t, err)
+		_, err = repo.AddCoupon("cart1", "FREESHIP")
+		require.NoError(t, err)
+
+		// When
+		again, againErr := repo.AddCoupon("cart1", "WELCOME10")
+		removed, removedErr := repo.RemoveCoupon("cart1", "WELCOME10")
+		_, missingErr := repo.RemoveCoupon("cart1", "WELCOME10")
+		storedCart, storedErr := repo.GetCartByID("cart1")
+
+		// Then
+		require.NoError(t, againErr)
+		require.Equal(t, []string{"WELCOME10", "FREESHIP"}, again.Coupons)
+		require.NoError(t, removedErr)
+		require.Equal(t, []string{"FREESHIP"}, removed.Coupons)
+		require.ErrorIs(t, missingErr, ErrCouponNotInCart)
+		require.NoError(t, storedErr)
+		require.Equal(t, []string{"FREESHIP"}, storedCart.Coupons)
+	})
+}
+
+func TestCartRepo_AddProduct_Quantity(t *testing.T) {
+	carts := map[string]models.Cart{
+		"12345": {ID: "cart1", UserID: "12345", Items: []models.LineItem{}},
 	}
 
-	createdCart := repo.CreateCart("testUserID", newCart)
-	require.Equal(t, "testUserID", createdCart.UserID)
+	forEachRepo(t, carts, func(t *testing.T, repo CartRepository) {
+		// Given
+		product := models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}
+		_, err := repo.AddProduct("cart1", product, 2)
+		require.NoError(t, err)
 
-	// When
-	res, err := repo.AddProduct(createdCart.ID, models.Product{
-		Name:     "coffeeTest",
-		Category: models.CoffeeCategory,
-		Price:    15,
+		// When
+		res, err := repo.AddProduct("cart1", product, 3)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, []models.LineItem{{Product: product, Quantity: 5}}, res.Items)
+	})
+}
+
+func TestCartRepo_SetFreeItems(t *testing.T) {
+	sample := models.Product{Name: "sample", Category: models.CoffeeCategory, Price: usd(0)}
+	carts := map[string]models.Cart{
+		"12345": {ID: "cart1", UserID: "12345", Items: []models.LineItem{{Product: sample, Quantity: 1}}},
+	}
+
+	forEachRepo(t, carts, func(t *testing.T, repo CartRepository) {
+		// Given a free line item of a product the user also added
+		free := models.LineItem{Product: sample, Quantity: 1, Free: true, PromotionID: "free-sample"}
+		_, err := repo.SetFreeItems("cart1", []models.LineItem{free})
+		require.NoError(t, err)
+		coffee := models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}
+		_, err = repo.AddProduct("cart1", coffee, 1)
+		require.NoError(t, err)
+
+		// When
+		updated, updateErr := repo.UpdateProductQuantity("cart1", "sample", 2)
+		removed, removeErr := repo.SetFreeItems("cart1", nil)
+
+		// Then the free line item stays last and apart from the one the user added
+		require.NoError(t, updateErr)
+		require.Equal(t, []models.LineItem{{Product: sample, Quantity: 2}, {Product: coffee, Quantity: 1}, free}, updated.Items)
+		require.NoError(t, removeErr)
+		require.Equal(t, []models.LineItem{{Product: sample, Quantity: 2}, {Product: coffee, Quantity: 1}}, removed.Items)
+	})
+}
+
+func TestCartRepo_Guest_Carts(t *testing.T) {
+	forEachRepo(t, nil, func(t *testing.T, repo CartRepository) {
+		// Given
+		_, err := repo.CreateCart("", models.Cart{ID: "guest1", GuestToken: "token1"})
+		require.NoError(t, err)
+		_, err = repo.CreateCart("", models.Cart{ID: "guest2", GuestToken: "token2"})
+		require.NoError(t, err)
+
+		// When
+		first, firstErr := repo.GetCartByGuestToken("token1")
+		second, secondErr := repo.GetCartByGuestToken("token2")
+		_, missingErr := repo.GetCartByGuestToken("token3")
+		_, userErr := repo.GetCartByUserID("")
+
+		// Then every guest gets their own cart
+		require.NoError(t, firstErr)
+		require.Equal(t, "guest1", first.ID)
+		require.Equal(t, "token1", first.GuestToken)
+		require.NoError(t, secondErr)
+		require.Equal(t, "guest2", second.ID)
+		require.ErrorIs(t, missingErr, ErrCartNotFound)
+		require.ErrorIs(t, userErr, ErrCartNotFound)
+	})
+}
+
+func TestCartRepo_MergeCart(t *testing.T) {
+	coffee := models.LineItem{Product: testCoffee, Quantity: 3}
+	forEachRepo(t, nil, func(t *testing.T, repo CartRepository) {
+		// Given
+		_, err := repo.CreateCart("", models.Cart{ID: "guest1", GuestToken: "token1"})
+		require.NoError(t, err)
+		_, err = repo.CreateCart("user1", models.Cart{ID: "cart1", UserID: "user1"})
+		require.NoError(t, err)
+		_, err = repo.AddCoupon("cart1", "TENOFF")
+		require.NoError(t, err)
+		merged := models.Cart{
+			ID:       "cart1",
+			UserID:   "user1",
+			Items:    []models.LineItem{coffee},
+			Checkout: models.Checkout{Email: "user@example.com"},
+			Coupons:  []string{"TENOFF", "FREESHIP"},
+		}
+
+		// When
+		var merging []models.Cart
+		mergedCart, mergeErr := repo.MergeCart("guest1", "user1", func(userCart, guestCart models.Cart) (models.Cart, error) {
+			merging = []models.Cart{userCart, guestCart}
+			return merged, nil
+		})
+		_, guestErr := repo.GetCartByID("guest1")
+		_, tokenErr := repo.GetCartByGuestToken("token1")
+		userCart, userErr := repo.GetCartByUserID("user1")
+
+		// Then the guest cart is gone and the user cart has what it was merged with
+		require.NoError(t, mergeErr)
+		require.Len(t, merging, 2)
+		require.Equal(t, "cart1", merging[0].ID)
+		require.Equal(t, []string{"TENOFF"}, merging[0].Coupons)
+		require.Equal(t, "guest1", merging[1].ID)
+		require.Equal(t, merged.Items, mergedCart.Items)
+		require.ErrorIs(t, guestErr, ErrCartNotFound)
+		require.ErrorIs(t, tokenErr, ErrCartNotFound)
+		require.NoError(t, userErr)
+		require.Equal(t, mergedCart, userCart)
+		require.Equal(t, "user@example.com", userCart.Checkout.Email)
+		require.Equal(t, []string{"TENOFF", "FREESHIP"}, userCart.Coupons)
 	})
+}
+
+func TestCartRepo_MergeCart_Hands_Guest_Cart_Over(t *testing.T) {
+	forEachRepo(t, nil, func(t *testing.T, repo CartRepository) {
+		// Given a user without a cart
+		_, err := repo.CreateCart("", models.Cart{ID: "guest1", GuestToken: "token1"})
+		require.NoError(t, err)
+		_, err = repo.AddProduct("guest1", testCoffee, 1)
+		require.NoError(t, err)
+
+		// When
+		var userCartID string
+		_, mergeErr := repo.MergeCart("guest1", "user1", func(userCart, guestCart models.Cart) (models.Cart, error) {
+			userCartID = userCart.ID
+			guestCart.UserID, guestCart.GuestToken = "user1", ""
+			return guestCart, nil
+		})
+		userCart, userErr := repo.GetCartByUserID("user1")
+		_, tokenErr := repo.GetCartByGuestToken("token1")
+
+		// Then
+		require.NoError(t, mergeErr)
+		require.Empty(t, userCartID)
+		require.NoError(t, userErr)
+		require.Equal(t, "guest1", userCart.ID)
+		require.Equal(t, []models.LineItem{{Product: testCoffee, Quantity: 1}}, userCart.Items)
+		require.ErrorIs(t, tokenErr, ErrCartNotFound)
+	})
+}
+
+func TestCartRepo_Index_Follows_New_Cart(t *testing.T) {
+	// Given a user whose cart was checked out, keyed by user as older snapshots stored them
+	repo := newCartRepo(map[string]models.Cart{
+		"12345": {ID: "oldCartID", UserID: "12345", CheckedOut: true},
+	})
+
+	// When
+	repo.mu.Lock()
+	repo.put(models.Cart{ID: "newCartID", UserID: "12345"})
+	repo.mu.Unlock()
 
 	// Then
+	oldCart, err := repo.GetCartByID("oldCartID")
 	require.NoError(t, err)
-	require.Equal(t, 1, len(res.Products))
+	require.True(t, oldCart.CheckedOut)
+	cart, err := repo.GetCartByUserID("12345")
+	require.NoError(t, err)
+	require.Equal(t, "newCartID", cart.ID)
+	require.Equal(t, map[string][]string{"12345": {"newCartID"}}, repo.users)
+}
+
+func TestCartRepo_Drops_Locks_Of_Removed_Carts(t *testing.T) {
+	// Given carts that were changed, so they have a lock
+	repo := newCartRepo(map[string]models.Cart{})
+	for _, cart := range []models.Cart{
+		{ID: "guest1", GuestToken: "token1"},
+		{ID: "cart1", UserID: "12345"},
+		{ID: "cart2", UserID: "12345"},
+	} {
+		_, err := repo.CreateCart(cart.UserID, cart)
+		require.NoError(t, err)
+		_, err = repo.AddProduct(cart.ID, testCoffee, 1)
+		require.NoError(t, err)
+	}
+
+	// When
+	deleteErr := repo.DeleteCart("cart1")
+	_, mergeErr := repo.MergeCart("guest1", "12345", func(userCart, _ models.Cart) (models.Cart, error) {
+		return userCart, nil
+	})
+
+	// Then only the cart left keeps its lock
+	require.NoError(t, deleteErr)
+	require.NoError(t, mergeErr)
+	var locked []string
+	repo.cartLocks.Range(func(cartID, _ any) bool {
+		locked = append(locked, cartID.(string))
+		return true
+	})
+	require.Equal(t, []string{"cart2"}, locked)
+}
+
+func TestCartRepo_Several_Carts_Per_User(t *testing.T) {
+	createdAt := time.Date(2026, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	forEachRepo(t, nil, func(t *testing.T, repo CartRepository) {
+		// Given
+		_, err := repo.CreateCart("12345", models.Cart{ID: "homeCartID", UserID: "12345", Name: "home", CreatedAt: createdAt.Add(time.Hour)})
+		require.NoError(t, err)
+		_, err = repo.CreateCart("12345", models.Cart{ID: "officeCartID", UserID: "12345", Name: "office", CreatedAt: createdAt})
+		require.NoError(t, err)
+		_, err = repo.CreateCart("12345", models.Cart{ID: "orderedCartID", UserID: "12345", Name: "ordered", CreatedAt: createdAt.Add(2 * time.Hour)})
+		require.NoError(t, err)
+		_, err = repo.CheckoutCart("orderedCartID")
+		require.NoError(t, err)
+
+		// When
+		userCarts, err := repo.GetCartsByUserID("12345")
+		require.NoError(t, err)
+		lastCart, err := repo.GetCartByUserID("12345")
+		require.NoError(t, err)
+		otherCarts, err := repo.GetCartsByUserID("67890")
+		require.NoError(t, err)
+
+		// Then the open carts are listed in the order they were created
+		require.Len(t, userCarts, 2)
+		require.Equal(t, "officeCartID", userCarts[0].ID)
+		require.Equal(t, "office", userCarts[0].Name)
+		require.True(t, createdAt.Equal(userCarts[0].CreatedAt))
+		require.Equal(t, "homeCartID", userCarts[1].ID)
+		require.Equal(t, "home", userCarts[1].Name)
+		require.Equal(t, "homeCartID", lastCart.ID)
+		require.Equal(t, []models.Cart{}, otherCarts)
+	})
+}
+
+func TestCartRepo_RenameCart(t *testing.T) {
+	carts := map[string]models.Cart{
+		"12345": {ID: "testCartID", UserID: "12345", Name: "office"},
+	}
+
+	forEachRepo(t, carts, func(t *testing.T, repo CartRepository) {
+		// When
+		renamedCart, err := repo.RenameCart("testCartID", "home")
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, "home", renamedCart.Name)
+		storedCart, err := repo.GetCartByID("testCartID")
+		require.NoError(t, err)
+		require.Equal(t, "home", storedCart.Name)
+
+		_, err = repo.CheckoutCart("testCartID")
+		require.NoError(t, err)
+		_, checkedOutErr := repo.RenameCart("testCartID", "office")
+		require.ErrorIs(t, checkedOutErr, ErrCartCheckedOut)
+		_, missingErr := repo.RenameCart("unknown", "office")
+		require.ErrorIs(t, missingErr, ErrCartNotFound)
+	})
+}
+
+func TestCartRepo_DeleteCart(t *testing.T) {
+	forEachRepo(t, nil, func(t *testing.T, repo CartRepository) {
+		// Given
+		_, err := repo.CreateCart("12345", models.Cart{
+			ID:      "officeCartID",
+			UserID:  "12345",
+			Items:   []models.LineItem{{Product: testCoffee, Quantity: 1}},
+			Coupons: []string{"TENOFF"},
+		})
+		require.NoError(t, err)
+		_, err = repo.CreateCart("12345", models.Cart{ID: "homeCartID", UserID: "12345"})
+		require.NoError(t, err)
+
+		// When
+		err = repo.DeleteCart("officeCartID")
+
+		// Then
+		require.NoError(t, err)
+		_, err = repo.GetCartByID("officeCartID")
+		require.ErrorIs(t, err, ErrCartNotFound)
+		userCarts, err := repo.GetCartsByUserID("12345")
+		require.NoError(t, err)
+		require.Len(t, userCarts, 1)
+		require.Equal(t, "homeCartID", userCarts[0].ID)
+
+		_, err = repo.CheckoutCart("homeCartID")
+		require.NoError(t, err)
+		require.ErrorIs(t, repo.DeleteCart("homeCartID"), ErrCartCheckedOut)
+		require.ErrorIs(t, repo.DeleteCart("officeCartID"), ErrCartNotFound)
+	})
+}
+
+func TestCartRepo_RemoveProduct(t *testing.T) {
+	carts := map[string]models.Cart{
+		"12345": {
+			ID:     "testCartID",
+			UserID: "12345",
+			Items: []models.LineItem{
+				{Product: models.Product{Name: "product1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 3},
+				{Product: models.Product{Name: "product2", Category: models.EquipmentCategory, Price: usd(20)}, Quantity: 1},
+			},
+		},
+	}
+
+	forEachRepo(t, carts, func(t *testing.T, repo CartRepository) {
+		// When
+		updatedCart, err := repo.RemoveProduct("testCartID", "product1")
+		_, missingErr := repo.RemoveProduct("testCartID", "product1")
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, 1, len(updatedCart.Items))
+		require.Equal(t, "product2", updatedCart.Items[0].Product.Name)
+		require.EqualError(t, missingErr, "product product1 does not exist in cart")
+	})
+}
+
+func TestCartRepo_CheckoutCart(t *testing.T) {
+	carts := map[string]models.Cart{
+		"12345": {
+			ID:     "testCartID",
+			UserID: "12345",
+			Items: []models.LineItem{
+				{Product: models.Product{Name: "product1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 1},
+			},
+		},
+	}
+
+	forEachRepo(t, carts, func(t *testing.T, repo CartRepository) {
+		// When
+		checkedOutCart, err := repo.CheckoutCart("testCartID")
+
+		// Then
+		require.NoError(t, err)
+		require.True(t, checkedOutCart.CheckedOut)
+
+		_, addErr := repo.AddProduct("testCartID", testCoffee, 1)
+		_, updateErr := repo.UpdateProductQuantity("testCartID", "product1", 2)
+		_, removeErr := repo.RemoveProduct("testCartID", "product1")
+		_, checkoutErr := repo.CheckoutCart("testCartID")
+		_, updateCheckoutErr := repo.UpdateCheckout("testCartID", models.Checkout{Email: "user@example.com"})
+		for _, err := range []error{addErr, updateErr, removeErr, checkoutErr, updateCheckoutErr} {
+			require.ErrorIs(t, err, ErrCartCheckedOut)
+		}
+
+		_, err = repo.GetCartByUserID("12345")
+		require.ErrorIs(t, err, ErrCartNotFound)
+
+		newCart, err := repo.CreateCart("12345", models.Cart{ID: "newCartID", UserID: "12345"})
+		require.NoError(t, err)
+		require.Equal(t, "newCartID", newCart.ID)
+		require.False(t, newCart.CheckedOut)
+	})
+}
+
+func TestCartRepo_ReopenCart(t *testing.T) {
+	carts := map[string]models.Cart{
+		"12345": {
+			ID:     "testCartID",
+			UserID: "12345",
+			Items: []models.LineItem{
+				{Product: models.Product{Name: "product1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 1},
+			},
+		},
+	}
+
+	forEachRepo(t, carts, func(t *testing.T, repo CartRepository) {
+		// Given
+		_, err := repo.CheckoutCart("testCartID")
+		require.NoError(t, err)
+
+		// When
+		reopenedCart, err := repo.ReopenCart("testCartID")
+
+		// Then
+		require.NoError(t, err)
+		require.False(t, reopenedCart.CheckedOut)
+		userCart, err := repo.GetCartByUserID("12345")
+		require.NoError(t, err)
+		require.Equal(t, "testCartID", userCart.ID)
+		_, err = repo.UpdateProductQuantity("testCartID", "product1", 2)
+		require.NoError(t, err)
+	})
+}
+
+func TestCartRepo_ReopenCart_Next_To_Another_Open_Cart(t *testing.T) {
+	carts := map[string]models.Cart{
+		"12345": {ID: "testCartID", UserID: "12345"},
+	}
+
+	forEachRepo(t, carts, func(t *testing.T, repo CartRepository) {
+		// Given a user who created another cart while the order of this one was placed
+		_, err := repo.CheckoutCart("testCartID")
+		require.NoError(t, err)
+		_, err = repo.CreateCart("12345", models.Cart{ID: "newCartID", UserID: "12345", CreatedAt: time.Now().UTC()})
+		require.NoError(t, err)
+
+		// When
+		reopenedCart, err := repo.ReopenCart("testCartID")
+
+		// Then
+		require.NoError(t, err)
+		require.False(t, reopenedCart.CheckedOut)
+		userCarts, err := repo.GetCartsByUserID("12345")
+		require.NoError(t, err)
+		require.Len(t, userCarts, 2)
+	})
 }
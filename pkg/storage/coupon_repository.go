@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"sync"
+	"time"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+// CouponRepository keeps the coupons by code, along with the redemptions of every order.
+type CouponRepository interface {
+	CreateCoupon(coupon models.Coupon) (models.Coupon, error)
+	GetCoupon(code string) (models.Coupon, error)
+	// CountUserRedemptions returns how many times the user redeemed the coupon.
+	CountUserRedemptions(code, userID string) (int, error)
+	// Redeem records all the redemptions of an order or none of them, failing with ErrCouponExhausted when any
+	// coupon was already redeemed as many times as it can be, in total or by the user.
+	Redeem(orderID int, redemptions []models.Redemption) error
+	// ReleaseRedemptions forgets the redemptions of an order that wasn't placed or was cancelled, so the coupons can be
+	// redeemed again.
+	ReleaseRedemptions(orderID int) error
+}
+
+// couponRepo keeps the coupons and the redemptions in memory. It's safe for concurrent use.
+type couponRepo struct {
+	mu          sync.RWMutex
+	coupons     map[string]models.Coupon
+	redemptions map[int][]models.Redemption
+}
+
+func NewCouponRepo() CouponRepository {
+	return newCouponRepo()
+}
+
+func newCouponRepo() *couponRepo {
+	return &couponRepo{
+		coupons:     make(map[string]models.Coupon),
+		redemptions: make(map[int][]models.Redemption),
+	}
+}
+
+func (c *couponRepo) CreateCoupon(coupon models.Coupon) (models.Coupon, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	change, err := c.create(coupon)
+	if err != nil {
+		return models.Coupon{}, err
+	}
+
+	c.apply(change)
+	return cloneCoupon(coupon), nil
+}
+
+func (c *couponRepo) GetCoupon(code string) (models.Coupon, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	coupon, ok := c.coupons[code]
+	if !ok {
+		return models.Coupon{}, couponNotFound(code)
+	}
+
+	return cloneCoupon(coupon), nil
+}
+
+func (c *couponRepo) CountUserRedemptions(code, userID string) (int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.countUserRedemptions(code, userID), nil
+}
+
+func (c *couponRepo) Redeem(orderID int, redemptions []models.Redemption) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	change, err := c.redeem(orderID, redemptions)
+	if err != nil {
+		return err
+	}
+
+	c.apply(change)
+	return nil
+}
+
+func (c *couponRepo) ReleaseRedemptions(orderID int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.apply(c.release(orderID))
+	return nil
+}
+
+// couponChange is the outcome of a change to the coupons: the coupons it touched, along with the redemptions of the
+// order it redeemed coupons for or the order whose redemptions it removed.
+type couponChange struct {
+	Coupons     []models.Coupon     `json:"coupons"`
+	OrderID     int                 `json:"order_id,omitempty"`
+	Redemptions []models.Redemption `json:"redemptions,omitempty"`
+	Removed     *int                `json:"removed,omitempty"`
+}
+
+// The methods below compute the change without applying it, so the file repository can persist it first.
+// The caller must hold mu.
+
+func (c *couponRepo) create(coupon models.Coupon) (couponChange, error) {
+	if _, ok := c.coupons[coupon.Code]; ok {
+		return couponChange{}, couponAlreadyExists(coupon.Code)
+	}
+
+	return couponChange{Coupons: []models.Coupon{cloneCoupon(coupon)}}, nil
+}
+
+func (c *couponRepo) redeem(orderID int, redemptions []models.Redemption) (couponChange, error) {
+	if _, ok := c.redemptions[orderID]; ok {
+		return couponChange{}, redemptionAlreadyExists(orderID)
+	}
+
+	coupons := make([]models.Coupon, 0, len(redemptions))
+	for _, redemption := range redemptions {
+		coupon, ok := c.coupons[redemption.Code]
+		if !ok {
+			return couponChange{}, couponNotFound(redemption.Code)
+		}
+
+		if coupon.MaxRedemptions > 0 && coupon.Redemptions >= coupon.MaxRedemptions {
+			return couponChange{}, couponExhausted(coupon.Code)
+		}
+
+		if coupon.MaxRedemptionsPerUser > 0 && c.countUserRedemptions(coupon.Code, redemption.UserID) >= coupon.MaxRedemptionsPerUser {
+			return couponChange{}, couponExhaustedByUser(coupon.Code, redemption.UserID)
+		}
+
+		coupon.Redemptions++
+		coupons = append(coupons, cloneCoupon(coupon))
+	}
+
+	return couponChange{Coupons: coupons, OrderID: orderID, Redemptions: append([]models.Redemption{}, redemptions...)}, nil
+}
+
+// release gives back the redemptions of the order, if it has any. Orders without them get an empty change.
+func (c *couponRepo) release(orderID int) couponChange {
+	redemptions, ok := c.redemptions[orderID]
+	if !ok {
+		return couponChange{}
+	}
+
+	coupons := make([]models.Coupon, 0, len(redemptions))
+	for _, redemption := range redemptions {
+		coupon := cloneCoupon(c.coupons[redemption.Code])
+		coupon.Redemptions--
+		coupons = append(coupons, coupon)
+	}
+
+	return couponChange{Coupons: coupons, Removed: &orderID}
+}
+
+func (c *couponRepo) apply(change couponChange) {
+	for _, coupon := range change.Coupons {
+		c.coupons[coupon.Code] = coupon
+	}
+
+	if change.Redemptions != nil {
+		c.redemptions[change.OrderID] = change.Redemptions
+	}
+
+	if change.Removed != nil {
+		delete(c.redemptions, *change.Removed)
+	}
+}
+
+// isEmpty reports whether the change changes nothing.
+func (c couponChange) isEmpty() bool {
+	return c.Coupons == nil && c.Redemptions == nil && c.Removed == nil
+}
+
+func (c *couponRepo) countUserRedemptions(code, userID string) int {
+	count := 0
+	for _, redemptions := range c.redemptions {
+		for _, redemption := range redemptions {
+			if redemption.Code == code && redemption.UserID == userID {
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+func cloneCoupon(coupon models.Coupon) models.Coupon {
+	coupon.StartsAt = cloneTime(coupon.StartsAt)
+	coupon.EndsAt = cloneTime(coupon.EndsAt)
+	return coupon
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	clone := *t
+	return &clone
+}
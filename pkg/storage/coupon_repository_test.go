@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+// forEachCouponRepo runs the test against every CouponRepository implementation.
+func forEachCouponRepo(t *testing.T, test func(t *testing.T, repo CouponRepository)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewCouponRepo())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		repo, err := NewFileCouponRepo(t.TempDir(), 0)
+		require.NoError(t, err)
+		t.Cleanup(func() { repo.Close() })
+		test(t, repo)
+	})
+
+	t.Run("sql", func(t *testing.T) {
+		test(t, NewSQLCouponRepo(newTestDB(t)))
+	})
+}
+
+func newTestCoupon(code string, maxRedemptions, maxRedemptionsPerUser int) models.Coupon {
+	return models.Coupon{
+		Code:                  code,
+		Effect:                "percent_off",
+		Percent:               10,
+		MaxRedemptions:        maxRedemptions,
+		MaxRedemptionsPerUser: maxRedemptionsPerUser,
+	}
+}
+
+func TestCouponRepo_CreateCoupon_And_GetCoupon(t *testing.T) {
+	forEachCouponRepo(t, func(t *testing.T, repo CouponRepository) {
+		// Given
+		startsAt := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+		coupon := models.Coupon{
+			Code:        "TENOFF",
+			Effect:      "fixed_off",
+			Amount:      usd(10),
+			Category:    models.CoffeeCategory,
+			MinSubtotal: usd(50),
+			StartsAt:    &startsAt,
+		}
+
+		// When
+		created, err := repo.CreateCoupon(coupon)
+		stored, storedErr := repo.GetCoupon("TENOFF")
+		_, missingErr := repo.GetCoupon("NOPE")
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, coupon, created)
+		require.NoError(t, storedErr)
+		require.Equal(t, coupon, stored)
+		require.ErrorIs(t, missingErr, ErrCouponNotFound)
+	})
+}
+
+func TestCouponRepo_CreateCoupon_Duplicated(t *testing.T) {
+	forEachCouponRepo(t, func(t *testing.T, repo CouponRepository) {
+		// Given
+		_, err := repo.CreateCoupon(newTestCoupon("TENOFF", 0, 0))
+		require.NoError(t, err)
+
+		// When
+		_, err = repo.CreateCoupon(newTestCoupon("TENOFF", 0, 0))
+
+		// Then
+		require.ErrorIs(t, err, ErrCouponExists)
+	})
+}
+
+func TestCouponRepo_Redeem(t *testing.T) {
+	forEachCouponRepo(t, func(t *testing.T, repo CouponRepository) {
+		// Given
+		_, err := repo.CreateCoupon(newTestCoupon("TENOFF", 0, 0))
+		require.NoError(t, err)
+		_, err = repo.CreateCoupon(newTestCoupon("FREESHIP", 0, 0))
+		require.NoError(t, err)
+
+		// When
+		err = repo.Redeem(1, []models.Redemption{
+			{Code: "TENOFF", UserID: "user1", OrderID: 1},
+			{Code: "FREESHIP", UserID: "user1", OrderID: 1},
+		})
+		duplicatedErr := repo.Redeem(1, []models.Redemption{{Code: "TENOFF", UserID: "user1", OrderID: 1}})
+
+		// Then
+		require.NoError(t, err)
+		require.ErrorIs(t, duplicatedErr, ErrRedemptionExists)
+		coupon, _ := repo.GetCoupon("TENOFF")
+		require.Equal(t, 1, coupon.Redemptions)
+		count, err := repo.CountUserRedemptions("FREESHIP", "user1")
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+	})
+}
+
+func TestCouponRepo_Redeem_Limits(t *testing.T) {
+	tests := []struct {
+		name    string
+		coupon  models.Coupon
+		userID  string
+		message string
+	}{
+		{
+			name:    "no redemptions left",
+			coupon:  newTestCoupon("TENOFF", 1, 0),
+			userID:  "user2",
+			message: "coupon TENOFF has no redemptions left",
+		},
+		{
+			name:    "no redemptions left for the user",
+			coupon:  newTestCoupon("TENOFF", 0, 1),
+			userID:  "user1",
+			message: "user user1 has no redemptions of coupon TENOFF left",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachCouponRepo(t, func(t *testing.T, repo CouponRepository) {
+				// Given a coupon already redeemed by user1, and another one that can still be redeemed
+				_, err := repo.CreateCoupon(tt.coupon)
+				require.NoError(t, err)
+				_, err = repo.CreateCoupon(newTestCoupon("FREESHIP", 0, 0))
+				require.NoError(t, err)
+				require.NoError(t, repo.Redeem(1, []models.Redemption{{Code: "TENOFF", UserID: "user1", OrderID: 1}}))
+
+				// When
+				err = repo.Redeem(2, []models.Redemption{
+					{Code: "FREESHIP", UserID: tt.userID, OrderID: 2},
+					{Code: "TENOFF", UserID: tt.userID, OrderID: 2},
+				})
+
+				// Then nothing is redeemed
+				require.ErrorIs(t, err, ErrCouponExhausted)
+				require.EqualError(t, err, tt.message)
+				freeShipping, _ := repo.GetCoupon("FREESHIP")
+				require.Equal(t, 0, freeShipping.Redemptions)
+			})
+		})
+	}
+}
+
+func TestCouponRepo_ReleaseRedemptions(t *testing.T) {
+	forEachCouponRepo(t, func(t *testing.T, repo CouponRepository) {
+		// Given
+		_, err := repo.CreateCoupon(newTestCoupon("TENOFF", 1, 0))
+		require.NoError(t, err)
+		require.NoError(t, repo.Redeem(1, []models.Redemption{{Code: "TENOFF", UserID: "user1", OrderID: 1}}))
+
+		// When
+		err = repo.ReleaseRedemptions(1)
+		notRedeemedErr := repo.ReleaseRedemptions(2)
+
+		// Then the coupon can be redeemed again
+		require.NoError(t, err)
+		require.NoError(t, notRedeemedErr)
+		coupon, _ := repo.GetCoupon("TENOFF")
+		require.Equal(t, 0, coupon.Redemptions)
+		require.NoError(t, repo.Redeem(2, []models.Redemption{{Code: "TENOFF", UserID: "user1", OrderID: 2}}))
+	})
+}
+
+func TestFileCouponRepo_Survives_Restart(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	repo, err := NewFileCouponRepo(dir, 2)
+	require.NoError(t, err)
+	_, err = repo.CreateCoupon(newTestCoupon("TENOFF", 0, 0))
+	require.NoError(t, err)
+	require.NoError(t, repo.Redeem(1, []models.Redemption{{Code: "TENOFF", UserID: "user1", OrderID: 1}}))
+	require.NoError(t, repo.Redeem(2, []models.Redemption{{Code: "TENOFF", UserID: "user1", OrderID: 2}}))
+	require.NoError(t, repo.ReleaseRedemptions(1))
+	require.NoError(t, repo.Close())
+
+	// When
+	reopened, err := NewFileCouponRepo(dir, 2)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	// Then
+	coupon, err := reopened.GetCoupon("TENOFF")
+	require.NoError(t, err)
+	require.Equal(t, 1, coupon.Redemptions)
+	count, err := reopened.CountUserRedemptions("TENOFF", "user1")
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+var (
+	ErrCartNotFound        = errors.New("cart not found")
+	ErrProductNotInCart    = errors.New("product not in cart")
+	ErrCartCheckedOut      = errors.New("cart is checked out")
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrOrderExists         = errors.New("order already exists")
+	ErrOrderStatusStale    = errors.New("order status changed")
+	ErrProductNotFound     = errors.New("product not found")
+	ErrProductExists       = errors.New("product already exists")
+	ErrInsufficientStock   = errors.New("insufficient stock")
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrReservationExists   = errors.New("reservation already exists")
+	ErrCouponNotFound      = errors.New("coupon not found")
+	ErrCouponExists        = errors.New("coupon already exists")
+	ErrCouponExhausted     = errors.New("coupon exhausted")
+	ErrCouponNotInCart     = errors.New("coupon not in cart")
+	ErrRedemptionExists    = errors.New("redemption already exists")
+)
+
+// storageError describes what failed in its message, while errors.Is matches it against its kind.
+type storageError struct {
+	kind    error
+	message string
+}
+
+func (e *storageError) Error() string {
+	return e.message
+}
+
+func (e *storageError) Unwrap() error {
+	return e.kind
+}
+
+func cartNotFound(cartID string) error {
+	return &storageError{kind: ErrCartNotFound, message: fmt.Sprintf("cart with ID %v doesn't exist", cartID)}
+}
+
+func userCartNotFound(userID string) error {
+	return &storageError{kind: ErrCartNotFound, message: fmt.Sprintf("user %v doesn't have a cart", userID)}
+}
+
+func guestCartNotFound() error {
+	return &storageError{kind: ErrCartNotFound, message: "guest cart doesn't exist"}
+}
+
+func productNotInCart(product string) error {
+	return &storageError{kind: ErrProductNotInCart, message: fmt.Sprintf("product %v does not exist in cart", product)}
+}
+
+func cartCheckedOut(cartID string) error {
+	return &storageError{kind: ErrCartCheckedOut, message: fmt.Sprintf("cart with ID %v is already checked out", cartID)}
+}
+
+func orderNotFound(orderID int) error {
+	return &storageError{kind: ErrOrderNotFound, message: fmt.Sprintf("order %v doesn't exist", orderID)}
+}
+
+func orderAlreadyExists(orderID int) error {
+	return &storageError{kind: ErrOrderExists, message: fmt.Sprintf("order %v already exists", orderID)}
+}
+
+func orderStatusStale(orderID int, expected models.OrderStatus) error {
+	return &storageError{kind: ErrOrderStatusStale, message: fmt.Sprintf("order %v is no longer %v", orderID, expected)}
+}
+
+func productNotFound(sku string) error {
+	return &storageError{kind: ErrProductNotFound, message: fmt.Sprintf("product with SKU %v doesn't exist", sku)}
+}
+
+func productAlreadyExists(sku string) error {
+	return &storageError{kind: ErrProductExists, message: fmt.Sprintf("product with SKU %v already exists", sku)}
+}
+
+func productNameTaken(name string) error {
+	return &storageError{kind: ErrProductExists, message: fmt.Sprintf("product named %v already exists", name)}
+}
+
+func insufficientStock(sku string, available int) error {
+	return &storageError{kind: ErrInsufficientStock, message: fmt.Sprintf("only %v units of %v are available", available, sku)}
+}
+
+func stockReserved(sku string, reserved int) error {
+	return &storageError{kind: ErrInsufficientStock, message: fmt.Sprintf("%v units of %v are reserved", reserved, sku)}
+}
+
+func reservationNotFound(orderID int) error {
+	return &storageError{kind: ErrReservationNotFound, message: fmt.Sprintf("order %v has no stock reserved", orderID)}
+}
+
+func reservationAlreadyExists(orderID int) error {
+	return &storageError{kind: ErrReservationExists, message: fmt.Sprintf("order %v already has stock reserved", orderID)}
+}
+
+func couponNotFound(code string) error {
+	return &storageError{kind: ErrCouponNotFound, message: fmt.Sprintf("coupon %v doesn't exist", code)}
+}
+
+func couponAlreadyExists(code string) error {
+	return &storageError{kind: ErrCouponExists, message: fmt.Sprintf("coupon %v already exists", code)}
+}
+
+func couponExhausted(code string) error {
+	return &storageError{kind: ErrCouponExhausted, message: fmt.Sprintf("coupon %v has no redemptions left", code)}
+}
+
+func couponExhaustedByUser(code, userID string) error {
+	return &storageError{kind: ErrCouponExhausted, message: fmt.Sprintf("user %v has no redemptions of coupon %v left", userID, code)}
+}
+
+func couponNotInCart(code string) error {
+	return &storageError{kind: ErrCouponNotInCart, message: fmt.Sprintf("coupon %v is not applied to the cart", code)}
+}
+
+func redemptionAlreadyExists(orderID int) error {
+	return &storageError{kind: ErrRedemptionExists, message: fmt.Sprintf("order %v already redeemed coupons", orderID)}
+}
@@ -0,0 +1,257 @@
+package storage
+
+import (
+	"encoding/json"
+	"sync"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+const cartsJournalName = "carts"
+
+// PersistentCartRepository is a CartRepository backed by resources that must be released.
+type PersistentCartRepository interface {
+	CartRepository
+	Close() error
+}
+
+// logRecord is the full state of a cart after a change, along with the ID of the cart the change Removed, if any.
+// Deleting a cart only records its removal.
+type logRecord struct {
+	UserID  string      `json:"user_id"`
+	Cart    models.Cart `json:"cart"`
+	Removed string      `json:"removed,omitempty"`
+}
+
+// fileCartRepo keeps carts in memory and persists every change to a journal on disk before acknowledging it.
+type fileCartRepo struct {
+	mu      sync.Mutex
+	memory  *cartRepo
+	journal *journal
+}
+
+func NewFileCartRepo(dir string, snapshotEvery int) (PersistentCartRepository, error) {
+	repo := &fileCartRepo{
+		memory: newCartRepo(make(map[string]models.Cart)),
+	}
+
+	loadSnapshot := func(data []byte) error {
+		if err := json.Unmarshal(data, &repo.memory.repo); err != nil {
+			return err
+		}
+		repo.memory.reindex()
+		return nil
+	}
+
+	apply := func(data []byte) error {
+		var record logRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		if record.Removed != "" {
+			repo.memory.remove(record.Removed)
+		}
+		if record.Cart.ID != "" {
+			repo.memory.put(record.Cart)
+		}
+		return nil
+	}
+
+	var err error
+	repo.journal, err = openJournal(dir, cartsJournalName, snapshotEvery, loadSnapshot, apply)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+func (f *fileCartRepo) GetCartByID(cartID string) (models.Cart, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.memory.GetCartByID(cartID)
+}
+
+func (f *fileCartRepo) GetCartByUserID(userID string) (models.Cart, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.memory.GetCartByUserID(userID)
+}
+
+func (f *fileCartRepo) GetCartsByUserID(userID string) ([]models.Cart, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.memory.GetCartsByUserID(userID)
+}
+
+func (f *fileCartRepo) GetCartByGuestToken(token string) (models.Cart, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.memory.GetCartByGuestToken(token)
+}
+
+func (f *fileCartRepo) CreateCart(userID string, cart models.Cart) (models.Cart, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	createdCart, err := f.memory.CreateCart(userID, cart)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	if err := f.append(logRecord{UserID: userID, Cart: createdCart}); err != nil {
+		_ = f.memory.DeleteCart(createdCart.ID)
+		return models.Cart{}, err
+	}
+
+	return createdCart, nil
+}
+
+func (f *fileCartRepo) AddProduct(cartID string, product models.Product, quantity int) (models.Cart, error) {
+	return f.mutate(cartID, func() (models.Cart, error) {
+		return f.memory.AddProduct(cartID, product, quantity)
+	})
+}
+
+func (f *fileCartRepo) UpdateProductQuantity(cartID, product string, quantity int) (models.Cart, error) {
+	return f.mutate(cartID, func() (models.Cart, error) {
+		return f.memory.UpdateProductQuantity(cartID, product, quantity)
+	})
+}
+
+func (f *fileCartRepo) RemoveProduct(cartID, product string) (models.Cart, error) {
+	return f.mutate(cartID, func() (models.Cart, error) {
+		return f.memory.RemoveProduct(cartID, product)
+	})
+}
+
+func (f *fileCartRepo) SetFreeItems(cartID string, items []models.LineItem) (models.Cart, error) {
+	return f.mutate(cartID, func() (models.Cart, error) {
+		return f.memory.SetFreeItems(cartID, items)
+	})
+}
+
+func (f *fileCartRepo) UpdateCheckout(cartID string, checkout models.Checkout) (models.Cart, error) {
+	return f.mutate(cartID, func() (models.Cart, error) {
+		return f.memory.UpdateCheckout(cartID, checkout)
+	})
+}
+
+func (f *fileCartRepo) AddCoupon(cartID, code string) (models.Cart, error) {
+	return f.mutate(cartID, func() (models.Cart, error) {
+		return f.memory.AddCoupon(cartID, code)
+	})
+}
+
+func (f *fileCartRepo) RemoveCoupon(cartID, code string) (models.Cart, error) {
+	return f.mutate(cartID, func() (models.Cart, error) {
+		return f.memory.RemoveCoupon(cartID, code)
+	})
+}
+
+func (f *fileCartRepo) CheckoutCart(cartID string) (models.Cart, error) {
+	return f.mutate(cartID, func() (models.Cart, error) {
+		return f.memory.CheckoutCart(cartID)
+	})
+}
+
+func (f *fileCartRepo) ReopenCart(cartID string) (models.Cart, error) {
+	return f.mutate(cartID, func() (models.Cart, error) {
+		return f.memory.ReopenCart(cartID)
+	})
+}
+
+func (f *fileCartRepo) RenameCart(cartID, name string) (models.Cart, error) {
+	return f.mutate(cartID, func() (models.Cart, error) {
+		return f.memory.RenameCart(cartID, name)
+	})
+}
+
+// DeleteCart persists the removal of the cart, putting it back in memory if it couldn't be written to the journal.
+func (f *fileCartRepo) DeleteCart(cartID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	deleted, err := f.memory.GetCartByID(cartID)
+	if err != nil {
+		return err
+	}
+
+	if err := f.memory.DeleteCart(cartID); err != nil {
+		return err
+	}
+
+	if err := f.append(logRecord{Removed: cartID}); err != nil {
+		f.memory.save(deleted)
+		return err
+	}
+
+	return nil
+}
+
+// MergeCart persists the merged cart and the removal of the guest cart in a single record, so a restart never finds
+// the products of the guest cart in both carts.
+func (f *fileCartRepo) MergeCart(guestCartID, userID string, merge MergeFunc) (models.Cart, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var previous, guestCart models.Cart
+	mergedCart, err := f.memory.MergeCart(guestCartID, userID, func(userCart, guest models.Cart) (models.Cart, error) {
+		previous, guestCart = userCart, guest
+		return merge(userCart, guest)
+	})
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	if err := f.append(logRecord{UserID: mergedCart.UserID, Cart: mergedCart, Removed: guestCartID}); err != nil {
+		if previous.ID != "" {
+			f.memory.save(previous)
+		}
+		f.memory.save(guestCart)
+		return models.Cart{}, err
+	}
+
+	return mergedCart, nil
+}
+
+func (f *fileCartRepo) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.journal.close()
+}
+
+// mutate applies the change in memory and persists the resulting cart, rolling the change back if it
+// couldn't be written to the journal.
+func (f *fileCartRepo) mutate(cartID string, change func() (models.Cart, error)) (models.Cart, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	previous, err := f.memory.GetCartByID(cartID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	updatedCart, err := change()
+	if err != nil {
+		f.memory.save(previous)
+		return models.Cart{}, err
+	}
+
+	if err := f.append(logRecord{UserID: updatedCart.UserID, Cart: updatedCart}); err != nil {
+		f.memory.save(previous)
+		return models.Cart{}, err
+	}
+
+	return updatedCart, nil
+}
+
+func (f *fileCartRepo) append(record logRecord) error {
+	return f.journal.append(record, func() ([]byte, error) {
+		return json.Marshal(f.memory.repo)
+	})
+}
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+var testCoffee = models.Product{Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10), Weight: 250}
+
+func TestFileCartRepo_Recovers_After_Restart(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	repo, err := NewFileCartRepo(dir, 0)
+	require.NoError(t, err)
+	_, err = repo.CreateCart("user1", models.Cart{ID: "cart1", UserID: "user1"})
+	require.NoError(t, err)
+	_, err = repo.AddProduct("cart1", testCoffee, 1)
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+
+	// When
+	reopened, err := NewFileCartRepo(dir, 0)
+	require.NoError(t, err)
+	defer reopened.Close()
+	cart, err := reopened.GetCartByID("cart1")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []models.LineItem{{Product: testCoffee, Quantity: 1}}, cart.Items)
+}
+
+func TestFileCartRepo_CreateCart_Error(t *testing.T) {
+	// Given a log that can no longer be written
+	repo, err := NewFileCartRepo(t.TempDir(), 0)
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+
+	// When
+	_, err = repo.CreateCart("user1", models.Cart{ID: "cart1", UserID: "user1"})
+
+	// Then
+	require.Error(t, err)
+	_, err = repo.GetCartByID("cart1")
+	require.EqualError(t, err, "cart with ID cart1 doesn't exist")
+}
+
+func TestFileCartRepo_Recovers_Merged_Cart_After_Restart(t *testing.T) {
+	// Given a guest cart merged into the cart of a user
+	dir := t.TempDir()
+	repo, err := NewFileCartRepo(dir, 0)
+	require.NoError(t, err)
+	_, err = repo.CreateCart("", models.Cart{ID: "guest1", GuestToken: "token1"})
+	require.NoError(t, err)
+	_, err = repo.CreateCart("user1", models.Cart{ID: "cart1", UserID: "user1"})
+	require.NoError(t, err)
+	_, err = repo.MergeCart("guest1", "user1", func(userCart, _ models.Cart) (models.Cart, error) {
+		userCart.Items = []models.LineItem{{Product: testCoffee, Quantity: 1}}
+		return userCart, nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+
+	// When
+	reopened, err := NewFileCartRepo(dir, 0)
+	require.NoError(t, err)
+	defer reopened.Close()
+	cart, err := reopened.GetCartByUserID("user1")
+	_, guestErr := reopened.GetCartByID("guest1")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []models.LineItem{{Product: testCoffee, Quantity: 1}}, cart.Items)
+	require.ErrorIs(t, guestErr, ErrCartNotFound)
+}
+
+func TestFileCartRepo_Recovers_Deleted_Cart_After_Restart(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	repo, err := NewFileCartRepo(dir, 0)
+	require.NoError(t, err)
+	_, err = repo.CreateCart("user1", models.Cart{ID: "cart1", UserID: "user1", Name: "office"})
+	require.NoError(t, err)
+	_, err = repo.CreateCart("user1", models.Cart{ID: "cart2", UserID: "user1", Name: "home"})
+	require.NoError(t, err)
+	require.NoError(t, repo.DeleteCart("cart1"))
+	require.NoError(t, repo.Close())
+
+	// When
+	reopened, err := NewFileCartRepo(dir, 0)
+	require.NoError(t, err)
+	defer reopened.Close()
+	carts, err := reopened.GetCartsByUserID("user1")
+	_, deletedErr := reopened.GetCartByID("cart1")
+
+	// Then
+	require.NoError(t, err)
+	require.Len(t, carts, 1)
+	require.Equal(t, "home", carts[0].Name)
+	require.ErrorIs(t, deletedErr, ErrCartNotFound)
+}
+
+func TestFileCartRepo_Recovers_From_Snapshot(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	repo, err := NewFileCartRepo(dir, 2)
+	require.NoError(t, err)
+	_, err = repo.CreateCart("user1", models.Cart{ID: "cart1", UserID: "user1"})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = repo.AddProduct("cart1", testCoffee, 1)
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.Close())
+
+	// When
+	reopened, err := NewFileCartRepo(dir, 2)
+	require.NoError(t, err)
+	defer reopened.Close()
+	cart, err := reopened.GetCartByID("cart1")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 3, cart.Items[0].Quantity)
+	_, err = os.Stat(filepath.Join(dir, cartsJournalName+".snapshot"))
+	require.NoError(t, err)
+}
+
+func TestFileCartRepo_Keeps_Changes_When_Snapshot_Fails(t *testing.T) {
+	// Given a snapshot that can't be replaced
+	dir := t.TempDir()
+	repo, err := NewFileCartRepo(dir, 2)
+	require.NoError(t, err)
+	snapshotPath := filepath.Join(dir, cartsJournalName+".snapshot")
+	require.NoError(t, os.MkdirAll(filepath.Join(snapshotPath, "in-the-way"), 0o755))
+
+	// When
+	_, createErr := repo.CreateCart("user1", models.Cart{ID: "cart1", UserID: "user1"})
+	_, addErr := repo.AddProduct("cart1", testCoffee, 1)
+	cart, err := repo.GetCartByID("cart1")
+
+	// Then
+	require.NoError(t, createErr)
+	require.NoError(t, addErr)
+	require.NoError(t, err)
+	require.Equal(t, 1, cart.Items[0].Quantity)
+
+	require.NoError(t, repo.Close())
+	require.NoError(t, os.RemoveAll(snapshotPath))
+	reopened, err := NewFileCartRepo(dir, 2)
+	require.NoError(t, err)
+	defer reopened.Close()
+	cart, err = reopened.GetCartByID("cart1")
+	require.NoError(t, err)
+	require.Equal(t, 1, cart.Items[0].Quantity)
+}
+
+func TestFileCartRepo_Discards_Partial_Write(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	repo, err := NewFileCartRepo(dir, 0)
+	require.NoError(t, err)
+	_, err = repo.CreateCart("user1", models.Cart{ID: "cart1", UserID: "user1"})
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+
+	// Simulate a crash in the middle of writing a record
+	log, err := os.OpenFile(filepath.Join(dir, cartsJournalName+".log"), os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = log.WriteString(`1234abcd {"user_id":"user1","cart":{"id":"ca`)
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+
+	// When
+	reopened, err := NewFileCartRepo(dir, 0)
+	require.NoError(t, err)
+	defer reopened.Close()
+	_, addErr := reopened.AddProduct("cart1", testCoffee, 1)
+	cart, err := reopened.GetCartByID("cart1")
+
+	// Then
+	require.NoError(t, addErr)
+	require.NoError(t, err)
+	require.Equal(t, 1, cart.Items[0].Quantity)
+}
+
+func TestFileCartRepo_Corrupted_Log(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	content := "00000000 {}\n" + "00000000 {}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, cartsJournalName+".log"), []byte(content), 0o644))
+
+	// When
+	_, err := NewFileCartRepo(dir, 0)
+
+	// Then
+	require.Error(t, err)
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"encoding/json"
+	"sync"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+const couponsJournalName = "coupons"
+
+// PersistentCouponRepository is a CouponRepository backed by resources that must be released.
+type PersistentCouponRepository interface {
+	CouponRepository
+	Close() error
+}
+
+// couponSnapshot is the whole state of the coupons.
+type couponSnapshot struct {
+	Coupons     map[string]models.Coupon    `json:"coupons"`
+	Redemptions map[int][]models.Redemption `json:"redemptions"`
+}
+
+// fileCouponRepo keeps the coupons in memory and persists every change to a journal on disk before acknowledging it.
+type fileCouponRepo struct {
+	mu      sync.Mutex
+	memory  *couponRepo
+	journal *journal
+}
+
+func NewFileCouponRepo(dir string, snapshotEvery int) (PersistentCouponRepository, error) {
+	repo := &fileCouponRepo{
+		memory: newCouponRepo(),
+	}
+
+	loadSnapshot := func(data []byte) error {
+		snapshot := couponSnapshot{Coupons: repo.memory.coupons, Redemptions: repo.memory.redemptions}
+		return json.Unmarshal(data, &snapshot)
+	}
+
+	apply := func(data []byte) error {
+		var change couponChange
+		if err := json.Unmarshal(data, &change); err != nil {
+			return err
+		}
+		repo.memory.apply(change)
+		return nil
+	}
+
+	var err error
+	repo.journal, err = openJournal(dir, couponsJournalName, snapshotEvery, loadSnapshot, apply)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+func (f *fileCouponRepo) CreateCoupon(coupon models.Coupon) (models.Coupon, error) {
+	err := f.mutate(func() (couponChange, error) {
+		return f.memory.create(coupon)
+	})
+	if err != nil {
+		return models.Coupon{}, err
+	}
+
+	return cloneCoupon(coupon), nil
+}
+
+func (f *fileCouponRepo) GetCoupon(code string) (models.Coupon, error) {
+	return f.memory.GetCoupon(code)
+}
+
+func (f *fileCouponRepo) CountUserRedemptions(code, userID string) (int, error) {
+	return f.memory.CountUserRedemptions(code, userID)
+}
+
+func (f *fileCouponRepo) Redeem(orderID int, redemptions []models.Redemption) error {
+	return f.mutate(func() (couponChange, error) {
+		return f.memory.redeem(orderID, redemptions)
+	})
+}
+
+func (f *fileCouponRepo) ReleaseRedemptions(orderID int) error {
+	return f.mutate(func() (couponChange, error) {
+		return f.memory.release(orderID), nil
+	})
+}
+
+func (f *fileCouponRepo) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.journal.close()
+}
+
+// mutate computes the change, persists it to the journal and only then applies it in memory. Changes that change
+// nothing aren't persisted.
+func (f *fileCouponRepo) mutate(compute func() (couponChange, error)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.memory.mu.Lock()
+	defer f.memory.mu.Unlock()
+
+	change, err := compute()
+	if err != nil || change.isEmpty() {
+		return err
+	}
+
+	// Applying a change is idempotent, so the snapshot can include it before it's applied below
+	err = f.journal.append(change, func() ([]byte, error) {
+		f.memory.apply(change)
+		return json.Marshal(couponSnapshot{Coupons: f.memory.coupons, Redemptions: f.memory.redemptions})
+	})
+	if err != nil {
+		return err
+	}
+
+	f.memory.apply(change)
+	return nil
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+const inventoryJournalName = "inventory"
+
+// PersistentInventoryRepository is an InventoryRepository backed by resources that must be released.
+type PersistentInventoryRepository interface {
+	InventoryRepository
+	Close() error
+}
+
+// inventorySnapshot is the whole state of the inventory.
+type inventorySnapshot struct {
+	Stock        map[string]models.Stock    `json:"stock"`
+	Reservations map[int]models.Reservation `json:"reservations"`
+}
+
+// fileInventoryRepo keeps the inventory in memory and persists every change to a journal on disk before
+// acknowledging it.
+type fileInventoryRepo struct {
+	mu      sync.Mutex
+	memory  *inventoryRepo
+	journal *journal
+}
+
+func NewFileInventoryRepo(dir string, snapshotEvery int) (PersistentInventoryRepository, error) {
+	repo := &fileInventoryRepo{
+		memory: newInventoryRepo(),
+	}
+
+	loadSnapshot := func(data []byte) error {
+		snapshot := inventorySnapshot{Stock: repo.memory.stock, Reservations: repo.memory.reservations}
+		return json.Unmarshal(data, &snapshot)
+	}
+
+	apply := func(data []byte) error {
+		var change inventoryChange
+		if err := json.Unmarshal(data, &change); err != nil {
+			return err
+		}
+		repo.memory.apply(change)
+		return nil
+	}
+
+	var err error
+	repo.journal, err = openJournal(dir, inventoryJournalName, snapshotEvery, loadSnapshot, apply)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+func (f *fileInventoryRepo) SetStock(sku string, onHand int) (models.Stock, error) {
+	var stock models.Stock
+	err := f.mutate(func() (inventoryChange, error) {
+		change, err := f.memory.restock(sku, onHand)
+		if err == nil {
+			stock = change.Stock[0]
+		}
+		return change, err
+	})
+
+	return stock, err
+}
+
+func (f *fileInventoryRepo) GetStock(sku string) (models.Stock, error) {
+	return f.memory.GetStock(sku)
+}
+
+func (f *fileInventoryRepo) CreateReservation(reservation models.Reservation) error {
+	return f.mutate(func() (inventoryChange, error) {
+		return f.memory.reserve(reservation)
+	})
+}
+
+func (f *fileInventoryRepo) CommitReservation(orderID int) error {
+	return f.mutate(func() (inventoryChange, error) {
+		return f.memory.settle(orderID, true)
+	})
+}
+
+func (f *fileInventoryRepo) ReleaseReservation(orderID int) error {
+	return f.mutate(func() (inventoryChange, error) {
+		return f.memory.settle(orderID, false)
+	})
+}
+
+func (f *fileInventoryRepo) GetReservation(orderID int) (models.Reservation, error) {
+	return f.memory.GetReservation(orderID)
+}
+
+func (f *fileInventoryRepo) GetReservationsExpiredAt(now time.Time) ([]models.Reservation, error) {
+	return f.memory.GetReservationsExpiredAt(now)
+}
+
+func (f *fileInventoryRepo) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.journal.close()
+}
+
+// mutate computes the change, persists it to the journal and only then applies it in memory.
+func (f *fileInventoryRepo) mutate(compute func() (inventoryChange, error)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.memory.mu.Lock()
+	defer f.memory.mu.Unlock()
+
+	change, err := compute()
+	if err != nil {
+		return err
+	}
+
+	// Applying a change is idempotent, so the snapshot can include it before it's applied below
+	err = f.journal.append(change, func() ([]byte, error) {
+		f.memory.apply(change)
+		return json.Marshal(inventorySnapshot{Stock: f.memory.stock, Reservations: f.memory.reservations})
+	})
+	if err != nil {
+		return err
+	}
+
+	f.memory.apply(change)
+	return nil
+}
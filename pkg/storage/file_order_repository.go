@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"encoding/json"
+	"sync"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+const ordersJournalName = "orders"
+
+// PersistentOrderRepository is an OrderRepository backed by resources that must be released.
+type PersistentOrderRepository interface {
+	OrderRepository
+	Close() error
+}
+
+// fileOrderRepo keeps orders in memory and persists every change to a journal on disk before acknowledging it.
+type fileOrderRepo struct {
+	mu      sync.Mutex
+	memory  *orderRepo
+	journal *journal
+}
+
+func NewFileOrderRepo(dir string, snapshotEvery int) (PersistentOrderRepository, error) {
+	repo := &fileOrderRepo{
+		memory: newOrderRepo(),
+	}
+
+	loadSnapshot := func(data []byte) error {
+		if err := json.Unmarshal(data, &repo.memory.orders); err != nil {
+			return err
+		}
+		repo.memory.reindex()
+		return nil
+	}
+
+	apply := func(data []byte) error {
+		var order models.Order
+		if err := json.Unmarshal(data, &order); err != nil {
+			return err
+		}
+		repo.memory.put(order)
+		return nil
+	}
+
+	var err error
+	repo.journal, err = openJournal(dir, ordersJournalName, snapshotEvery, loadSnapshot, apply)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+func (f *fileOrderRepo) CreateOrder(order models.Order) (models.Order, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.memory.GetOrderByID(order.Totals.Order); err == nil {
+		return models.Order{}, orderAlreadyExists(order.Totals.Order)
+	}
+
+	if err := f.append(order); err != nil {
+		return models.Order{}, err
+	}
+
+	return f.memory.CreateOrder(order)
+}
+
+func (f *fileOrderRepo) UpdateOrderStatus(orderID int, expected models.OrderStatus, change models.StatusChange) (models.Order, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	order, err := f.memory.GetOrderByID(orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	updated, err := changeStatus(order, expected, change)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	if err := f.append(updated); err != nil {
+		return models.Order{}, err
+	}
+
+	return f.memory.UpdateOrderStatus(orderID, expected, change)
+}
+
+func (f *fileOrderRepo) GetOrderByID(orderID int) (models.Order, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.memory.GetOrderByID(orderID)
+}
+
+func (f *fileOrderRepo) GetOrdersByUserID(userID string) ([]models.Order, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.memory.GetOrdersByUserID(userID)
+}
+
+func (f *fileOrderRepo) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.journal.close()
+}
+
+func (f *fileOrderRepo) append(order models.Order) error {
+	return f.journal.append(order, func() ([]byte, error) {
+		orders := make(map[int]models.Order, len(f.memory.orders)+1)
+		for orderID, stored := range f.memory.orders {
+			orders[orderID] = stored
+		}
+		orders[order.Totals.Order] = order
+		return json.Marshal(orders)
+	})
+}
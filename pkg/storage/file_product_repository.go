@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"encoding/json"
+	"sync"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+const productsJournalName = "products"
+
+// PersistentProductRepository is a ProductRepository backed by resources that must be released.
+type PersistentProductRepository interface {
+	ProductRepository
+	Close() error
+}
+
+// productRecord is the state of a product after a change. Deleted products have no state.
+type productRecord struct {
+	SKU     string          `json:"sku"`
+	Product *models.Product `json:"product"`
+}
+
+// fileProductRepo keeps the products in memory and persists every change to a journal on disk before
+// acknowledging it.
+type fileProductRepo struct {
+	mu      sync.Mutex
+	memory  *productRepo
+	journal *journal
+}
+
+func NewFileProductRepo(dir string, snapshotEvery int) (PersistentProductRepository, error) {
+	repo := &fileProductRepo{
+		memory: newProductRepo(),
+	}
+
+	loadSnapshot := func(data []byte) error {
+		return json.Unmarshal(data, &repo.memory.products)
+	}
+
+	apply := func(data []byte) error {
+		var record productRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		repo.memory.apply(record)
+		return nil
+	}
+
+	var err error
+	repo.journal, err = openJournal(dir, productsJournalName, snapshotEvery, loadSnapshot, apply)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+func (f *fileProductRepo) CreateProduct(product models.Product) (models.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.memory.mu.RLock()
+	err := f.memory.checkCreate(product)
+	f.memory.mu.RUnlock()
+	if err != nil {
+		return models.Product{}, err
+	}
+
+	if err := f.append(productRecord{SKU: product.SKU, Product: &product}); err != nil {
+		return models.Product{}, err
+	}
+
+	return f.memory.CreateProduct(product)
+}
+
+func (f *fileProductRepo) UpdateProduct(product models.Product) (models.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.memory.mu.RLock()
+	err := f.memory.checkUpdate(product)
+	f.memory.mu.RUnlock()
+	if err != nil {
+		return models.Product{}, err
+	}
+
+	if err := f.append(productRecord{SKU: product.SKU, Product: &product}); err != nil {
+		return models.Product{}, err
+	}
+
+	return f.memory.UpdateProduct(product)
+}
+
+func (f *fileProductRepo) DeleteProduct(sku string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.memory.GetProductBySKU(sku); err != nil {
+		return err
+	}
+
+	if err := f.append(productRecord{SKU: sku}); err != nil {
+		return err
+	}
+
+	return f.memory.DeleteProduct(sku)
+}
+
+func (f *fileProductRepo) GetProductBySKU(sku string) (models.Product, error) {
+	return f.memory.GetProductBySKU(sku)
+}
+
+func (f *fileProductRepo) GetProducts() ([]models.Product, error) {
+	return f.memory.GetProducts()
+}
+
+func (f *fileProductRepo) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.journal.close()
+}
+
+func (f *fileProductRepo) append(record productRecord) error {
+	return f.journal.append(record, func() ([]byte, error) {
+		f.memory.mu.RLock()
+		defer f.memory.mu.RUnlock()
+
+		products := make(map[string]models.Product, len(f.memory.products)+1)
+		for sku, product := range f.memory.products {
+			products[sku] = product
+		}
+		if record.Product != nil {
+			products[record.SKU] = *record.Product
+		} else {
+			delete(products, record.SKU)
+		}
+		return json.Marshal(products)
+	})
+}
@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"time"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+// InventoryRepository keeps the stock of every product by SKU and the stock reserved for every order.
+// Products without stock have none available.
+type InventoryRepository interface {
+	SetStock(sku string, onHand int) (models.Stock, error)
+	GetStock(sku string) (models.Stock, error)
+	// CreateReservation reserves all the units of the reservation or none of them, failing with
+	// ErrInsufficientStock when any product doesn't have enough units available.
+	CreateReservation(reservation models.Reservation) error
+	// CommitReservation takes the reserved units out of the stock, since they've been sold.
+	CommitReservation(orderID int) error
+	// ReleaseReservation makes the reserved units available again.
+	ReleaseReservation(orderID int) error
+	GetReservation(orderID int) (models.Reservation, error)
+	// GetReservationsExpiredAt returns the reservations expired at the given time, oldest first.
+	GetReservationsExpiredAt(now time.Time) ([]models.Reservation, error)
+}
+
+// inventoryRepo keeps the stock and the reservations in memory. It's safe for concurrent use.
+type inventoryRepo struct {
+	mu           sync.RWMutex
+	stock        map[string]models.Stock
+	reservations map[int]models.Reservation
+}
+
+func NewInventoryRepo() InventoryRepository {
+	return newInventoryRepo()
+}
+
+func newInventoryRepo() *inventoryRepo {
+	return &inventoryRepo{
+		stock:        make(map[string]models.Stock),
+		reservations: make(map[int]models.Reservation),
+	}
+}
+
+func (i *inventoryRepo) SetStock(sku string, onHand int) (models.Stock, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	change, err := i.restock(sku, onHand)
+	if err != nil {
+		return models.Stock{}, err
+	}
+
+	i.apply(change)
+	return change.Stock[0], nil
+}
+
+func (i *inventoryRepo) GetStock(sku string) (models.Stock, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return i.getStock(sku), nil
+}
+
+func (i *inventoryRepo) CreateReservation(reservation models.Reservation) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	change, err := i.reserve(reservation)
+	if err != nil {
+		return err
+	}
+
+	i.apply(change)
+	return nil
+}
+
+func (i *inventoryRepo) CommitReservation(orderID int) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	change, err := i.settle(orderID, true)
+	if err != nil {
+		return err
+	}
+
+	i.apply(change)
+	return nil
+}
+
+func (i *inventoryRepo) ReleaseReservation(orderID int) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	change, err := i.settle(orderID, false)
+	if err != nil {
+		return err
+	}
+
+	i.apply(change)
+	return nil
+}
+
+func (i *inventoryRepo) GetReservation(orderID int) (models.Reservation, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	reservation, ok := i.reservations[orderID]
+	if !ok {
+		return models.Reservation{}, reservationNotFound(orderID)
+	}
+
+	return cloneReservation(reservation), nil
+}
+
+func (i *inventoryRepo) GetReservationsExpiredAt(now time.Time) ([]models.Reservation, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	expired := []models.Reservation{}
+	for _, reservation := range i.reservations {
+		if !reservation.ExpiresAt.After(now) {
+			expired = append(expired, cloneReservation(reservation))
+		}
+	}
+
+	sort.Slice(expired, func(a, b int) bool {
+		if expired[a].ExpiresAt.Equal(expired[b].ExpiresAt) {
+			return expired[a].OrderID < expired[b].OrderID
+		}
+		return expired[a].ExpiresAt.Before(expired[b].ExpiresAt)
+	})
+
+	return expired, nil
+}
+
+// inventoryChange is the outcome of a change to the inventory: the stock of the products it touched, along with
+// the reservation it created or the order whose reservation it removed.
+type inventoryChange struct {
+	Stock       []models.Stock      `json:"stock"`
+	Reservation *models.Reservation `json:"reservation,omitempty"`
+	Removed     *int                `json:"removed,omitempty"`
+}
+
+// The methods below compute the change without applying it, so the file repository can persist it first.
+// The caller must hold mu.
+
+func (i *inventoryRepo) getStock(sku string) models.Stock {
+	if stock, ok := i.stock[sku]; ok {
+		return stock
+	}
+
+	return models.Stock{SKU: sku}
+}
+
+func (i *inventoryRepo) restock(sku string, onHand int) (inventoryChange, error) {
+	stock := i.getStock(sku)
+	if onHand < stock.Reserved {
+		return inventoryChange{}, stockReserved(sku, stock.Reserved)
+	}
+
+	stock.OnHand = onHand
+	return inventoryChange{Stock: []models.Stock{stock}}, nil
+}
+
+func (i *inventoryRepo) reserve(reservation models.Reservation) (inventoryChange, error) {
+	if _, ok := i.reservations[reservation.OrderID]; ok {
+		return inventoryChange{}, reservationAlreadyExists(reservation.OrderID)
+	}
+
+	stock := make([]models.Stock, 0, len(reservation.Items))
+	for _, sku := range sortedSKUs(reservation.Items) {
+		current := i.getStock(sku)
+		if current.Available() < reservation.Items[sku] {
+			return inventoryChange{}, insufficientStock(sku, current.Available())
+		}
+
+		current.Reserved += reservation.Items[sku]
+		stock = append(stock, current)
+	}
+
+	reservation = cloneReservation(reservation)
+	return inventoryChange{Stock: stock, Reservation: &reservation}, nil
+}
+
+// settle removes the reservation of the order, taking its units out of the stock when they've been sold.
+func (i *inventoryRepo) settle(orderID int, sold bool) (inventoryChange, error) {
+	reservation, ok := i.reservations[orderID]
+	if !ok {
+		return inventoryChange{}, reservationNotFound(orderID)
+	}
+
+	stock := make([]models.Stock, 0, len(reservation.Items))
+	for _, sku := range sortedSKUs(reservation.Items) {
+		current := i.getStock(sku)
+		current.Reserved -= reservation.Items[sku]
+		if sold {
+			current.OnHand -= reservation.Items[sku]
+		}
+		stock = append(stock, current)
+	}
+
+	return inventoryChange{Stock: stock, Removed: &orderID}, nil
+}
+
+func (i *inventoryRepo) apply(change inventoryChange) {
+	for _, stock := range change.Stock {
+		i.stock[stock.SKU] = stock
+	}
+
+	if change.Reservation != nil {
+		i.reservations[change.Reservation.OrderID] = *change.Reservation
+	}
+
+	if change.Removed != nil {
+		delete(i.reservations, *change.Removed)
+	}
+}
+
+func sortedSKUs(items map[string]int) []string {
+	skus := make([]string, 0, len(items))
+	for sku := range items {
+		skus = append(skus, sku)
+	}
+	sort.Strings(skus)
+
+	return skus
+}
+
+func cloneReservation(reservation models.Reservation) models.Reservation {
+	items := make(map[string]int, len(reservation.Items))
+	for sku, quantity := range reservation.Items {
+		items[sku] = quantity
+	}
+	reservation.Items = items
+
+	return reservation
+}
@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+// forEachInventoryRepo runs the test against every InventoryRepository implementation.
+func forEachInventoryRepo(t *testing.T, test func(t *testing.T, repo InventoryRepository)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewInventoryRepo())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		repo, err := NewFileInventoryRepo(t.TempDir(), 0)
+		require.NoError(t, err)
+		t.Cleanup(func() { repo.Close() })
+		test(t, repo)
+	})
+
+	t.Run("sql", func(t *testing.T) {
+		test(t, NewSQLInventoryRepo(newTestDB(t)))
+	})
+}
+
+func newTestReservation(orderID int, items map[string]int, expiresAt time.Time) models.Reservation {
+	return models.Reservation{OrderID: orderID, Items: items, ExpiresAt: expiresAt.UTC().Truncate(time.Millisecond)}
+}
+
+func TestInventoryRepo_SetStock_And_GetStock(t *testing.T) {
+	forEachInventoryRepo(t, func(t *testing.T, repo InventoryRepository) {
+		// Given
+		_, err := repo.SetStock("COF-001", 10)
+		require.NoError(t, err)
+
+		// When
+		stock, err := repo.GetStock("COF-001")
+		unknown, unknownErr := repo.GetStock("COF-002")
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, models.Stock{SKU: "COF-001", OnHand: 10}, stock)
+		require.NoError(t, unknownErr)
+		require.Equal(t, 0, unknown.Available())
+	})
+}
+
+func TestInventoryRepo_CreateReservation(t *testing.T) {
+	forEachInventoryRepo(t, func(t *testing.T, repo InventoryRepository) {
+		// Given
+		_, err := repo.SetStock("COF-001", 10)
+		require.NoError(t, err)
+		_, err = repo.SetStock("ACC-001", 3)
+		require.NoError(t, err)
+		reservation := newTestReservation(1, map[string]int{"COF-001": 4, "ACC-001": 3}, time.Now().Add(time.Minute))
+
+		// When
+		err = repo.CreateReservation(reservation)
+
+		// Then
+		require.NoError(t, err)
+		coffee, _ := repo.GetStock("COF-001")
+		require.Equal(t, 6, coffee.Available())
+		mugs, _ := repo.GetStock("ACC-001")
+		require.Equal(t, 0, mugs.Available())
+		stored, err := repo.GetReservation(1)
+		require.NoError(t, err)
+		require.Equal(t, reservation.Items, stored.Items)
+		require.True(t, reservation.ExpiresAt.Equal(stored.ExpiresAt))
+	})
+}
+
+func TestInventoryRepo_CreateReservation_Insufficient_Stock_Reserves_Nothing(t *testing.T) {
+	forEachInventoryRepo(t, func(t *testing.T, repo InventoryRepository) {
+		// Given
+		_, err := repo.SetStock("ACC-001", 5)
+		require.NoError(t, err)
+		_, err = repo.SetStock("COF-001", 1)
+		require.NoError(t, err)
+
+		// When
+		err = repo.CreateReservation(newTestReservation(1, map[string]int{"ACC-001": 2, "COF-001": 2}, time.Now()))
+
+		// Then
+		require.ErrorIs(t, err, ErrInsufficientStock)
+		require.EqualError(t, err, "only 1 units of COF-001 are available")
+		mugs, _ := repo.GetStock("ACC-001")
+		require.Equal(t, 5, mugs.Available())
+		_, err = repo.GetReservation(1)
+		require.ErrorIs(t, err, ErrReservationNotFound)
+	})
+}
+
+func TestInventoryRepo_CreateReservation_Duplicated(t *testing.T) {
+	forEachInventoryRepo(t, func(t *testing.T, repo InventoryRepository) {
+		// Given
+		_, err := repo.SetStock("COF-001", 10)
+		require.NoError(t, err)
+		require.NoError(t, repo.CreateReservation(newTestReservation(1, map[string]int{"COF-001": 1}, time.Now())))
+
+		// When
+		err = repo.CreateReservation(newTestReservation(1, map[string]int{"COF-001": 1}, time.Now()))
+
+		// Then
+		require.ErrorIs(t, err, ErrReservationExists)
+	})
+}
+
+func TestInventoryRepo_CommitReservation(t *testing.T) {
+	forEachInventoryRepo(t, func(t *testing.T, repo InventoryRepository) {
+		// Given
+		_, err := repo.SetStock("COF-001", 10)
+		require.NoError(t, err)
+		require.NoError(t, repo.CreateReservation(newTestReservation(1, map[string]int{"COF-001": 4}, time.Now())))
+
+		// When
+		err = repo.CommitReservation(1)
+
+		// Then
+		require.NoError(t, err)
+		stock, _ := repo.GetStock("COF-001")
+		require.Equal(t, models.Stock{SKU: "COF-001", OnHand: 6}, stock)
+		require.ErrorIs(t, repo.CommitReservation(1), ErrReservationNotFound)
+	})
+}
+
+func TestInventoryRepo_ReleaseReservation(t *testing.T) {
+	forEachInventoryRepo(t, func(t *testing.T, repo InventoryRepository) {
+		// Given
+		_, err := repo.SetStock("COF-001", 10)
+		require.NoError(t, err)
+		require.NoError(t, repo.CreateReservation(newTestReservation(1, map[string]int{"COF-001": 4}, time.Now())))
+
+		// When
+		err = repo.ReleaseReservation(1)
+
+		// Then
+		require.NoError(t, err)
+		stock, _ := repo.GetStock("COF-001")
+		require.Equal(t, models.Stock{SKU: "COF-001", OnHand: 10}, stock)
+		require.ErrorIs(t, repo.ReleaseReservation(1), ErrReservationNotFound)
+	})
+}
+
+func TestInventoryRepo_SetStock_Below_Reserved(t *testing.T) {
+	forEachInventoryRepo(t, func(t *testing.T, repo InventoryRepository) {
+		// Given
+		_, err := repo.SetStock("COF-001", 10)
+		require.NoError(t, err)
+		require.NoError(t, repo.CreateReservation(newTestReservation(1, map[string]int{"COF-001": 4}, time.Now())))
+
+		// When
+		_, err = repo.SetStock("COF-001", 3)
+
+		// Then
+		require.ErrorIs(t, err, ErrInsufficientStock)
+		stock, _ := repo.GetStock("COF-001")
+		require.Equal(t, 10, stock.OnHand)
+	})
+}
+
+func TestInventoryRepo_GetReservationsExpiredAt(t *testing.T) {
+	forEachInventoryRepo(t, func(t *testing.T, repo InventoryRepository) {
+		// Given
+		now := time.Now()
+		_, err := repo.SetStock("COF-001", 10)
+		require.NoError(t, err)
+		for orderID, expiresAt := range map[int]time.Time{1: now.Add(-time.Minute), 2: now.Add(time.Minute), 3: now.Add(-time.Hour)} {
+			require.NoError(t, repo.CreateReservation(newTestReservation(orderID, map[string]int{"COF-001": 1}, expiresAt)))
+		}
+
+		// When
+		expired, err := repo.GetReservationsExpiredAt(now)
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, expired, 2)
+		require.Equal(t, 3, expired[0].OrderID)
+		require.Equal(t, 1, expired[1].OrderID)
+	})
+}
+
+func TestFileInventoryRepo_Recovers_After_Restart(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	repo, err := NewFileInventoryRepo(dir, 2)
+	require.NoError(t, err)
+	_, err = repo.SetStock("COF-001", 10)
+	require.NoError(t, err)
+	require.NoError(t, repo.CreateReservation(newTestReservation(1, map[string]int{"COF-001": 4}, time.Now())))
+	require.NoError(t, repo.CreateReservation(newTestReservation(2, map[string]int{"COF-001": 1}, time.Now())))
+	require.NoError(t, repo.CommitReservation(1))
+	require.NoError(t, repo.Close())
+
+	// When
+	reopened, err := NewFileInventoryRepo(dir, 2)
+	require.NoError(t, err)
+	defer reopened.Close()
+	stock, err := reopened.GetStock("COF-001")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, models.Stock{SKU: "COF-001", OnHand: 6, Reserved: 1}, stock)
+	_, err = reopened.GetReservation(2)
+	require.NoError(t, err)
+}
@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const defaultSnapshotEvery = 1000
+
+// journal is an append-only log of JSON records on disk, compacted into a snapshot every snapshotEvery records.
+// Records must hold the full state of what changed, so replaying a record already included in a snapshot
+// has no side effects.
+//
+// Every log line has the form "<crc32> <json record>". A crash mid-write leaves at most one incomplete or
+// corrupted line at the end of the log, which is discarded on recovery.
+type journal struct {
+	logPath       string
+	snapshotPath  string
+	log           *os.File
+	records       int
+	snapshotEvery int
+}
+
+// openJournal recovers the state persisted in dir under name: loadSnapshot gets the last snapshot, if there
+// is one, and apply gets every record logged after it.
+func openJournal(dir, name string, snapshotEvery int, loadSnapshot func(data []byte) error, apply func(data []byte) error) (*journal, error) {
+	if snapshotEvery <= 0 {
+		snapshotEvery = defaultSnapshotEvery
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	j := &journal{
+		logPath:       filepath.Join(dir, name+".log"),
+		snapshotPath:  filepath.Join(dir, name+".snapshot"),
+		snapshotEvery: snapshotEvery,
+	}
+
+	data, err := os.ReadFile(j.snapshotPath)
+	if err == nil {
+		if err := loadSnapshot(data); err != nil {
+			return nil, errors.New(fmt.Sprintf("reading %v snapshot: %v", name, err))
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	j.log, err = os.OpenFile(j.logPath, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	validSize, err := j.replay(apply)
+	if err != nil {
+		j.log.Close()
+		return nil, errors.New(fmt.Sprintf("%v %v", name, err))
+	}
+
+	// Drop whatever was left by a crash mid-write after the last valid record
+	if err := j.log.Truncate(validSize); err != nil {
+		j.log.Close()
+		return nil, err
+	}
+	if _, err := j.log.Seek(validSize, io.SeekStart); err != nil {
+		j.log.Close()
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// append durably logs the record. Once the log holds snapshotEvery records, it's replaced by the snapshot.
+// An error means the record wasn't logged: whatever part of it was written is dropped. Once the record is logged,
+// failing to compact the log isn't an error, it's retried with the next record.
+func (j *journal) append(record any, snapshot func() ([]byte, error)) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	offset, err := j.log.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	line := fmt.Sprintf("%08x %s\n", crc32.ChecksumIEEE(data), data)
+	if _, err := j.log.WriteString(line); err != nil {
+		j.discardFrom(offset)
+		return err
+	}
+	if err := j.log.Sync(); err != nil {
+		j.discardFrom(offset)
+		return err
+	}
+
+	j.records++
+	if j.records < j.snapshotEvery {
+		return nil
+	}
+
+	if data, err := snapshot(); err == nil {
+		_ = j.compact(data)
+	}
+
+	return nil
+}
+
+// discardFrom drops what was written to the log after offset, so the next record doesn't follow a torn one.
+// If that fails too, the torn record is discarded on recovery as long as it's the last one.
+func (j *journal) discardFrom(offset int64) {
+	if err := j.log.Truncate(offset); err != nil {
+		return
+	}
+	_, _ = j.log.Seek(offset, io.SeekStart)
+}
+
+func (j *journal) close() error {
+	return j.log.Close()
+}
+
+// compact atomically replaces the snapshot and empties the log.
+func (j *journal) compact(snapshot []byte) error {
+	if err := writeFileAtomically(j.snapshotPath, snapshot); err != nil {
+		return err
+	}
+
+	if err := j.log.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := j.log.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	j.records = 0
+	return j.log.Sync()
+}
+
+// replay applies every valid log record and returns the size of the log up to the last valid one.
+// Only the last line can be invalid, anything else means the log is corrupted.
+func (j *journal) replay(apply func(data []byte) error) (int64, error) {
+	reader := bufio.NewReader(j.log)
+	var validSize int64
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// An incomplete last line is a record that wasn't fully written
+			return validSize, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		data, ok := parseLogLine(line)
+		if ok {
+			ok = apply(data) == nil
+		}
+		if !ok {
+			if _, err := reader.Peek(1); err == io.EOF {
+				return validSize, nil
+			}
+			return 0, errors.New(fmt.Sprintf("log is corrupted at line %d", lineNumber))
+		}
+
+		j.records++
+		validSize += int64(len(line))
+	}
+}
+
+func parseLogLine(line []byte) ([]byte, bool) {
+	checksum, data, found := bytes.Cut(bytes.TrimSuffix(line, []byte("\n")), []byte(" "))
+	if !found {
+		return nil, false
+	}
+
+	expected, err := strconv.ParseUint(string(checksum), 16, 32)
+	if err != nil || uint32(expected) != crc32.ChecksumIEEE(data) {
+		return nil, false
+	}
+
+	return data, true
+}
+
+func writeFileAtomically(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	return dir.Sync()
+}
@@ -0,0 +1,17 @@
+CREATE TABLE carts (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE line_items (
+    id       INTEGER PRIMARY KEY AUTOINCREMENT,
+    cart_id  TEXT    NOT NULL REFERENCES carts (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    name     TEXT    NOT NULL,
+    category TEXT    NOT NULL,
+    price    INTEGER NOT NULL,
+    UNIQUE (cart_id, position)
+);
+
+CREATE INDEX line_items_category ON line_items (category);
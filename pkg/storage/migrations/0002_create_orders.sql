@@ -0,0 +1,11 @@
+CREATE TABLE orders (
+    id         INTEGER PRIMARY KEY,
+    cart_id    TEXT      NOT NULL REFERENCES carts (id),
+    products   INTEGER   NOT NULL,
+    discounts  INTEGER   NOT NULL,
+    shipping   INTEGER   NOT NULL,
+    price      INTEGER   NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX orders_cart_id ON orders (cart_id);
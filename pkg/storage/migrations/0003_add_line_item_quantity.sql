@@ -0,0 +1,21 @@
+-- Line items hold a quantity instead of one row per unit, so duplicated rows are merged into the first one.
+ALTER TABLE line_items ADD COLUMN quantity INTEGER NOT NULL DEFAULT 1;
+
+UPDATE line_items
+SET quantity = (SELECT COUNT(*)
+                FROM line_items other
+                WHERE other.cart_id = line_items.cart_id
+                  AND other.name = line_items.name)
+WHERE id = (SELECT MIN(id)
+            FROM line_items other
+            WHERE other.cart_id = line_items.cart_id
+              AND other.name = line_items.name);
+
+DELETE
+FROM line_items
+WHERE id <> (SELECT MIN(id)
+             FROM line_items other
+             WHERE other.cart_id = line_items.cart_id
+               AND other.name = line_items.name);
+
+CREATE UNIQUE INDEX line_items_cart_id_name ON line_items (cart_id, name);
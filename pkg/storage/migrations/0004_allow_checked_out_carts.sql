@@ -0,0 +1,19 @@
+-- A user keeps their checked out carts and gets a new one, so user_id is only unique among open carts.
+-- SQLite can't drop a constraint, the table is rebuilt instead.
+CREATE TABLE carts_new (
+    id          TEXT PRIMARY KEY,
+    user_id     TEXT      NOT NULL,
+    checked_out INTEGER   NOT NULL DEFAULT 0,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO carts_new (id, user_id, created_at)
+SELECT id, user_id, created_at
+FROM carts;
+
+DROP TABLE carts;
+
+ALTER TABLE carts_new RENAME TO carts;
+
+CREATE UNIQUE INDEX carts_open_user_id ON carts (user_id) WHERE checked_out = 0;
+CREATE INDEX carts_user_id ON carts (user_id);
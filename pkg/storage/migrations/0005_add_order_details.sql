@@ -0,0 +1,13 @@
+ALTER TABLE orders ADD COLUMN user_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX orders_user_id ON orders (user_id);
+
+CREATE TABLE order_items (
+    order_id INTEGER NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    name     TEXT    NOT NULL,
+    category TEXT    NOT NULL,
+    price    INTEGER NOT NULL,
+    quantity INTEGER NOT NULL,
+    PRIMARY KEY (order_id, position)
+);
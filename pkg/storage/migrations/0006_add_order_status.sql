@@ -0,0 +1,13 @@
+ALTER TABLE orders ADD COLUMN status TEXT NOT NULL DEFAULT 'pending';
+
+CREATE TABLE order_status_changes (
+    order_id   INTEGER   NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    position   INTEGER   NOT NULL,
+    status     TEXT      NOT NULL,
+    changed_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (order_id, position)
+);
+
+-- Orders placed before statuses existed start their history as pending when they were created
+INSERT INTO order_status_changes (order_id, position, status, changed_at)
+SELECT id, 1, 'pending', created_at FROM orders;
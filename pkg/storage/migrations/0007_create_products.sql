@@ -0,0 +1,10 @@
+CREATE TABLE products (
+    sku      TEXT PRIMARY KEY,
+    name     TEXT    NOT NULL UNIQUE,
+    category TEXT    NOT NULL,
+    price    INTEGER NOT NULL
+);
+
+-- Products added to carts before the catalog existed have no SKU
+ALTER TABLE line_items ADD COLUMN sku TEXT NOT NULL DEFAULT '';
+ALTER TABLE order_items ADD COLUMN sku TEXT NOT NULL DEFAULT '';
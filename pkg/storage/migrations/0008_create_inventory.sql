@@ -0,0 +1,22 @@
+CREATE TABLE stock (
+    sku      TEXT PRIMARY KEY,
+    on_hand  INTEGER NOT NULL,
+    reserved INTEGER NOT NULL DEFAULT 0,
+    CHECK (reserved >= 0 AND reserved <= on_hand)
+);
+
+-- Stock is reserved before its order is stored, so reservations don't reference orders.
+-- expires_at holds milliseconds since the Unix epoch so reservations can be compared by expiration in SQL.
+CREATE TABLE reservations (
+    order_id   INTEGER PRIMARY KEY,
+    expires_at INTEGER NOT NULL
+);
+
+CREATE INDEX reservations_expires_at ON reservations (expires_at);
+
+CREATE TABLE reservation_items (
+    order_id INTEGER NOT NULL REFERENCES reservations (order_id) ON DELETE CASCADE,
+    sku      TEXT    NOT NULL,
+    quantity INTEGER NOT NULL,
+    PRIMARY KEY (order_id, sku)
+);
@@ -0,0 +1,14 @@
+-- Amounts are stored in minor units of their currency, so the whole units stored so far become cents of USD,
+-- the only currency until now.
+ALTER TABLE products ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+UPDATE products SET price = price * 100;
+
+ALTER TABLE line_items ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+UPDATE line_items SET price = price * 100;
+
+ALTER TABLE order_items ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+UPDATE order_items SET price = price * 100;
+
+-- Every amount of an order is in the same currency
+ALTER TABLE orders ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+UPDATE orders SET discounts = discounts * 100, shipping = shipping * 100, price = price * 100;
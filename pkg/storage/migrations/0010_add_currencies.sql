@@ -0,0 +1,17 @@
+-- Carts are priced in the currency chosen when they're created. The ones in another currency than the catalog one
+-- keep the exchange rate their prices are converted with, from exchange_rate_from into their currency, and so do
+-- their orders.
+ALTER TABLE carts ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+ALTER TABLE carts ADD COLUMN exchange_rate_from TEXT;
+ALTER TABLE carts ADD COLUMN exchange_rate TEXT;
+
+ALTER TABLE orders ADD COLUMN exchange_rate_from TEXT;
+ALTER TABLE orders ADD COLUMN exchange_rate TEXT;
+
+-- The price list of the products in other currencies than the catalog one
+CREATE TABLE product_prices (
+    sku      TEXT    NOT NULL REFERENCES products (sku) ON DELETE CASCADE,
+    currency TEXT    NOT NULL,
+    price    INTEGER NOT NULL,
+    PRIMARY KEY (sku, currency)
+);
@@ -0,0 +1,9 @@
+-- Orders keep the taxes they were charged and the region they were taxed as. Inclusive taxes are already part of the
+-- prices, so they aren't added to the order price.
+ALTER TABLE orders ADD COLUMN tax INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN tax_inclusive INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN tax_region TEXT NOT NULL DEFAULT '';
+
+-- The tax of every item, NULL for the orders that weren't taxed
+ALTER TABLE order_items ADD COLUMN tax INTEGER;
+ALTER TABLE order_items ADD COLUMN tax_rate TEXT;
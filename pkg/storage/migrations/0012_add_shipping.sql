@@ -0,0 +1,12 @@
+-- Products weigh something in grams, for the shipping methods that charge by weight, and so do the line items of the
+-- carts and orders.
+ALTER TABLE products ADD COLUMN weight INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE line_items ADD COLUMN weight INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN weight INTEGER NOT NULL DEFAULT 0;
+
+-- Orders keep the shipping method they were shipped with and what it charged before the promotions. The orders
+-- placed before there were shipping methods were all shipped at the standard flat rate, which is what they paid
+-- unless a promotion waived it.
+ALTER TABLE orders ADD COLUMN shipping_method TEXT NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN shipping_rate INTEGER NOT NULL DEFAULT 0;
+UPDATE orders SET shipping_method = 'standard', shipping_rate = shipping;
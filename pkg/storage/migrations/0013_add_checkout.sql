@@ -0,0 +1,33 @@
+-- Carts need an email, a shipping and a billing address and a delivery method before their order is placed, and
+-- orders keep the ones they were placed with.
+ALTER TABLE carts ADD COLUMN email TEXT NOT NULL DEFAULT '';
+ALTER TABLE carts ADD COLUMN delivery_method TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE orders ADD COLUMN email TEXT NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN delivery_method TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE cart_addresses (
+    cart_id     TEXT NOT NULL REFERENCES carts (id) ON DELETE CASCADE,
+    kind        TEXT NOT NULL CHECK (kind IN ('shipping', 'billing')),
+    name        TEXT NOT NULL,
+    line1       TEXT NOT NULL,
+    line2       TEXT NOT NULL,
+    city        TEXT NOT NULL,
+    region      TEXT NOT NULL,
+    postal_code TEXT NOT NULL,
+    country     TEXT NOT NULL,
+    PRIMARY KEY (cart_id, kind)
+);
+
+CREATE TABLE order_addresses (
+    order_id    INTEGER NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    kind        TEXT    NOT NULL CHECK (kind IN ('shipping', 'billing')),
+    name        TEXT    NOT NULL,
+    line1       TEXT    NOT NULL,
+    line2       TEXT    NOT NULL,
+    city        TEXT    NOT NULL,
+    region      TEXT    NOT NULL,
+    postal_code TEXT    NOT NULL,
+    country     TEXT    NOT NULL,
+    PRIMARY KEY (order_id, kind)
+);
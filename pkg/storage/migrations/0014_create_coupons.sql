@@ -0,0 +1,41 @@
+-- starts_at and ends_at hold milliseconds since the Unix epoch, NULL when the coupon has no such limit.
+CREATE TABLE coupons (
+    code                     TEXT PRIMARY KEY,
+    effect                   TEXT    NOT NULL,
+    percent                  INTEGER NOT NULL,
+    amount                   INTEGER NOT NULL,
+    amount_currency          TEXT    NOT NULL,
+    category                 TEXT    NOT NULL,
+    min_subtotal             INTEGER NOT NULL,
+    min_subtotal_currency    TEXT    NOT NULL,
+    starts_at                INTEGER,
+    ends_at                  INTEGER,
+    max_redemptions          INTEGER NOT NULL,
+    max_redemptions_per_user INTEGER NOT NULL,
+    redemptions              INTEGER NOT NULL DEFAULT 0,
+    CHECK (max_redemptions = 0 OR redemptions <= max_redemptions)
+);
+
+-- Coupons are redeemed before their order is stored, so redemptions don't reference orders.
+CREATE TABLE coupon_redemptions (
+    order_id INTEGER NOT NULL,
+    code     TEXT    NOT NULL REFERENCES coupons (code),
+    user_id  TEXT    NOT NULL,
+    PRIMARY KEY (order_id, code)
+);
+
+CREATE INDEX coupon_redemptions_code_user_id ON coupon_redemptions (code, user_id);
+
+CREATE TABLE cart_coupons (
+    cart_id  TEXT    NOT NULL REFERENCES carts (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    code     TEXT    NOT NULL,
+    PRIMARY KEY (cart_id, code)
+);
+
+CREATE TABLE order_coupons (
+    order_id INTEGER NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    code     TEXT    NOT NULL,
+    PRIMARY KEY (order_id, code)
+);
@@ -0,0 +1,21 @@
+-- Orders itemize their discounts and the shipping waived by the promotions and coupons applied to them. Amounts are in
+-- the currency of the order.
+CREATE TABLE order_adjustments (
+    order_id      INTEGER NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    position      INTEGER NOT NULL,
+    adjustment_id TEXT    NOT NULL,
+    description   TEXT    NOT NULL,
+    amount        INTEGER NOT NULL,
+    shipping      INTEGER NOT NULL,
+    PRIMARY KEY (order_id, position)
+);
+
+-- The names of the line items every adjustment applies to
+CREATE TABLE order_adjustment_items (
+    order_id   INTEGER NOT NULL,
+    adjustment INTEGER NOT NULL,
+    position   INTEGER NOT NULL,
+    name       TEXT    NOT NULL,
+    PRIMARY KEY (order_id, adjustment, position),
+    FOREIGN KEY (order_id, adjustment) REFERENCES order_adjustments (order_id, position) ON DELETE CASCADE
+);
@@ -0,0 +1,14 @@
+-- Free line items are given away by the promotion with promotion_id, and are kept apart from the products users add,
+-- so a cart can have both a free and a paid line item of the same product.
+ALTER TABLE line_items ADD COLUMN free INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE line_items ADD COLUMN promotion_id TEXT NOT NULL DEFAULT '';
+
+DROP INDEX line_items_cart_id_name;
+CREATE UNIQUE INDEX line_items_cart_id_name ON line_items (cart_id, name, promotion_id);
+
+ALTER TABLE order_items ADD COLUMN free INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN promotion_id TEXT NOT NULL DEFAULT '';
+
+-- Until now the extra coffee was the only product given away, added like any other product but without a SKU or a price
+UPDATE line_items SET free = 1, promotion_id = 'extra-coffee' WHERE name = 'extraCoffee' AND sku = '' AND price = 0;
+UPDATE order_items SET free = 1, promotion_id = 'extra-coffee' WHERE name = 'extraCoffee' AND sku = '' AND price = 0;
@@ -0,0 +1,8 @@
+-- Guest carts have no user, an empty user_id, and are identified by their guest_token instead. Users keep having a
+-- single open cart, while guests can have as many as they create.
+ALTER TABLE carts ADD COLUMN guest_token TEXT;
+
+CREATE UNIQUE INDEX carts_guest_token ON carts (guest_token);
+
+DROP INDEX carts_open_user_id;
+CREATE UNIQUE INDEX carts_open_user_id ON carts (user_id) WHERE checked_out = 0 AND user_id <> '';
@@ -0,0 +1,5 @@
+-- Users can have several open carts at once, told apart by their name. Carts are listed in the order they were
+-- created, which older carts already record in created_at.
+ALTER TABLE carts ADD COLUMN name TEXT NOT NULL DEFAULT '';
+
+DROP INDEX carts_open_user_id;
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+type OrderRepository interface {
+	CreateOrder(order models.Order) (models.Order, error)
+	GetOrderByID(orderID int) (models.Order, error)
+	GetOrdersByUserID(userID string) ([]models.Order, error)
+	// UpdateOrderStatus moves the order to the status of the change, recording it in its history, as long as the
+	// order is still in the expected status. Otherwise it fails with ErrOrderStatusStale.
+	UpdateOrderStatus(orderID int, expected models.OrderStatus, change models.StatusChange) (models.Order, error)
+}
+
+// orderRepo keeps orders in memory by ID, along with the IDs of the orders of every user in the order
+// they were placed. It's safe for concurrent use.
+type orderRepo struct {
+	mu     sync.RWMutex
+	orders map[int]models.Order
+	byUser map[string][]int
+}
+
+func NewOrderRepo() OrderRepository {
+	return newOrderRepo()
+}
+
+func newOrderRepo() *orderRepo {
+	return &orderRepo{
+		orders: make(map[int]models.Order),
+		byUser: make(map[string][]int),
+	}
+}
+
+func (o *orderRepo) CreateOrder(order models.Order) (models.Order, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, ok := o.orders[order.Totals.Order]; ok {
+		return models.Order{}, orderAlreadyExists(order.Totals.Order)
+	}
+
+	o.put(order)
+	return cloneOrder(order), nil
+}
+
+func (o *orderRepo) GetOrderByID(orderID int) (models.Order, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	order, ok := o.orders[orderID]
+	if !ok {
+		return models.Order{}, orderNotFound(orderID)
+	}
+
+	return cloneOrder(order), nil
+}
+
+func (o *orderRepo) GetOrdersByUserID(userID string) ([]models.Order, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	orders := make([]models.Order, 0, len(o.byUser[userID]))
+	for _, orderID := range o.byUser[userID] {
+		orders = append(orders, cloneOrder(o.orders[orderID]))
+	}
+
+	return orders, nil
+}
+
+func (o *orderRepo) UpdateOrderStatus(orderID int, expected models.OrderStatus, change models.StatusChange) (models.Order, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	order, ok := o.orders[orderID]
+	if !ok {
+		return models.Order{}, orderNotFound(orderID)
+	}
+
+	updated, err := changeStatus(order, expected, change)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	o.put(updated)
+	return cloneOrder(updated), nil
+}
+
+// put stores the order, replacing the previous version of it. The caller must hold mu.
+func (o *orderRepo) put(order models.Order) {
+	orderID := order.Totals.Order
+	if _, ok := o.orders[orderID]; !ok {
+		o.byUser[order.UserID] = append(o.byUser[order.UserID], orderID)
+	}
+
+	o.orders[orderID] = order
+}
+
+// reindex rebuilds the orders of every user from the stored orders. The caller must hold mu or own the repository.
+func (o *orderRepo) reindex() {
+	o.byUser = make(map[string][]int)
+	for orderID, order := range o.orders {
+		o.byUser[order.UserID] = append(o.byUser[order.UserID], orderID)
+	}
+
+	for _, orderIDs := range o.byUser {
+		sort.Slice(orderIDs, func(i, j int) bool {
+			first, second := o.orders[orderIDs[i]], o.orders[orderIDs[j]]
+			if first.CreatedAt.Equal(second.CreatedAt) {
+				return orderIDs[i] < orderIDs[j]
+			}
+			return first.CreatedAt.Before(second.CreatedAt)
+		})
+	}
+}
+
+// changeStatus returns a copy of the order moved to the status of the change, if it's in the expected status.
+func changeStatus(order models.Order, expected models.OrderStatus, change models.StatusChange) (models.Order, error) {
+	if order.Status != expected {
+		return models.Order{}, orderStatusStale(order.Totals.Order, expected)
+	}
+
+	order = cloneOrder(order)
+	order.Status = change.Status
+	order.History = append(order.History, change)
+	return order, nil
+}
+
+func cloneOrder(order models.Order) models.Order {
+	if order.Items != nil {
+		order.Items = append([]models.LineItem{}, order.Items...)
+		for i, item := range order.Items {
+			if item.Tax != nil {
+				tax := *item.Tax
+				order.Items[i].Tax = &tax
+			}
+		}
+	}
+
+	if order.History != nil {
+		order.History = append([]models.StatusChange{}, order.History...)
+	}
+
+	order.ExchangeRate = cloneRate(order.ExchangeRate)
+	order.Checkout = cloneCheckout(order.Checkout)
+	if order.Coupons != nil {
+		order.Coupons = append([]string{}, order.Coupons...)
+	}
+
+	if order.Totals.Adjustments != nil {
+		order.Totals.Adjustments = append([]models.Adjustment{}, order.Totals.Adjustments...)
+		for i, adjustment := range order.Totals.Adjustments {
+			if adjustment.Items != nil {
+				order.Totals.Adjustments[i].Items = append([]string{}, adjustment.Items...)
+			}
+		}
+	}
+
+	return order
+}
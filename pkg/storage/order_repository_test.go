@@ -0,0 +1,342 @@
+package storage
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+)
+
+// forEachOrderRepo runs the test against every OrderRepository implementation.
+func forEachOrderRepo(t *testing.T, test func(t *testing.T, repo OrderRepository)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewOrderRepo())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		repo, err := NewFileOrderRepo(t.TempDir(), 0)
+		require.NoError(t, err)
+		t.Cleanup(func() { repo.Close() })
+		test(t, repo)
+	})
+
+	t.Run("sql", func(t *testing.T) {
+		db := newTestDB(t)
+		// Orders reference their cart
+		_, err := NewSQLCartRepo(db).CreateCart("user1", models.Cart{ID: "cart1", UserID: "user1"})
+		require.NoError(t, err)
+		test(t, NewSQLOrderRepo(db))
+	})
+}
+
+func newTestOrder(orderID int, userID string, createdAt time.Time) models.Order {
+	return models.Order{
+		CartID: "cart1",
+		UserID: userID,
+		Items: []models.LineItem{
+			{Product: testCoffee, Quantity: 2},
+		},
+		Totals: models.Total{
+			Products:       2,
+			Discounts:      usd(0),
+			Shipping:       usd(20),
+			ShippingMethod: "standard",
+			ShippingRate:   usd(20),
+			Tax:            usd(0),
+			Order:          orderID,
+			Price:          usd(20),
+		},
+		Status:    models.OrderPending,
+		History:   []models.StatusChange{{Status: models.OrderPending, At: createdAt.UTC()}},
+		CreatedAt: createdAt.UTC(),
+	}
+}
+
+func TestOrderRepo_CreateOrder_And_GetOrderByID(t *testing.T) {
+	forEachOrderRepo(t, func(t *testing.T, repo OrderRepository) {
+		// Given
+		order := newTestOrder(12345678, "user1", time.Now())
+
+		// When
+		_, err := repo.CreateOrder(order)
+		require.NoError(t, err)
+		storedOrder, err := repo.GetOrderByID(12345678)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, order.CartID, storedOrder.CartID)
+		require.Equal(t, order.UserID, storedOrder.UserID)
+		require.Equal(t, order.Items, storedOrder.Items)
+		require.Equal(t, order.Totals, storedOrder.Totals)
+		require.Equal(t, models.OrderPending, storedOrder.Status)
+		require.Len(t, storedOrder.History, 1)
+		require.True(t, order.CreatedAt.Equal(storedOrder.CreatedAt))
+	})
+}
+
+func TestOrderRepo_Keeps_Exchange_Rate(t *testing.T) {
+	forEachOrderRepo(t, func(t *testing.T, repo OrderRepository) {
+		// Given
+		rate, err := money.ParseRate(money.USD, money.EUR, "0.92")
+		require.NoError(t, err)
+		order := newTestOrder(1, "user1", time.Now())
+		order.Items[0].Product.Price = money.New(920, money.EUR)
+		order.Totals = models.Total{Products: 2, Discounts: money.Zero(money.EUR), Shipping: money.New(1840, money.EUR),
+			ShippingMethod: "standard", ShippingRate: money.New(1840, money.EUR), Tax: money.Zero(money.EUR), Order: 1,
+			Price: money.New(1840, money.EUR)}
+		order.ExchangeRate = &rate
+
+		// When
+		_, err = repo.CreateOrder(order)
+		require.NoError(t, err)
+		stored, storedErr := repo.GetOrderByID(1)
+
+		// Then
+		require.NoError(t, storedErr)
+		require.Equal(t, order.Totals, stored.Totals)
+		require.Equal(t, &rate, stored.ExchangeRate)
+	})
+}
+
+func TestOrderRepo_Keeps_Taxes(t *testing.T) {
+	forEachOrderRepo(t, func(t *testing.T, repo OrderRepository) {
+		// Given
+		order := newTestOrder(1, "user1", time.Now())
+		order.TaxRegion = "US-CA"
+		order.Items[0].Tax = &models.Tax{Rate: "7.25", Amount: money.New(145, money.USD)}
+		order.Totals.Tax = money.New(145, money.USD)
+		order.Totals.Price = money.New(2145, money.USD)
+
+		// When
+		_, err := repo.CreateOrder(order)
+		require.NoError(t, err)
+		stored, storedErr := repo.GetOrderByID(1)
+
+		// Then
+		require.NoError(t, storedErr)
+		require.Equal(t, order.Totals, stored.Totals)
+		require.Equal(t, "US-CA", stored.TaxRegion)
+		require.Equal(t, order.Items, stored.Items)
+	})
+}
+
+func TestOrderRepo_Keeps_Checkout(t *testing.T) {
+	forEachOrderRepo(t, func(t *testing.T, repo OrderRepository) {
+		// Given
+		order := newTestOrder(1, "user1", time.Now())
+		order.Checkout = models.Checkout{
+			Email: "ada@example.com",
+			ShippingAddress: &models.Address{Name: "Ada Lovelace", Line1: "350 5th Ave", City: "New York", Region: "NY",
+				PostalCode: "10118", Country: "US"},
+			BillingAddress: &models.Address{Name: "Ada Lovelace", Line1: "Calle Mayor 1", City: "Madrid",
+				PostalCode: "28013", Country: "ES"},
+			DeliveryMethod: "standard",
+		}
+
+		// When
+		_, err := repo.CreateOrder(order)
+		require.NoError(t, err)
+		stored, storedErr := repo.GetOrderByID(1)
+
+		// Then
+		require.NoError(t, storedErr)
+		require.Equal(t, order.Checkout, stored.Checkout)
+	})
+}
+
+func TestOrderRepo_Keeps_Coupons(t *testing.T) {
+	forEachOrderRepo(t, func(t *testing.T, repo OrderRepository) {
+		// Given
+		order := newTestOrder(1, "user1", time.Now())
+		order.Coupons = []string{"WELCOME10", "FREESHIP"}
+
+		// When
+		_, err := repo.CreateOrder(order)
+		require.NoError(t, err)
+		stored, storedErr := repo.GetOrderByID(1)
+
+		// Then
+		require.NoError(t, storedErr)
+		require.Equal(t, order.Coupons, stored.Coupons)
+	})
+}
+
+func TestOrderRepo_Keeps_Adjustments(t *testing.T) {
+	forEachOrderRepo(t, func(t *testing.T, repo OrderRepository) {
+		// Given
+		order := newTestOrder(1, "user1", time.Now())
+		order.Totals.Adjustments = []models.Adjustment{
+			{ID: "extra-coffee", Description: "Free extraCoffee for at least 2 coffee products", Items: []string{"extraCoffee"}, Amount: usd(0)},
+			{ID: "coupon-TENOFF", Description: "Coupon TENOFF", Items: []string{"coffee1", "mug"}, Amount: usd(10)},
+			{ID: "equipment-free-shipping", Description: "Free shipping", Amount: usd(20), Shipping: true},
+		}
+
+		// When
+		_, err := repo.CreateOrder(order)
+		require.NoError(t, err)
+		stored, storedErr := repo.GetOrderByID(1)
+
+		// Then
+		require.NoError(t, storedErr)
+		require.Equal(t, order.Totals.Adjustments, stored.Totals.Adjustments)
+	})
+}
+
+func TestOrderRepo_Keeps_Free_Items(t *testing.T) {
+	forEachOrderRepo(t, func(t *testing.T, repo OrderRepository) {
+		// Given
+		order := newTestOrder(1, "user1", time.Now())
+		order.Items = append(order.Items, models.LineItem{
+			Product:     models.Product{Name: "extraCoffee", Category: models.CoffeeCategory, Price: usd(0)},
+			Quantity:    1,
+			Free:        true,
+			PromotionID: "extra-coffee",
+		})
+
+		// When
+		_, err := repo.CreateOrder(order)
+		require.NoError(t, err)
+		stored, storedErr := repo.GetOrderByID(1)
+
+		// Then
+		require.NoError(t, storedErr)
+		require.Equal(t, order.Items, stored.Items)
+	})
+}
+
+func TestOrderRepo_CreateOrder_Duplicated(t *testing.T) {
+	forEachOrderRepo(t, func(t *testing.T, repo OrderRepository) {
+		// Given
+		_, err := repo.CreateOrder(newTestOrder(1, "user1", time.Now()))
+		require.NoError(t, err)
+
+		// When
+		_, err = repo.CreateOrder(newTestOrder(1, "user2", time.Now()))
+
+		// Then
+		require.ErrorIs(t, err, ErrOrderExists)
+	})
+}
+
+func TestOrderRepo_GetOrderByID_Not_Found(t *testing.T) {
+	forEachOrderRepo(t, func(t *testing.T, repo OrderRepository) {
+		// When
+		_, err := repo.GetOrderByID(1)
+
+		// Then
+		require.EqualError(t, err, "order 1 doesn't exist")
+		require.ErrorIs(t, err, ErrOrderNotFound)
+	})
+}
+
+func TestOrderRepo_GetOrdersByUserID(t *testing.T) {
+	forEachOrderRepo(t, func(t *testing.T, repo OrderRepository) {
+		// Given
+		now := time.Now()
+		for _, order := range []models.Order{
+			newTestOrder(3, "user1", now),
+			newTestOrder(1, "user1", now.Add(time.Minute)),
+			newTestOrder(2, "user2", now),
+		} {
+			_, err := repo.CreateOrder(order)
+			require.NoError(t, err)
+		}
+
+		// When
+		orders, err := repo.GetOrdersByUserID("user1")
+		noOrders, noOrdersErr := repo.GetOrdersByUserID("user3")
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, 2, len(orders))
+		require.Equal(t, 3, orders[0].Totals.Order)
+		require.Equal(t, 1, orders[1].Totals.Order)
+		require.NoError(t, noOrdersErr)
+		require.Empty(t, noOrders)
+	})
+}
+
+func TestOrderRepo_UpdateOrderStatus(t *testing.T) {
+	forEachOrderRepo(t, func(t *testing.T, repo OrderRepository) {
+		// Given
+		now := time.Now().UTC()
+		_, err := repo.CreateOrder(newTestOrder(1, "user1", now))
+		require.NoError(t, err)
+		paidAt := now.Add(time.Minute)
+
+		// When
+		updated, err := repo.UpdateOrderStatus(1, models.OrderPending, models.StatusChange{Status: models.OrderPaid, At: paidAt})
+		require.NoError(t, err)
+		stored, storedErr := repo.GetOrderByID(1)
+
+		// Then
+		require.NoError(t, storedErr)
+		for _, order := range []models.Order{updated, stored} {
+			require.Equal(t, models.OrderPaid, order.Status)
+			require.Len(t, order.History, 2)
+			require.Equal(t, models.OrderPaid, order.History[1].Status)
+			require.True(t, paidAt.Equal(order.History[1].At))
+		}
+	})
+}
+
+func TestOrderRepo_UpdateOrderStatus_Stale(t *testing.T) {
+	forEachOrderRepo(t, func(t *testing.T, repo OrderRepository) {
+		// Given
+		_, err := repo.CreateOrder(newTestOrder(1, "user1", time.Now()))
+		require.NoError(t, err)
+		_, err = repo.UpdateOrderStatus(1, models.OrderPending, models.StatusChange{Status: models.OrderCancelled, At: time.Now()})
+		require.NoError(t, err)
+
+		// When
+		_, err = repo.UpdateOrderStatus(1, models.OrderPending, models.StatusChange{Status: models.OrderPaid, At: time.Now()})
+		stored, storedErr := repo.GetOrderByID(1)
+
+		// Then
+		require.ErrorIs(t, err, ErrOrderStatusStale)
+		require.NoError(t, storedErr)
+		require.Equal(t, models.OrderCancelled, stored.Status)
+		require.Len(t, stored.History, 2)
+	})
+}
+
+func TestOrderRepo_UpdateOrderStatus_Not_Found(t *testing.T) {
+	forEachOrderRepo(t, func(t *testing.T, repo OrderRepository) {
+		// When
+		_, err := repo.UpdateOrderStatus(1, models.OrderPending, models.StatusChange{Status: models.OrderPaid, At: time.Now()})
+
+		// Then
+		require.ErrorIs(t, err, ErrOrderNotFound)
+	})
+}
+
+func TestFileOrderRepo_Recovers_After_Restart(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	repo, err := NewFileOrderRepo(dir, 2)
+	require.NoError(t, err)
+	now := time.Now()
+	for orderID := 1; orderID <= 3; orderID++ {
+		_, err := repo.CreateOrder(newTestOrder(orderID, "user1", now.Add(time.Duration(orderID)*time.Minute)))
+		require.NoError(t, err)
+	}
+	_, err = repo.UpdateOrderStatus(3, models.OrderPending, models.StatusChange{Status: models.OrderPaid, At: now})
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+
+	// When
+	reopened, err := NewFileOrderRepo(dir, 2)
+	require.NoError(t, err)
+	defer reopened.Close()
+	orders, err := reopened.GetOrdersByUserID("user1")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 3, len(orders))
+	for i, order := range orders {
+		require.Equal(t, i+1, order.Totals.Order)
+	}
+	require.Equal(t, models.OrderPaid, orders[2].Status)
+	require.Len(t, orders[2].History, 2)
+}
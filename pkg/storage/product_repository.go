@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+)
+
+// ProductRepository stores the catalog. Products are identified by their SKU and their names are unique too,
+// since carts tell products apart by name.
+type ProductRepository interface {
+	CreateProduct(product models.Product) (models.Product, error)
+	UpdateProduct(product models.Product) (models.Product, error)
+	DeleteProduct(sku string) error
+	GetProductBySKU(sku string) (models.Product, error)
+	GetProducts() ([]models.Product, error)
+}
+
+// productRepo keeps the products in memory by SKU. It's safe for concurrent use.
+type productRepo struct {
+	mu       sync.RWMutex
+	products map[string]models.Product
+}
+
+func NewProductRepo() ProductRepository {
+	return newProductRepo()
+}
+
+func newProductRepo() *productRepo {
+	return &productRepo{
+		products: make(map[string]models.Product),
+	}
+}
+
+func (p *productRepo) CreateProduct(product models.Product) (models.Product, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.checkCreate(product); err != nil {
+		return models.Product{}, err
+	}
+
+	p.products[product.SKU] = cloneProduct(product)
+	return product, nil
+}
+
+func (p *productRepo) UpdateProduct(product models.Product) (models.Product, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.checkUpdate(product); err != nil {
+		return models.Product{}, err
+	}
+
+	p.products[product.SKU] = cloneProduct(product)
+	return product, nil
+}
+
+func (p *productRepo) DeleteProduct(sku string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.products[sku]; !ok {
+		return productNotFound(sku)
+	}
+
+	delete(p.products, sku)
+	return nil
+}
+
+func (p *productRepo) GetProductBySKU(sku string) (models.Product, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	product, ok := p.products[sku]
+	if !ok {
+		return models.Product{}, productNotFound(sku)
+	}
+
+	return cloneProduct(product), nil
+}
+
+// GetProducts returns every product sorted by SKU.
+func (p *productRepo) GetProducts() ([]models.Product, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	products := make([]models.Product, 0, len(p.products))
+	for _, product := range p.products {
+		products = append(products, cloneProduct(product))
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].SKU < products[j].SKU
+	})
+
+	return products, nil
+}
+
+// checkCreate tells whether the product can be added. The caller must hold mu.
+func (p *productRepo) checkCreate(product models.Product) error {
+	if _, ok := p.products[product.SKU]; ok {
+		return productAlreadyExists(product.SKU)
+	}
+
+	return p.checkName(product)
+}
+
+// checkUpdate tells whether the product can replace the stored one with its SKU. The caller must hold mu.
+func (p *productRepo) checkUpdate(product models.Product) error {
+	if _, ok := p.products[product.SKU]; !ok {
+		return productNotFound(product.SKU)
+	}
+
+	return p.checkName(product)
+}
+
+// checkName tells whether the name of the product is free. The caller must hold mu.
+func (p *productRepo) checkName(product models.Product) error {
+	for sku, stored := range p.products {
+		if sku != product.SKU && stored.Name == product.Name {
+			return productNameTaken(product.Name)
+		}
+	}
+
+	return nil
+}
+
+// apply replays a change of the journal. The caller must own the repository.
+func (p *productRepo) apply(record productRecord) {
+	if record.Product == nil {
+		delete(p.products, record.SKU)
+		return
+	}
+
+	p.products[record.SKU] = *record.Product
+}
+
+func cloneProduct(product models.Product) models.Product {
+	if product.Prices != nil {
+		product.Prices = append([]money.Money{}, product.Prices...)
+	}
+
+	return product
+}
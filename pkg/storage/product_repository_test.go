@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+)
+
+// forEachProductRepo runs the test against every ProductRepository implementation.
+func forEachProductRepo(t *testing.T, test func(t *testing.T, repo ProductRepository)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewProductRepo())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		repo, err := NewFileProductRepo(t.TempDir(), 0)
+		require.NoError(t, err)
+		t.Cleanup(func() { repo.Close() })
+		test(t, repo)
+	})
+
+	t.Run("sql", func(t *testing.T) {
+		test(t, NewSQLProductRepo(newTestDB(t)))
+	})
+}
+
+var (
+	testCatalogCoffee = models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10), Weight: 250}
+	testCatalogMug    = models.Product{SKU: "ACC-001", Name: "mug", Category: models.AccessoriesCategory, Price: usd(5)}
+)
+
+func TestProductRepo_CreateProduct_And_GetProductBySKU(t *testing.T) {
+	forEachProductRepo(t, func(t *testing.T, repo ProductRepository) {
+		// Given
+		_, err := repo.CreateProduct(testCatalogCoffee)
+		require.NoError(t, err)
+
+		// When
+		product, err := repo.GetProductBySKU("COF-001")
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, testCatalogCoffee, product)
+	})
+}
+
+func TestProductRepo_Price_List(t *testing.T) {
+	forEachProductRepo(t, func(t *testing.T, repo ProductRepository) {
+		// Given
+		withPrices := testCatalogCoffee
+		withPrices.Prices = []money.Money{money.New(900, money.EUR), money.New(800, money.GBP)}
+		_, err := repo.CreateProduct(withPrices)
+		require.NoError(t, err)
+		repriced := withPrices
+		repriced.Prices = []money.Money{money.New(950, money.EUR)}
+
+		// When
+		created, createdErr := repo.GetProductBySKU("COF-001")
+		_, err = repo.UpdateProduct(repriced)
+		require.NoError(t, err)
+		products, updatedErr := repo.GetProducts()
+
+		// Then
+		require.NoError(t, createdErr)
+		require.ElementsMatch(t, withPrices.Prices, created.Prices)
+		require.NoError(t, updatedErr)
+		require.Equal(t, []models.Product{repriced}, products)
+	})
+}
+
+func TestProductRepo_CreateProduct_Duplicated(t *testing.T) {
+	forEachProductRepo(t, func(t *testing.T, repo ProductRepository) {
+		// Given
+		_, err := repo.CreateProduct(testCatalogCoffee)
+		require.NoError(t, err)
+		sameName := testCatalogMug
+		sameName.Name = testCatalogCoffee.Name
+
+		// When
+		_, skuErr := repo.CreateProduct(testCatalogCoffee)
+		_, nameErr := repo.CreateProduct(sameName)
+
+		// Then
+		require.ErrorIs(t, skuErr, ErrProductExists)
+		require.EqualError(t, skuErr, "product with SKU COF-001 already exists")
+		require.ErrorIs(t, nameErr, ErrProductExists)
+		require.EqualError(t, nameErr, "product named coffee1 already exists")
+	})
+}
+
+func TestProductRepo_UpdateProduct(t *testing.T) {
+	forEachProductRepo(t, func(t *testing.T, repo ProductRepository) {
+		// Given
+		_, err := repo.CreateProduct(testCatalogCoffee)
+		require.NoError(t, err)
+		_, err = repo.CreateProduct(testCatalogMug)
+		require.NoError(t, err)
+		updated := testCatalogCoffee
+		updated.Price = usd(12)
+		renamedToMug := testCatalogCoffee
+		renamedToMug.Name = testCatalogMug.Name
+
+		// When
+		_, err = repo.UpdateProduct(updated)
+		_, nameErr := repo.UpdateProduct(renamedToMug)
+		_, notFoundErr := repo.UpdateProduct(models.Product{SKU: "unknown", Name: "tea"})
+
+		// Then
+		require.NoError(t, err)
+		product, err := repo.GetProductBySKU("COF-001")
+		require.NoError(t, err)
+		require.Equal(t, usd(12), product.Price)
+		require.ErrorIs(t, nameErr, ErrProductExists)
+		require.ErrorIs(t, notFoundErr, ErrProductNotFound)
+	})
+}
+
+func TestProductRepo_DeleteProduct(t *testing.T) {
+	forEachProductRepo(t, func(t *testing.T, repo ProductRepository) {
+		// Given
+		_, err := repo.CreateProduct(testCatalogCoffee)
+		require.NoError(t, err)
+
+		// When
+		err = repo.DeleteProduct("COF-001")
+		notFoundErr := repo.DeleteProduct("COF-001")
+
+		// Then
+		require.NoError(t, err)
+		_, err = repo.GetProductBySKU("COF-001")
+		require.ErrorIs(t, err, ErrProductNotFound)
+		require.ErrorIs(t, notFoundErr, ErrProductNotFound)
+	})
+}
+
+func TestProductRepo_GetProducts_Sorted_By_SKU(t *testing.T) {
+	forEachProductRepo(t, func(t *testing.T, repo ProductRepository) {
+		// Given
+		for _, product := range []models.Product{testCatalogCoffee, testCatalogMug} {
+			_, err := repo.CreateProduct(product)
+			require.NoError(t, err)
+		}
+
+		// When
+		products, err := repo.GetProducts()
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, []models.Product{testCatalogMug, testCatalogCoffee}, products)
+	})
+}
+
+func TestFileProductRepo_Recovers_After_Restart(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	repo, err := NewFileProductRepo(dir, 2)
+	require.NoError(t, err)
+	for _, product := range []models.Product{testCatalogCoffee, testCatalogMug} {
+		_, err := repo.CreateProduct(product)
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.DeleteProduct(testCatalogMug.SKU))
+	require.NoError(t, repo.Close())
+
+	// When
+	reopened, err := NewFileProductRepo(dir, 2)
+	require.NoError(t, err)
+	defer reopened.Close()
+	products, err := reopened.GetProducts()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []models.Product{testCatalogCoffee}, products)
+}
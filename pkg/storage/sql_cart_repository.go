@@ -0,0 +1,685 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+
+	_ "modernc.org/sqlite"
+)
+
+type sqlCartRepo struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens, creating it if needed, the SQLite database at path and brings its schema up to date.
+func OpenSQLite(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%v?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)", path))
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer, serializing connections avoids "database is locked" errors
+	db.SetMaxOpenConns(1)
+
+	if err := Migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func NewSQLCartRepo(db *sql.DB) CartRepository {
+	return &sqlCartRepo{
+		db: db,
+	}
+}
+
+func (s *sqlCartRepo) GetCartByID(cartID string) (models.Cart, error) {
+	return getCartByID(s.db, cartID)
+}
+
+func (s *sqlCartRepo) GetCartByUserID(userID string) (models.Cart, error) {
+	return getCartByUserID(s.db, userID)
+}
+
+func (s *sqlCartRepo) GetCartsByUserID(userID string) ([]models.Cart, error) {
+	rows, err := s.db.Query(`SELECT id FROM carts WHERE user_id = ? AND user_id <> '' AND checked_out = 0
+		ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var cartIDs []string
+	for rows.Next() {
+		var cartID string
+		if err := rows.Scan(&cartID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		cartIDs = append(cartIDs, cartID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	carts := make([]models.Cart, 0, len(cartIDs))
+	for _, cartID := range cartIDs {
+		cart, err := getCartByID(s.db, cartID)
+		if err != nil {
+			return nil, err
+		}
+		carts = append(carts, cart)
+	}
+
+	return carts, nil
+}
+
+func (s *sqlCartRepo) GetCartByGuestToken(token string) (models.Cart, error) {
+	return getCart(s.db, `SELECT id, user_id, guest_token, name, checked_out, currency, exchange_rate_from, exchange_rate, email,
+			delivery_method, created_at
+		FROM carts WHERE guest_token = ? AND checked_out = 0`, token, guestCartNotFound())
+}
+
+func (s *sqlCartRepo) CreateCart(userID string, cartToCreate models.Cart) (models.Cart, error) {
+	var createdCart models.Cart
+	err := s.withTx(func(tx *sql.Tx) error {
+		rateFrom, rate := rateColumns(cartToCreate.ExchangeRate)
+		if _, err := tx.Exec(`INSERT INTO carts (id, user_id, guest_token, name, currency, exchange_rate_from, exchange_rate,
+				created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			cartToCreate.ID, userID, guestTokenColumn(cartToCreate.GuestToken), cartToCreate.Name, cartCurrency(cartToCreate),
+			rateFrom, rate, cartToCreate.CreatedAt); err != nil {
+			return err
+		}
+
+		for _, item := range cartToCreate.Items {
+			if err := insertLineItem(tx, cartToCreate.ID, item); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		createdCart, err = getCartByID(tx, cartToCreate.ID)
+		return err
+	})
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	return createdCart, nil
+}
+
+func (s *sqlCartRepo) AddProduct(cartID string, product models.Product, quantity int) (models.Cart, error) {
+	var updatedCart models.Cart
+	err := s.withTx(func(tx *sql.Tx) error {
+		if _, err := getOpenCartByID(tx, cartID); err != nil {
+			return err
+		}
+
+		if err := insertLineItem(tx, cartID, models.LineItem{Product: product, Quantity: quantity}); err != nil {
+			return err
+		}
+
+		var err error
+		updatedCart, err = getCartByID(tx, cartID)
+		return err
+	})
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	return updatedCart, nil
+}
+
+func (s *sqlCartRepo) UpdateProductQuantity(cartID, product string, quantity int) (models.Cart, error) {
+	var updatedCart models.Cart
+	err := s.withTx(func(tx *sql.Tx) error {
+		if _, err := getOpenCartByID(tx, cartID); err != nil {
+			return err
+		}
+
+		var res sql.Result
+		var err error
+		if quantity == 0 {
+			res, err = tx.Exec(`DELETE FROM line_items WHERE cart_id = ? AND name = ? AND free = 0`, cartID, product)
+		} else {
+			res, err = tx.Exec(`UPDATE line_items SET quantity = ? WHERE cart_id = ? AND name = ? AND free = 0`, quantity, cartID, product)
+		}
+		if err != nil {
+			return err
+		}
+
+		if updated, err := res.RowsAffected(); err != nil {
+			return err
+		} else if updated == 0 {
+			return productNotInCart(product)
+		}
+
+		updatedCart, err = getCartByID(tx, cartID)
+		return err
+	})
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	return updatedCart, nil
+}
+
+func (s *sqlCartRepo) RemoveProduct(cartID, product string) (models.Cart, error) {
+	return s.UpdateProductQuantity(cartID, product, 0)
+}
+
+func (s *sqlCartRepo) SetFreeItems(cartID string, items []models.LineItem) (models.Cart, error) {
+	var updatedCart models.Cart
+	err := s.withTx(func(tx *sql.Tx) error {
+		if _, err := getOpenCartByID(tx, cartID); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`DELETE FROM line_items WHERE cart_id = ? AND free = 1`, cartID); err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if err := insertLineItem(tx, cartID, item); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		updatedCart, err = getCartByID(tx, cartID)
+		return err
+	})
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	return updatedCart, nil
+}
+
+func (s *sqlCartRepo) UpdateCheckout(cartID string, checkout models.Checkout) (models.Cart, error) {
+	var updatedCart models.Cart
+	err := s.withTx(func(tx *sql.Tx) error {
+		userCart, err := getOpenCartByID(tx, cartID)
+		if err != nil {
+			return err
+		}
+
+		merged := mergeCheckout(userCart.Checkout, checkout)
+		if _, err := tx.Exec(`UPDATE carts SET email = ?, delivery_method = ? WHERE id = ?`,
+			merged.Email, merged.DeliveryMethod, cartID); err != nil {
+			return err
+		}
+
+		if err := saveAddresses(tx, cartAddresses, cartID, merged); err != nil {
+			return err
+		}
+
+		updatedCart, err = getCartByID(tx, cartID)
+		return err
+	})
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	return updatedCart, nil
+}
+
+func (s *sqlCartRepo) AddCoupon(cartID, code string) (models.Cart, error) {
+	var updatedCart models.Cart
+	err := s.withTx(func(tx *sql.Tx) error {
+		if _, err := getOpenCartByID(tx, cartID); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`INSERT INTO cart_coupons (cart_id, position, code)
+			SELECT ?, COALESCE(MAX(position), 0) + 1, ? FROM cart_coupons WHERE cart_id = ?
+			ON CONFLICT (cart_id, code) DO NOTHING`, cartID, code, cartID)
+		if err != nil {
+			return err
+		}
+
+		updatedCart, err = getCartByID(tx, cartID)
+		return err
+	})
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	return updatedCart, nil
+}
+
+func (s *sqlCartRepo) RemoveCoupon(cartID, code string) (models.Cart, error) {
+	var updatedCart models.Cart
+	err := s.withTx(func(tx *sql.Tx) error {
+		if _, err := getOpenCartByID(tx, cartID); err != nil {
+			return err
+		}
+
+		res, err := tx.Exec(`DELETE FROM cart_coupons WHERE cart_id = ? AND code = ?`, cartID, code)
+		if err != nil {
+			return err
+		}
+
+		if removed, err := res.RowsAffected(); err != nil {
+			return err
+		} else if removed == 0 {
+			return couponNotInCart(code)
+		}
+
+		updatedCart, err = getCartByID(tx, cartID)
+		return err
+	})
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	return updatedCart, nil
+}
+
+func (s *sqlCartRepo) CheckoutCart(cartID string) (models.Cart, error) {
+	var checkedOutCart models.Cart
+	err := s.withTx(func(tx *sql.Tx) error {
+		if _, err := getOpenCartByID(tx, cartID); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`UPDATE carts SET checked_out = 1 WHERE id = ?`, cartID); err != nil {
+			return err
+		}
+
+		var err error
+		checkedOutCart, err = getCartByID(tx, cartID)
+		return err
+	})
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	return checkedOutCart, nil
+}
+
+func (s *sqlCartRepo) ReopenCart(cartID string) (models.Cart, error) {
+	var reopenedCart models.Cart
+	err := s.withTx(func(tx *sql.Tx) error {
+		if _, err := getCartByID(tx, cartID); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`UPDATE carts SET checked_out = 0 WHERE id = ?`, cartID); err != nil {
+			return err
+		}
+
+		var err error
+		reopenedCart, err = getCartByID(tx, cartID)
+		return err
+	})
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	return reopenedCart, nil
+}
+
+func (s *sqlCartRepo) RenameCart(cartID, name string) (models.Cart, error) {
+	var renamedCart models.Cart
+	err := s.withTx(func(tx *sql.Tx) error {
+		if _, err := getOpenCartByID(tx, cartID); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`UPDATE carts SET name = ? WHERE id = ?`, name, cartID); err != nil {
+			return err
+		}
+
+		var err error
+		renamedCart, err = getCartByID(tx, cartID)
+		return err
+	})
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	return renamedCart, nil
+}
+
+// DeleteCart deletes the cart along with its line items, addresses and coupons.
+func (s *sqlCartRepo) DeleteCart(cartID string) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		if _, err := getOpenCartByID(tx, cartID); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`DELETE FROM carts WHERE id = ?`, cartID)
+		return err
+	})
+}
+
+func (s *sqlCartRepo) MergeCart(guestCartID, userID string, merge MergeFunc) (models.Cart, error) {
+	var mergedCart models.Cart
+	err := s.withTx(func(tx *sql.Tx) error {
+		guestCart, err := getOpenCartByID(tx, guestCartID)
+		if err != nil {
+			return err
+		}
+
+		userCart, err := getCartByUserID(tx, userID)
+		if err != nil && !errors.Is(err, ErrCartNotFound) {
+			return err
+		}
+
+		merged, err := merge(userCart, guestCart)
+		if err != nil {
+			return err
+		}
+
+		// The line items, addresses and coupons of the guest cart go along with it
+		if merged.ID != guestCartID {
+			if _, err := tx.Exec(`DELETE FROM carts WHERE id = ?`, guestCartID); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(`UPDATE carts SET user_id = ?, guest_token = ?, name = ?, email = ?, delivery_method = ?
+			WHERE id = ?`,
+			merged.UserID, guestTokenColumn(merged.GuestToken), merged.Name, merged.Checkout.Email,
+			merged.Checkout.DeliveryMethod, merged.ID); err != nil {
+			return err
+		}
+
+		if err := saveAddresses(tx, cartAddresses, merged.ID, merged.Checkout); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`DELETE FROM line_items WHERE cart_id = ?`, merged.ID); err != nil {
+			return err
+		}
+		for _, item := range merged.Items {
+			if err := insertLineItem(tx, merged.ID, item); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(`DELETE FROM cart_coupons WHERE cart_id = ?`, merged.ID); err != nil {
+			return err
+		}
+		for i, code := range merged.Coupons {
+			if _, err := tx.Exec(`INSERT INTO cart_coupons (cart_id, position, code) VALUES (?, ?, ?)`, merged.ID, i+1, code); err != nil {
+				return err
+			}
+		}
+
+		mergedCart, err = getCartByID(tx, merged.ID)
+		return err
+	})
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	return mergedCart, nil
+}
+
+func (s *sqlCartRepo) withTx(fn func(tx *sql.Tx) error) error {
+	return withTx(s.db, fn)
+}
+
+func withTx(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func getCartByID(q queryer, cartID string) (models.Cart, error) {
+	return getCart(q, `SELECT id, user_id, guest_token, name, checked_out, currency, exchange_rate_from, exchange_rate, email,
+			delivery_method, created_at
+		FROM carts WHERE id = ?`, cartID, cartNotFound(cartID))
+}
+
+// getOpenCartByID returns the cart as long as it can still be changed.
+func getOpenCartByID(q queryer, cartID string) (models.Cart, error) {
+	cart, err := getCartByID(q, cartID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	if cart.CheckedOut {
+		return models.Cart{}, cartCheckedOut(cartID)
+	}
+
+	return cart, nil
+}
+
+func getCartByUserID(q queryer, userID string) (models.Cart, error) {
+	return getCart(q, `SELECT id, user_id, guest_token, name, checked_out, currency, exchange_rate_from, exchange_rate, email,
+			delivery_method, created_at
+		FROM carts WHERE user_id = ? AND user_id <> '' AND checked_out = 0 ORDER BY created_at DESC, id DESC LIMIT 1`, userID,
+		userCartNotFound(userID))
+}
+
+// getCart loads the cart matching the query, which must select its id, user_id, guest_token, name, checked_out,
+// currency, exchange_rate_from, exchange_rate, email, delivery_method and created_at, along with its line items,
+// addresses and coupons.
+func getCart(q queryer, query string, arg string, notFound error) (models.Cart, error) {
+	var cart models.Cart
+	var guestToken, rateFrom, rate sql.NullString
+	err := q.QueryRow(query, arg).Scan(&cart.ID, &cart.UserID, &guestToken, &cart.Name, &cart.CheckedOut, &cart.Currency,
+		&rateFrom, &rate, &cart.Checkout.Email, &cart.Checkout.DeliveryMethod, &cart.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Cart{}, notFound
+	}
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	cart.GuestToken = guestToken.String
+	cart.ExchangeRate, err = parseRateColumns(rateFrom, rate, cart.Currency)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	cart.Checkout.ShippingAddress, cart.Checkout.BillingAddress, err = getAddresses(q, cartAddresses, cart.ID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	cart.Coupons, err = getCoupons(q, cartCoupons, cart.ID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	// Free line items come after the products the user added
+	rows, err := q.Query(`SELECT sku, name, category, price, currency, weight, quantity, free, promotion_id FROM line_items
+		WHERE cart_id = ? ORDER BY free, position`, cart.ID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+	defer rows.Close()
+
+	cart.Items = []models.LineItem{}
+	for rows.Next() {
+		var item models.LineItem
+		if err := rows.Scan(&item.Product.SKU, &item.Product.Name, &item.Product.Category, &item.Product.Price.Amount,
+			&item.Product.Price.Currency, &item.Product.Weight, &item.Quantity, &item.Free, &item.PromotionID); err != nil {
+			return models.Cart{}, err
+		}
+		cart.Items = append(cart.Items, item)
+	}
+
+	return cart, rows.Err()
+}
+
+// insertLineItem adds the units of the line item to the cart, in a new line item at the end of the cart or in the
+// line item its product already has. Free line items only add up with the ones given away by the same promotion.
+func insertLineItem(tx *sql.Tx, cartID string, item models.LineItem) error {
+	product := item.Product
+	_, err := tx.Exec(`INSERT INTO line_items (cart_id, position, sku, name, category, price, currency, weight, quantity,
+			free, promotion_id)
+		SELECT ?, COALESCE(MAX(position), 0) + 1, ?, ?, ?, ?, ?, ?, ?, ?, ? FROM line_items WHERE cart_id = ?
+		ON CONFLICT (cart_id, name, promotion_id) DO UPDATE SET quantity = quantity + excluded.quantity`,
+		cartID, product.SKU, product.Name, product.Category, product.Price.Amount, product.Price.Currency, product.Weight,
+		item.Quantity, item.Free, item.PromotionID, cartID)
+	return err
+}
+
+// cartCurrency returns the currency the cart is stored with, the catalog one for the carts that don't say theirs.
+func cartCurrency(cart models.Cart) money.Currency {
+	if cart.Currency == "" {
+		return money.DefaultCurrency
+	}
+
+	return cart.Currency
+}
+
+// guestTokenColumn returns the guest token as it's stored, NULL for the carts of users.
+func guestTokenColumn(token string) sql.NullString {
+	return sql.NullString{String: token, Valid: token != ""}
+}
+
+// rateColumns returns the currency the rate converts from and its value as they're stored, both NULL if there's
+// no rate.
+func rateColumns(rate *money.Rate) (sql.NullString, sql.NullString) {
+	if rate == nil {
+		return sql.NullString{}, sql.NullString{}
+	}
+
+	return sql.NullString{String: string(rate.From), Valid: true}, sql.NullString{String: rate.Value(), Valid: true}
+}
+
+// parseRateColumns reads the rate stored by rateColumns, which converts into the given currency.
+func parseRateColumns(from, value sql.NullString, to money.Currency) (*money.Rate, error) {
+	if !from.Valid || !value.Valid {
+		return nil, nil
+	}
+
+	rate, err := money.ParseRate(money.Currency(from.String), to, value.String)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rate, nil
+}
+
+// addressTable is a table of the addresses of carts or orders, keyed by the ID of their owner and their kind.
+type addressTable struct {
+	name  string
+	owner string
+}
+
+var (
+	cartAddresses  = addressTable{name: "cart_addresses", owner: "cart_id"}
+	orderAddresses = addressTable{name: "order_addresses", owner: "order_id"}
+)
+
+const (
+	shippingAddress = "shipping"
+	billingAddress  = "billing"
+)
+
+// saveAddresses stores the addresses of the checkout, replacing the ones of the same kind the owner had.
+func saveAddresses(tx *sql.Tx, table addressTable, ownerID any, checkout models.Checkout) error {
+	addresses := []struct {
+		kind    string
+		address *models.Address
+	}{
+		{kind: shippingAddress, address: checkout.ShippingAddress},
+		{kind: billingAddress, address: checkout.BillingAddress},
+	}
+
+	for _, a := range addresses {
+		if a.address == nil {
+			continue
+		}
+
+		_, err := tx.Exec(fmt.Sprintf(`INSERT INTO %v (%v, kind, name, line1, line2, city, region, postal_code, country)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (%v, kind) DO UPDATE SET name = excluded.name, line1 = excluded.line1, line2 = excluded.line2,
+				city = excluded.city, region = excluded.region, postal_code = excluded.postal_code, country = excluded.country`,
+			table.name, table.owner, table.owner),
+			ownerID, a.kind, a.address.Name, a.address.Line1, a.address.Line2, a.address.City, a.address.Region,
+			a.address.PostalCode, a.address.Country)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getAddresses returns the shipping and billing addresses of the owner, nil if it doesn't have them.
+func getAddresses(q queryer, table addressTable, ownerID any) (*models.Address, *models.Address, error) {
+	rows, err := q.Query(fmt.Sprintf(`SELECT kind, name, line1, line2, city, region, postal_code, country FROM %v
+		WHERE %v = ?`, table.name, table.owner), ownerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var shipping, billing *models.Address
+	for rows.Next() {
+		var kind string
+		var address models.Address
+		if err := rows.Scan(&kind, &address.Name, &address.Line1, &address.Line2, &address.City, &address.Region,
+			&address.PostalCode, &address.Country); err != nil {
+			return nil, nil, err
+		}
+
+		if kind == shippingAddress {
+			shipping = &address
+		} else {
+			billing = &address
+		}
+	}
+
+	return shipping, billing, rows.Err()
+}
+
+// couponTable is a table of the codes of the coupons applied to carts or redeemed by orders, keyed by the ID of their
+// owner.
+type couponTable struct {
+	name  string
+	owner string
+}
+
+var (
+	cartCoupons  = couponTable{name: "cart_coupons", owner: "cart_id"}
+	orderCoupons = couponTable{name: "order_coupons", owner: "order_id"}
+)
+
+// getCoupons returns the codes of the coupons of the owner in the order they were applied, nil if it has none.
+func getCoupons(q queryer, table couponTable, ownerID any) ([]string, error) {
+	rows, err := q.Query(fmt.Sprintf(`SELECT code FROM %v WHERE %v = ? ORDER BY position`, table.name, table.owner), ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []string
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+
+	return codes, rows.Err()
+}
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+	"time"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "carts.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrate_Is_Idempotent(t *testing.T) {
+	// Given
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "carts.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+
+	// When
+	require.NoError(t, Migrate(db))
+	require.NoError(t, Migrate(db))
+
+	// Then
+	var applied, version int
+	err = db.QueryRow(`SELECT COUNT(*), MAX(version) FROM schema_migrations`).Scan(&applied, &version)
+	require.NoError(t, err)
+	require.Equal(t, len(migrations), applied)
+	require.Equal(t, migrations[len(migrations)-1].version, version)
+}
+
+func TestSQLCartRepo_Line_Items_Are_Queryable(t *testing.T) {
+	// Given
+	db := newTestDB(t)
+	repo := NewSQLCartRepo(db)
+	_, err := repo.CreateCart("user1", models.Cart{ID: "cart1", UserID: "user1"})
+	require.NoError(t, err)
+	_, err = repo.AddProduct("cart1", testCoffee, 1)
+	require.NoError(t, err)
+	_, err = repo.UpdateProductQuantity("cart1", testCoffee.Name, 3)
+	require.NoError(t, err)
+
+	// When
+	var quantity, total int
+	err = db.QueryRow(`SELECT SUM(quantity), SUM(price * quantity) FROM line_items WHERE cart_id = ? AND category = ?`,
+		"cart1", models.CoffeeCategory).Scan(&quantity, &total)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 3, quantity)
+	require.Equal(t, 3000, total, "prices are stored in cents")
+}
+
+func TestSQLCartRepo_CreateCart_Error(t *testing.T) {
+	// Given
+	repo := NewSQLCartRepo(newTestDB(t))
+	_, err := repo.CreateCart("user1", models.Cart{ID: "cart1", UserID: "user1"})
+	require.NoError(t, err)
+
+	// When
+	_, err = repo.CreateCart("user2", models.Cart{ID: "cart1", UserID: "user2"})
+
+	// Then
+	require.Error(t, err)
+	userCart, err := repo.GetCartByID("cart1")
+	require.NoError(t, err)
+	require.Equal(t, "user1", userCart.UserID)
+}
+
+func TestMigrate_Merges_Duplicated_Line_Items(t *testing.T) {
+	// Given a database with one row per unit, as it was before line items had a quantity
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "carts.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)`)
+	require.NoError(t, err)
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	for _, m := range migrations[:2] {
+		require.NoError(t, applyMigration(context.Background(), conn, m))
+	}
+	require.NoError(t, conn.Close())
+	_, err = db.Exec(`INSERT INTO carts (id, user_id) VALUES ('cart1', 'user1');
+		INSERT INTO line_items (cart_id, position, name, category, price) VALUES
+			('cart1', 1, 'coffee1', 'coffee', 10),
+			('cart1', 2, 'mug', 'accessories', 5),
+			('cart1', 3, 'coffee1', 'coffee', 10);`)
+	require.NoError(t, err)
+
+	// When
+	err = Migrate(db)
+
+	// Then
+	require.NoError(t, err)
+	cart, err := getCartByID(db, "cart1")
+	require.NoError(t, err)
+	require.Equal(t, []models.LineItem{
+		{Product: models.Product{Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 2},
+		{Product: models.Product{Name: "mug", Category: models.AccessoriesCategory, Price: usd(5)}, Quantity: 1},
+	}, cart.Items)
+}
+
+func TestMigrate_Starts_Order_History_As_Pending(t *testing.T) {
+	// Given a database with an order placed before orders had a status
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "carts.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)`)
+	require.NoError(t, err)
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	for _, m := range migrations[:5] {
+		require.NoError(t, applyMigration(context.Background(), conn, m))
+	}
+	require.NoError(t, conn.Close())
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	_, err = db.Exec(`INSERT INTO carts (id, user_id, checked_out) VALUES ('cart1', 'user1', 1)`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO orders (id, cart_id, user_id, products, discounts, shipping, price, created_at)
+		VALUES (1, 'cart1', 'user1', 1, 0, 20, 30, ?)`, createdAt)
+	require.NoError(t, err)
+
+	// When
+	err = Migrate(db)
+
+	// Then
+	require.NoError(t, err)
+	order, err := getOrder(db, 1)
+	require.NoError(t, err)
+	require.Equal(t, models.OrderPending, order.Status)
+	require.Len(t, order.History, 1)
+	require.Equal(t, models.OrderPending, order.History[0].Status)
+	require.True(t, createdAt.Equal(order.History[0].At))
+}
+
+func TestMigrate_Stores_Amounts_In_Minor_Units(t *testing.T) {
+	// Given a database with a product and an order priced in whole dollars, as they were before amounts had a currency
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "carts.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)`)
+	require.NoError(t, err)
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	for _, m := range migrations[:8] {
+		require.NoError(t, applyMigration(context.Background(), conn, m))
+	}
+	require.NoError(t, conn.Close())
+	_, err = db.Exec(`INSERT INTO products (sku, name, category, price) VALUES ('COF-001', 'coffee1', 'coffee', 15);
+		INSERT INTO carts (id, user_id, checked_out) VALUES ('cart1', 'user1', 1);
+		INSERT INTO orders (id, cart_id, user_id, products, discounts, shipping, price) VALUES (1, 'cart1', 'user1', 2, 3, 20, 27);
+		INSERT INTO order_items (order_id, position, sku, name, category, price, quantity) VALUES (1, 1, 'COF-001', 'coffee1', 'coffee', 15, 2);`)
+	require.NoError(t, err)
+
+	// When
+	err = Migrate(db)
+
+	// Then
+	require.NoError(t, err)
+	product, err := getProduct(db, "COF-001")
+	require.NoError(t, err)
+	require.Equal(t, usd(15), product.Price)
+	order, err := getOrder(db, 1)
+	require.NoError(t, err)
+	require.Equal(t, models.Total{Products: 2, Discounts: usd(3), Shipping: usd(20), ShippingMethod: "standard",
+		ShippingRate: usd(20), Tax: usd(0), Order: 1, Price: usd(27)}, order.Totals)
+	require.Equal(t, usd(15), order.Items[0].Product.Price)
+}
+
+func TestMigrate_Flags_The_Extra_Coffee_As_Free(t *testing.T) {
+	// Given a database with the extra coffee added like any other product, as it was before line items could be free
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "carts.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)`)
+	require.NoError(t, err)
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	for _, m := range migrations[:15] {
+		require.NoError(t, applyMigration(context.Background(), conn, m))
+	}
+	require.NoError(t, conn.Close())
+	_, err = db.Exec(`INSERT INTO carts (id, user_id) VALUES ('cart1', 'user1');
+		INSERT INTO line_items (cart_id, position, sku, name, category, price, quantity) VALUES
+			('cart1', 1, 'COF-001', 'coffee1', 'coffee', 1000, 2),
+			('cart1', 2, '', 'extraCoffee', 'coffee', 0, 1);`)
+	require.NoError(t, err)
+
+	// When
+	err = Migrate(db)
+
+	// Then
+	require.NoError(t, err)
+	cart, err := getCartByID(db, "cart1")
+	require.NoError(t, err)
+	require.Equal(t, []models.LineItem{
+		{Product: models.Product{SKU: "COF-001", Name: "coffee1", Category: models.CoffeeCategory, Price: usd(10)}, Quantity: 2},
+		{Product: models.Product{Name: "extraCoffee", Category: models.CoffeeCategory, Price: usd(0)}, Quantity: 1, Free: true, PromotionID: "extra-coffee"},
+	}, cart.Items)
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+type sqlCouponRepo struct {
+	db *sql.DB
+}
+
+func NewSQLCouponRepo(db *sql.DB) CouponRepository {
+	return &sqlCouponRepo{
+		db: db,
+	}
+}
+
+func (s *sqlCouponRepo) CreateCoupon(coupon models.Coupon) (models.Coupon, error) {
+	err := withTx(s.db, func(tx *sql.Tx) error {
+		if _, err := getCoupon(tx, coupon.Code); err == nil {
+			return couponAlreadyExists(coupon.Code)
+		}
+
+		_, err := tx.Exec(`INSERT INTO coupons (code, effect, percent, amount, amount_currency, category, min_subtotal,
+				min_subtotal_currency, starts_at, ends_at, max_redemptions, max_redemptions_per_user, redemptions)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			coupon.Code, coupon.Effect, coupon.Percent, coupon.Amount.Amount, coupon.Amount.Currency, coupon.Category,
+			coupon.MinSubtotal.Amount, coupon.MinSubtotal.Currency, timeColumn(coupon.StartsAt), timeColumn(coupon.EndsAt),
+			coupon.MaxRedemptions, coupon.MaxRedemptionsPerUser, coupon.Redemptions)
+		return err
+	})
+	if err != nil {
+		return models.Coupon{}, err
+	}
+
+	return cloneCoupon(coupon), nil
+}
+
+func (s *sqlCouponRepo) GetCoupon(code string) (models.Coupon, error) {
+	return getCoupon(s.db, code)
+}
+
+func (s *sqlCouponRepo) CountUserRedemptions(code, userID string) (int, error) {
+	return countUserRedemptions(s.db, code, userID)
+}
+
+func (s *sqlCouponRepo) Redeem(orderID int, redemptions []models.Redemption) error {
+	return withTx(s.db, func(tx *sql.Tx) error {
+		var redeemed int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM coupon_redemptions WHERE order_id = ?`, orderID).Scan(&redeemed); err != nil {
+			return err
+		}
+		if redeemed > 0 {
+			return redemptionAlreadyExists(orderID)
+		}
+
+		for _, redemption := range redemptions {
+			coupon, err := getCoupon(tx, redemption.Code)
+			if err != nil {
+				return err
+			}
+
+			if coupon.MaxRedemptions > 0 && coupon.Redemptions >= coupon.MaxRedemptions {
+				return couponExhausted(coupon.Code)
+			}
+
+			if coupon.MaxRedemptionsPerUser > 0 {
+				count, err := countUserRedemptions(tx, coupon.Code, redemption.UserID)
+				if err != nil {
+					return err
+				}
+				if count >= coupon.MaxRedemptionsPerUser {
+					return couponExhaustedByUser(coupon.Code, redemption.UserID)
+				}
+			}
+
+			if _, err := tx.Exec(`UPDATE coupons SET redemptions = redemptions + 1 WHERE code = ?`, coupon.Code); err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(`INSERT INTO coupon_redemptions (order_id, code, user_id) VALUES (?, ?, ?)`,
+				orderID, coupon.Code, redemption.UserID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *sqlCouponRepo) ReleaseRedemptions(orderID int) error {
+	return withTx(s.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`UPDATE coupons SET redemptions = redemptions - 1
+			WHERE code IN (SELECT code FROM coupon_redemptions WHERE order_id = ?)`, orderID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`DELETE FROM coupon_redemptions WHERE order_id = ?`, orderID)
+		return err
+	})
+}
+
+func getCoupon(q queryer, code string) (models.Coupon, error) {
+	var coupon models.Coupon
+	var startsAt, endsAt sql.NullInt64
+	err := q.QueryRow(`SELECT code, effect, percent, amount, amount_currency, category, min_subtotal, min_subtotal_currency,
+			starts_at, ends_at, max_redemptions, max_redemptions_per_user, redemptions
+		FROM coupons WHERE code = ?`, code).
+		Scan(&coupon.Code, &coupon.Effect, &coupon.Percent, &coupon.Amount.Amount, &coupon.Amount.Currency, &coupon.Category,
+			&coupon.MinSubtotal.Amount, &coupon.MinSubtotal.Currency, &startsAt, &endsAt, &coupon.MaxRedemptions,
+			&coupon.MaxRedemptionsPerUser, &coupon.Redemptions)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Coupon{}, couponNotFound(code)
+	}
+	if err != nil {
+		return models.Coupon{}, err
+	}
+
+	coupon.StartsAt, coupon.EndsAt = parseTimeColumn(startsAt), parseTimeColumn(endsAt)
+	return coupon, nil
+}
+
+func countUserRedemptions(q queryer, code, userID string) (int, error) {
+	var count int
+	err := q.QueryRow(`SELECT COUNT(*) FROM coupon_redemptions WHERE code = ? AND user_id = ?`, code, userID).Scan(&count)
+	return count, err
+}
+
+// timeColumn returns the time as it's stored, in milliseconds since the Unix epoch, or NULL if there's no time.
+func timeColumn(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{Int64: t.UnixMilli(), Valid: true}
+}
+
+// parseTimeColumn reads the time stored by timeColumn.
+func parseTimeColumn(value sql.NullInt64) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+
+	t := time.UnixMilli(value.Int64).UTC()
+	return &t
+}
@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"trafilea-tech-challenge/pkg/models"
+)
+
+type sqlInventoryRepo struct {
+	db *sql.DB
+}
+
+func NewSQLInventoryRepo(db *sql.DB) InventoryRepository {
+	return &sqlInventoryRepo{
+		db: db,
+	}
+}
+
+func (s *sqlInventoryRepo) SetStock(sku string, onHand int) (models.Stock, error) {
+	var stock models.Stock
+	err := withTx(s.db, func(tx *sql.Tx) error {
+		var err error
+		stock, err = getStock(tx, sku)
+		if err != nil {
+			return err
+		}
+
+		if onHand < stock.Reserved {
+			return stockReserved(sku, stock.Reserved)
+		}
+
+		stock.OnHand = onHand
+		_, err = tx.Exec(`INSERT INTO stock (sku, on_hand) VALUES (?, ?)
+			ON CONFLICT (sku) DO UPDATE SET on_hand = excluded.on_hand`, sku, onHand)
+		return err
+	})
+	if err != nil {
+		return models.Stock{}, err
+	}
+
+	return stock, nil
+}
+
+func (s *sqlInventoryRepo) GetStock(sku string) (models.Stock, error) {
+	return getStock(s.db, sku)
+}
+
+func (s *sqlInventoryRepo) CreateReservation(reservation models.Reservation) error {
+	return withTx(s.db, func(tx *sql.Tx) error {
+		if _, err := getReservation(tx, reservation.OrderID); err == nil {
+			return reservationAlreadyExists(reservation.OrderID)
+		}
+
+		_, err := tx.Exec(`INSERT INTO reservations (order_id, expires_at) VALUES (?, ?)`,
+			reservation.OrderID, reservation.ExpiresAt.UnixMilli())
+		if err != nil {
+			return err
+		}
+
+		for _, sku := range sortedSKUs(reservation.Items) {
+			quantity := reservation.Items[sku]
+			res, err := tx.Exec(`UPDATE stock SET reserved = reserved + ? WHERE sku = ? AND on_hand - reserved >= ?`,
+				quantity, sku, quantity)
+			if err != nil {
+				return err
+			}
+
+			if updated, err := res.RowsAffected(); err != nil {
+				return err
+			} else if updated == 0 {
+				stock, err := getStock(tx, sku)
+				if err != nil {
+					return err
+				}
+				return insufficientStock(sku, stock.Available())
+			}
+
+			_, err = tx.Exec(`INSERT INTO reservation_items (order_id, sku, quantity) VALUES (?, ?, ?)`,
+				reservation.OrderID, sku, quantity)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *sqlInventoryRepo) CommitReservation(orderID int) error {
+	return s.settle(orderID, true)
+}
+
+func (s *sqlInventoryRepo) ReleaseReservation(orderID int) error {
+	return s.settle(orderID, false)
+}
+
+func (s *sqlInventoryRepo) GetReservation(orderID int) (models.Reservation, error) {
+	return getReservation(s.db, orderID)
+}
+
+func (s *sqlInventoryRepo) GetReservationsExpiredAt(now time.Time) ([]models.Reservation, error) {
+	rows, err := s.db.Query(`SELECT order_id FROM reservations WHERE expires_at <= ? ORDER BY expires_at, order_id`, now.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+
+	var orderIDs []int
+	for rows.Next() {
+		var orderID int
+		if err := rows.Scan(&orderID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	reservations := make([]models.Reservation, 0, len(orderIDs))
+	for _, orderID := range orderIDs {
+		reservation, err := getReservation(s.db, orderID)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, reservation)
+	}
+
+	return reservations, nil
+}
+
+// settle removes the reservation of the order, taking its units out of the stock when they've been sold.
+func (s *sqlInventoryRepo) settle(orderID int, sold bool) error {
+	return withTx(s.db, func(tx *sql.Tx) error {
+		reservation, err := getReservation(tx, orderID)
+		if err != nil {
+			return err
+		}
+
+		for sku, quantity := range reservation.Items {
+			unitsSold := 0
+			if sold {
+				unitsSold = quantity
+			}
+
+			_, err := tx.Exec(`UPDATE stock SET on_hand = on_hand - ?, reserved = reserved - ? WHERE sku = ?`, unitsSold, quantity, sku)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec(`DELETE FROM reservations WHERE order_id = ?`, orderID)
+		return err
+	})
+}
+
+func getStock(q queryer, sku string) (models.Stock, error) {
+	stock := models.Stock{SKU: sku}
+	err := q.QueryRow(`SELECT on_hand, reserved FROM stock WHERE sku = ?`, sku).Scan(&stock.OnHand, &stock.Reserved)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return models.Stock{}, err
+	}
+
+	return stock, nil
+}
+
+func getReservation(q queryer, orderID int) (models.Reservation, error) {
+	reservation := models.Reservation{OrderID: orderID, Items: make(map[string]int)}
+	var expiresAt int64
+	err := q.QueryRow(`SELECT expires_at FROM reservations WHERE order_id = ?`, orderID).Scan(&expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Reservation{}, reservationNotFound(orderID)
+	}
+	if err != nil {
+		return models.Reservation{}, err
+	}
+	reservation.ExpiresAt = time.UnixMilli(expiresAt).UTC()
+
+	rows, err := q.Query(`SELECT sku, quantity FROM reservation_items WHERE order_id = ?`, orderID)
+	if err != nil {
+		return models.Reservation{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sku string
+		var quantity int
+		if err := rows.Scan(&sku, &quantity); err != nil {
+			return models.Reservation{}, err
+		}
+		reservation.Items[sku] = quantity
+	}
+
+	return reservation, rows.Err()
+}
@@ -0,0 +1,310 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+)
+
+type sqlOrderRepo struct {
+	db *sql.DB
+}
+
+func NewSQLOrderRepo(db *sql.DB) OrderRepository {
+	return &sqlOrderRepo{
+		db: db,
+	}
+}
+
+func (s *sqlOrderRepo) CreateOrder(order models.Order) (models.Order, error) {
+	err := withTx(s.db, func(tx *sql.Tx) error {
+		if _, err := getOrder(tx, order.Totals.Order); err == nil {
+			return orderAlreadyExists(order.Totals.Order)
+		}
+
+		rateFrom, rate := rateColumns(order.ExchangeRate)
+		_, err := tx.Exec(`INSERT INTO orders (id, cart_id, user_id, products, discounts, shipping, price, currency, status, created_at,
+				exchange_rate_from, exchange_rate, tax, tax_inclusive, tax_region, shipping_method, shipping_rate, email, delivery_method)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			order.Totals.Order, order.CartID, order.UserID, order.Totals.Products, order.Totals.Discounts.Amount,
+			order.Totals.Shipping.Amount, order.Totals.Price.Amount, order.Totals.Price.Currency, order.Status, order.CreatedAt,
+			rateFrom, rate, order.Totals.Tax.Amount, order.Totals.TaxInclusive, order.TaxRegion, order.Totals.ShippingMethod,
+			order.Totals.ShippingRate.Amount, order.Checkout.Email, order.Checkout.DeliveryMethod)
+		if err != nil {
+			return err
+		}
+
+		if err := saveAddresses(tx, orderAddresses, order.Totals.Order, order.Checkout); err != nil {
+			return err
+		}
+
+		for i, code := range order.Coupons {
+			_, err := tx.Exec(`INSERT INTO order_coupons (order_id, position, code) VALUES (?, ?, ?)`, order.Totals.Order, i+1, code)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := insertAdjustments(tx, order.Totals.Order, order.Totals.Adjustments); err != nil {
+			return err
+		}
+
+		for i, change := range order.History {
+			if err := insertStatusChange(tx, order.Totals.Order, i+1, change); err != nil {
+				return err
+			}
+		}
+
+		for i, item := range order.Items {
+			var tax sql.NullInt64
+			var taxRate sql.NullString
+			if item.Tax != nil {
+				tax = sql.NullInt64{Int64: item.Tax.Amount.Amount, Valid: true}
+				taxRate = sql.NullString{String: item.Tax.Rate, Valid: true}
+			}
+
+			_, err := tx.Exec(`INSERT INTO order_items (order_id, position, sku, name, category, price, currency, weight, quantity,
+					tax, tax_rate, free, promotion_id)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				order.Totals.Order, i+1, item.Product.SKU, item.Product.Name, item.Product.Category, item.Product.Price.Amount,
+				item.Product.Price.Currency, item.Product.Weight, item.Quantity, tax, taxRate, item.Free, item.PromotionID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	return order, nil
+}
+
+func (s *sqlOrderRepo) UpdateOrderStatus(orderID int, expected models.OrderStatus, change models.StatusChange) (models.Order, error) {
+	var updated models.Order
+	err := withTx(s.db, func(tx *sql.Tx) error {
+		order, err := getOrder(tx, orderID)
+		if err != nil {
+			return err
+		}
+
+		updated, err = changeStatus(order, expected, change)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`UPDATE orders SET status = ? WHERE id = ?`, change.Status, orderID); err != nil {
+			return err
+		}
+
+		return insertStatusChange(tx, orderID, len(updated.History), change)
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	return updated, nil
+}
+
+func (s *sqlOrderRepo) GetOrderByID(orderID int) (models.Order, error) {
+	return getOrder(s.db, orderID)
+}
+
+func (s *sqlOrderRepo) GetOrdersByUserID(userID string) ([]models.Order, error) {
+	rows, err := s.db.Query(`SELECT id FROM orders WHERE user_id = ? ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var orderIDs []int
+	for rows.Next() {
+		var orderID int
+		if err := rows.Scan(&orderID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	orders := make([]models.Order, 0, len(orderIDs))
+	for _, orderID := range orderIDs {
+		order, err := getOrder(s.db, orderID)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+func getOrder(q queryer, orderID int) (models.Order, error) {
+	order := models.Order{Totals: models.Total{Order: orderID}}
+	var currency money.Currency
+	var rateFrom, rate sql.NullString
+	err := q.QueryRow(`SELECT cart_id, user_id, products, discounts, shipping, price, currency, status, created_at,
+			exchange_rate_from, exchange_rate, tax, tax_inclusive, tax_region, shipping_method, shipping_rate, email, delivery_method
+		FROM orders WHERE id = ?`, orderID).
+		Scan(&order.CartID, &order.UserID, &order.Totals.Products, &order.Totals.Discounts.Amount, &order.Totals.Shipping.Amount,
+			&order.Totals.Price.Amount, &currency, &order.Status, &order.CreatedAt, &rateFrom, &rate, &order.Totals.Tax.Amount,
+			&order.Totals.TaxInclusive, &order.TaxRegion, &order.Totals.ShippingMethod, &order.Totals.ShippingRate.Amount,
+			&order.Checkout.Email, &order.Checkout.DeliveryMethod)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Order{}, orderNotFound(orderID)
+	}
+	if err != nil {
+		return models.Order{}, err
+	}
+	order.Totals.Discounts.Currency, order.Totals.Shipping.Currency, order.Totals.Price.Currency = currency, currency, currency
+	order.Totals.Tax.Currency, order.Totals.ShippingRate.Currency = currency, currency
+
+	order.ExchangeRate, err = parseRateColumns(rateFrom, rate, currency)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	order.Checkout.ShippingAddress, order.Checkout.BillingAddress, err = getAddresses(q, orderAddresses, orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	order.Coupons, err = getCoupons(q, orderCoupons, orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	rows, err := q.Query(`SELECT sku, name, category, price, currency, weight, quantity, tax, tax_rate, free, promotion_id
+		FROM order_items WHERE order_id = ? ORDER BY position`, orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	defer rows.Close()
+
+	order.Items = []models.LineItem{}
+	for rows.Next() {
+		var item models.LineItem
+		var tax sql.NullInt64
+		var taxRate sql.NullString
+		if err := rows.Scan(&item.Product.SKU, &item.Product.Name, &item.Product.Category, &item.Product.Price.Amount,
+			&item.Product.Price.Currency, &item.Product.Weight, &item.Quantity, &tax, &taxRate, &item.Free,
+			&item.PromotionID); err != nil {
+			return models.Order{}, err
+		}
+		if tax.Valid {
+			item.Tax = &models.Tax{Rate: taxRate.String, Amount: money.New(tax.Int64, currency)}
+		}
+		order.Items = append(order.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Order{}, err
+	}
+
+	order.History, err = getStatusChanges(q, orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	order.Totals.Adjustments, err = getAdjustments(q, orderID, currency)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	return order, nil
+}
+
+func insertAdjustments(tx *sql.Tx, orderID int, adjustments []models.Adjustment) error {
+	for i, adjustment := range adjustments {
+		_, err := tx.Exec(`INSERT INTO order_adjustments (order_id, position, adjustment_id, description, amount, shipping)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			orderID, i+1, adjustment.ID, adjustment.Description, adjustment.Amount.Amount, adjustment.Shipping)
+		if err != nil {
+			return err
+		}
+
+		for j, name := range adjustment.Items {
+			_, err := tx.Exec(`INSERT INTO order_adjustment_items (order_id, adjustment, position, name) VALUES (?, ?, ?, ?)`,
+				orderID, i+1, j+1, name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func getAdjustments(q queryer, orderID int, currency money.Currency) ([]models.Adjustment, error) {
+	rows, err := q.Query(`SELECT adjustment_id, description, amount, shipping FROM order_adjustments
+		WHERE order_id = ? ORDER BY position`, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	var adjustments []models.Adjustment
+	for rows.Next() {
+		adjustment := models.Adjustment{Amount: money.Zero(currency)}
+		if err := rows.Scan(&adjustment.ID, &adjustment.Description, &adjustment.Amount.Amount, &adjustment.Shipping); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		adjustments = append(adjustments, adjustment)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range adjustments {
+		rows, err := q.Query(`SELECT name FROM order_adjustment_items WHERE order_id = ? AND adjustment = ? ORDER BY position`,
+			orderID, i+1)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			adjustments[i].Items = append(adjustments[i].Items, name)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return adjustments, nil
+}
+
+func getStatusChanges(q queryer, orderID int) ([]models.StatusChange, error) {
+	rows, err := q.Query(`SELECT status, changed_at FROM order_status_changes WHERE order_id = ? ORDER BY position`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.StatusChange{}
+	for rows.Next() {
+		var change models.StatusChange
+		if err := rows.Scan(&change.Status, &change.At); err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+
+	return history, rows.Err()
+}
+
+func insertStatusChange(tx *sql.Tx, orderID, position int, change models.StatusChange) error {
+	_, err := tx.Exec(`INSERT INTO order_status_changes (order_id, position, status, changed_at) VALUES (?, ?, ?, ?)`,
+		orderID, position, change.Status, change.At)
+	return err
+}
@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"trafilea-tech-challenge/pkg/models"
+	"trafilea-tech-challenge/pkg/money"
+)
+
+type sqlProductRepo struct {
+	db *sql.DB
+}
+
+func NewSQLProductRepo(db *sql.DB) ProductRepository {
+	return &sqlProductRepo{
+		db: db,
+	}
+}
+
+func (s *sqlProductRepo) CreateProduct(product models.Product) (models.Product, error) {
+	err := withTx(s.db, func(tx *sql.Tx) error {
+		if _, err := getProduct(tx, product.SKU); err == nil {
+			return productAlreadyExists(product.SKU)
+		}
+
+		if err := checkProductName(tx, product); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`INSERT INTO products (sku, name, category, price, currency, weight) VALUES (?, ?, ?, ?, ?, ?)`,
+			product.SKU, product.Name, product.Category, product.Price.Amount, product.Price.Currency, product.Weight)
+		if err != nil {
+			return err
+		}
+
+		return savePrices(tx, product)
+	})
+	if err != nil {
+		return models.Product{}, err
+	}
+
+	return product, nil
+}
+
+func (s *sqlProductRepo) UpdateProduct(product models.Product) (models.Product, error) {
+	err := withTx(s.db, func(tx *sql.Tx) error {
+		if _, err := getProduct(tx, product.SKU); err != nil {
+			return err
+		}
+
+		if err := checkProductName(tx, product); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`UPDATE products SET name = ?, category = ?, price = ?, currency = ?, weight = ? WHERE sku = ?`,
+			product.Name, product.Category, product.Price.Amount, product.Price.Currency, product.Weight, product.SKU)
+		if err != nil {
+			return err
+		}
+
+		return savePrices(tx, product)
+	})
+	if err != nil {
+		return models.Product{}, err
+	}
+
+	return product, nil
+}
+
+func (s *sqlProductRepo) DeleteProduct(sku string) error {
+	res, err := s.db.Exec(`DELETE FROM products WHERE sku = ?`, sku)
+	if err != nil {
+		return err
+	}
+
+	if deleted, err := res.RowsAffected(); err != nil {
+		return err
+	} else if deleted == 0 {
+		return productNotFound(sku)
+	}
+
+	return nil
+}
+
+func (s *sqlProductRepo) GetProductBySKU(sku string) (models.Product, error) {
+	return getProduct(s.db, sku)
+}
+
+func (s *sqlProductRepo) GetProducts() ([]models.Product, error) {
+	rows, err := s.db.Query(`SELECT sku, name, category, price, currency, weight FROM products ORDER BY sku`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []models.Product{}
+	for rows.Next() {
+		var product models.Product
+		if err := rows.Scan(&product.SKU, &product.Name, &product.Category, &product.Price.Amount, &product.Price.Currency,
+			&product.Weight); err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	prices, err := getPrices(s.db, `SELECT sku, price, currency FROM product_prices ORDER BY sku, currency`)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range products {
+		products[i].Prices = prices[products[i].SKU]
+	}
+
+	return products, nil
+}
+
+func getProduct(q queryer, sku string) (models.Product, error) {
+	product := models.Product{SKU: sku}
+	err := q.QueryRow(`SELECT name, category, price, currency, weight FROM products WHERE sku = ?`, sku).
+		Scan(&product.Name, &product.Category, &product.Price.Amount, &product.Price.Currency, &product.Weight)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Product{}, productNotFound(sku)
+	}
+	if err != nil {
+		return models.Product{}, err
+	}
+
+	prices, err := getPrices(q, `SELECT sku, price, currency FROM product_prices WHERE sku = ? ORDER BY currency`, sku)
+	if err != nil {
+		return models.Product{}, err
+	}
+	product.Prices = prices[sku]
+
+	return product, nil
+}
+
+// getPrices loads the price lists matching the query, which must select the sku, price and currency of the prices,
+// by SKU.
+func getPrices(q queryer, query string, args ...any) (map[string][]money.Money, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := make(map[string][]money.Money)
+	for rows.Next() {
+		var sku string
+		var price money.Money
+		if err := rows.Scan(&sku, &price.Amount, &price.Currency); err != nil {
+			return nil, err
+		}
+		prices[sku] = append(prices[sku], price)
+	}
+
+	return prices, rows.Err()
+}
+
+// savePrices replaces the price list of the product with the one it has.
+func savePrices(tx *sql.Tx, product models.Product) error {
+	if _, err := tx.Exec(`DELETE FROM product_prices WHERE sku = ?`, product.SKU); err != nil {
+		return err
+	}
+
+	for _, price := range product.Prices {
+		_, err := tx.Exec(`INSERT INTO product_prices (sku, currency, price) VALUES (?, ?, ?)`, product.SKU, price.Currency, price.Amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkProductName tells whether no other product has the name of the product.
+func checkProductName(q queryer, product models.Product) error {
+	var sku string
+	err := q.QueryRow(`SELECT sku FROM products WHERE name = ? AND sku <> ?`, product.Name, product.SKU).Scan(&sku)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return productNameTaken(product.Name)
+}